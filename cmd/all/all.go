@@ -1,6 +1,8 @@
 package all
 
 import (
+	_ "github.com/internetarchive/Zeno/cmd/benchmark"
 	_ "github.com/internetarchive/Zeno/cmd/get"
+	_ "github.com/internetarchive/Zeno/cmd/verify"
 	_ "github.com/internetarchive/Zeno/cmd/version"
 )
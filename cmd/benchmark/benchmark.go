@@ -0,0 +1,100 @@
+package benchmark
+
+import (
+	"net/url"
+
+	"github.com/internetarchive/Zeno/cmd"
+	"github.com/internetarchive/Zeno/config"
+	"github.com/internetarchive/Zeno/internal/pkg/crawl"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/testsite"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	defaults := testsite.DefaultConfig()
+
+	cmd.RegisterCommand(
+		cli.Command{
+			Name:      "benchmark",
+			Usage:     "Crawl a local synthetic website to measure the throughput, CPU and memory usage of the current configuration.",
+			Action:    cmdBenchmark,
+			UsageText: "[ARGUMENTS]",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "depth",
+					Value: 3,
+					Usage: "Number of levels of pages below the home page of the synthetic website. --max-hops is raised to match it.",
+				},
+				&cli.IntFlag{
+					Name:  "links-per-page",
+					Value: defaults.LinksPerPage,
+					Usage: "Number of child pages linked by every page of the synthetic website.",
+				},
+				&cli.IntFlag{
+					Name:  "assets-per-page",
+					Value: defaults.AssetsPerPage,
+					Usage: "Number of assets referenced by every page of the synthetic website.",
+				},
+				&cli.IntFlag{
+					Name:  "asset-size",
+					Value: defaults.AssetSize,
+					Usage: "Size in bytes of every asset of the synthetic website.",
+				},
+				&cli.IntFlag{
+					Name:  "redirects",
+					Value: defaults.Redirects,
+					Usage: "Length of the redirection chain in front of every page of the synthetic website.",
+				},
+			},
+		})
+}
+
+func cmdBenchmark(c *cli.Context) error {
+	site := testsite.Config{
+		Depth:         c.Int("depth"),
+		LinksPerPage:  c.Int("links-per-page"),
+		AssetsPerPage: c.Int("assets-per-page"),
+		AssetSize:     c.Int("asset-size"),
+		Redirects:     c.Int("redirects"),
+	}
+
+	server := testsite.New(site)
+	defer server.Close()
+
+	seed, err := url.Parse(server.URL + "/")
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"url": server.URL,
+			"err": err.Error(),
+		}).Error("Unable to parse the synthetic website URL")
+		return err
+	}
+
+	// Init crawl using the flags provided
+	benchmarkCrawl := cmd.InitCrawlWithCMD(config.App.Flags)
+	benchmarkCrawl.Benchmark = &crawl.Benchmark{ExpectedURIs: site.URIs()}
+
+	// Every page of the synthetic website must be reachable
+	if int(benchmarkCrawl.MaxHops) < site.Depth {
+		benchmarkCrawl.MaxHops = uint8(site.Depth)
+	}
+
+	benchmarkCrawl.SeedList = append(benchmarkCrawl.SeedList, *frontier.NewItem(seed, nil, "seed", 0, "", false))
+
+	logrus.WithFields(logrus.Fields{
+		"url":  server.URL,
+		"uris": site.URIs(),
+	}).Info("Benchmarking against a synthetic website")
+
+	err = benchmarkCrawl.Start()
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err.Error(),
+		}).Error("Benchmark exited due to error")
+		return err
+	}
+
+	return nil
+}
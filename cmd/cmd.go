@@ -22,6 +22,24 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Job name to use, will determine the path for the persistent queue, seencheck database, and WARC files.",
 		Destination: &config.App.Flags.Job,
 	},
+	&cli.StringFlag{
+		Name:        "job-id",
+		Value:       "",
+		Usage:       "Identifier of this crawl, attached to the logs, metrics and WARC files. A random one is generated at every start if not specified.",
+		Destination: &config.App.Flags.JobID,
+	},
+	&cli.StringFlag{
+		Name:        "jobs-directory",
+		Value:       "jobs",
+		Usage:       "Directory in which the job directories are created, e.g. a volume mounted in the container.",
+		Destination: &config.App.Flags.JobsDirectory,
+	},
+	&cli.StringFlag{
+		Name:        "output-layout",
+		Value:       "job",
+		Usage:       "Layout of the job directories in --jobs-directory: \"job\" for a directory per job name, \"daily\" for a directory per day (UTC) in it or \"run\" for a directory per --job-id in it. The queue, WARC files, logs and reports of the crawl are written in that directory.",
+		Destination: &config.App.Flags.OutputLayout,
+	},
 	&cli.IntFlag{
 		Name:        "workers",
 		Aliases:     []string{"w"},
@@ -29,6 +47,28 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Number of concurrent workers to run.",
 		Destination: &config.App.Flags.Workers,
 	},
+	&cli.IntFlag{
+		Name:        "min-workers",
+		Usage:       "Minimum number of workers when the worker pool is scaled dynamically. Defaults to --workers.",
+		Destination: &config.App.Flags.MinWorkers,
+	},
+	&cli.IntFlag{
+		Name:        "max-workers",
+		Usage:       "Maximum number of workers when the worker pool is scaled dynamically. Defaults to --workers, which disables the scaling.",
+		Destination: &config.App.Flags.MaxWorkers,
+	},
+	&cli.IntFlag{
+		Name:        "max-memory",
+		Value:       0,
+		Usage:       "Memory usage in MB above which the worker pool is scaled down, 0 to ignore the memory usage.",
+		Destination: &config.App.Flags.MaxMemory,
+	},
+	&cli.IntFlag{
+		Name:        "max-bandwidth",
+		Value:       0,
+		Usage:       "Bandwidth in MB/s above which the worker pool is scaled down, 0 to ignore the bandwidth.",
+		Destination: &config.App.Flags.MaxBandwidth,
+	},
 	&cli.IntFlag{
 		Name:        "max-concurrent-assets",
 		Aliases:     []string{"ca"},
@@ -36,6 +76,33 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Max number of concurrent assets to fetch PER worker. E.g. if you have 100 workers and this setting at 8, Zeno could do up to 800 concurrent requests at any time.",
 		Destination: &config.App.Flags.MaxConcurrentAssets,
 	},
+	&cli.IntFlag{
+		Name:        "max-assets-per-page",
+		Value:       0,
+		Usage:       "Maximum number of assets captured with a page, 0 for no limit. The assets exceeding the limit are logged and not captured, unless --queue-overflow-assets is set.",
+		Destination: &config.App.Flags.MaxAssetsPerPage,
+	},
+	&cli.BoolFlag{
+		Name:        "queue-overflow-assets",
+		Usage:       "Queue the assets exceeding --max-assets-per-page as regular items in the frontier instead of ignoring them.",
+		Destination: &config.App.Flags.QueueOverflowAssets,
+	},
+	&cli.StringFlag{
+		Name:        "asset-scope",
+		Value:       "all",
+		Usage:       "Restrict the assets captured with a page to \"all\" of them, the ones on the same \"origin\" as the page, or the ones on the same registered \"domain\". The hosts given with --asset-allowed-host are always allowed.",
+		Destination: &config.App.Flags.AssetScope,
+	},
+	&cli.StringSliceFlag{
+		Name:        "asset-allowed-host",
+		Usage:       "Host (and its subdomains) the assets are always captured from with --asset-scope, e.g. a CDN used by the crawled site. Can be specified multiple times.",
+		Destination: &config.App.Flags.AssetAllowedHosts,
+	},
+	&cli.BoolFlag{
+		Name:        "upgrade-mixed-content",
+		Usage:       "Upgrade the http:// assets of https:// pages to https://, like browsers do, so that the replays find the upgraded resources. An asset that can't be captured over https:// falls back to its original URL.",
+		Destination: &config.App.Flags.UpgradeMixedContent,
+	},
 	&cli.UintFlag{
 		Name:        "max-hops",
 		Aliases:     []string{"hops"},
@@ -53,9 +120,35 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Keep a global cookie jar",
 		Destination: &config.App.Flags.KeepCookies,
 	},
+	&cli.StringFlag{
+		Name:        "cookie-policy",
+		Value:       "accept",
+		Usage:       "How cookies are handled: \"accept\" them, \"block\" them entirely, accept them for the \"session\" without ever writing them to the --cookies file, or only accept them for the hosts given with --cookie-allowed-host (\"allowlist\").",
+		Destination: &config.App.Flags.CookiePolicy,
+	},
+	&cli.StringSliceFlag{
+		Name:        "cookie-allowed-host",
+		Usage:       "Host (and its subdomains) for which cookies are accepted with --cookie-policy allowlist. Can be used multiple times.",
+		Destination: &config.App.Flags.CookieAllowedHosts,
+	},
+	&cli.StringSliceFlag{
+		Name:        "http-credentials",
+		Usage:       "Credential used to answer the HTTP Basic authentication challenges of a host, as host=user:password. Can be used multiple times.",
+		Destination: &config.App.Flags.HTTPCredentials,
+	},
+	&cli.StringFlag{
+		Name:        "hosts-file",
+		Usage:       "Hosts-style file forcing the IPs of some hostnames (IP followed by the hostnames, one mapping per line), e.g. to archive a site before a DNS cutover. Not applied to the requests going through --proxy.",
+		Destination: &config.App.Flags.HostsFile,
+	},
+	&cli.StringFlag{
+		Name:        "config-file",
+		Usage:       "JSON file of settings reloaded at runtime when the file changes or on SIGHUP: excludedHosts, includedHosts, excludedStrings, rateLimitDelay, maxConcurrentRequestsPerDomain, workers, targetRate and logLevel.",
+		Destination: &config.App.Flags.ConfigFile,
+	},
 	&cli.BoolFlag{
 		Name:        "headless",
-		Usage:       "Use headless browsers instead of standard GET requests.",
+		Usage:       "Use headless browsers instead of standard GET requests. Not implemented yet.",
 		Destination: &config.App.Flags.Headless,
 	},
 	&cli.BoolFlag{
@@ -76,6 +169,80 @@ var GlobalFlags = []cli.Flag{
 		Name:        "live-stats",
 		Destination: &config.App.Flags.LiveStats,
 	},
+	&cli.StringFlag{
+		Name:        "syslog-address",
+		Usage:       "Send logs to a syslog server as RFC5424 messages. Example: udp://127.0.0.1:514, tcp://127.0.0.1:514 or unix:///dev/log",
+		Destination: &config.App.Flags.SyslogAddress,
+	},
+	&cli.BoolFlag{
+		Name:        "journald",
+		Usage:       "Send logs to systemd-journald, with log fields as journal fields.",
+		Destination: &config.App.Flags.Journald,
+	},
+	&cli.IntFlag{
+		Name:        "log-sample-info",
+		Value:       1,
+		Usage:       "Only write 1 out of N high-rate info log entries (e.g. archived URLs), the others are aggregated.",
+		Destination: &config.App.Flags.LogSampleInfo,
+	},
+	&cli.IntFlag{
+		Name:        "log-sample-warning",
+		Value:       1,
+		Usage:       "Only write 1 out of N high-rate warning log entries, the others are aggregated.",
+		Destination: &config.App.Flags.LogSampleWarning,
+	},
+	&cli.IntFlag{
+		Name:        "log-sample-error",
+		Value:       1,
+		Usage:       "Only write 1 out of N high-rate error log entries, the others are aggregated.",
+		Destination: &config.App.Flags.LogSampleError,
+	},
+	&cli.IntFlag{
+		Name:        "log-sample-interval",
+		Value:       60,
+		Usage:       "Number of seconds between each aggregated log line reporting the entries suppressed by sampling.",
+		Destination: &config.App.Flags.LogSampleInterval,
+	},
+	&cli.BoolFlag{
+		Name:        "heritrix-crawl-log",
+		Usage:       "Write a Heritrix-compatible crawl.log in the job's logs directory.",
+		Destination: &config.App.Flags.HeritrixCrawlLog,
+	},
+	&cli.BoolFlag{
+		Name:        "dry-run",
+		Usage:       "Fetch the pages and extract the links without writing any WARC, the discovered URLs are written as an edge list to graph.csv in the job's directory (and sent to HQ if it is used). Useful to survey the scope of a crawl.",
+		Destination: &config.App.Flags.DryRun,
+	},
+	&cli.BoolFlag{
+		Name:        "link-graph",
+		Usage:       "Write every extracted link as an edge (parent URL, discovered URL, link type, hop, anchor text, heading) to graph.csv in the job's directory.",
+		Destination: &config.App.Flags.LinkGraph,
+	},
+	&cli.BoolFlag{
+		Name:        "attachments-report",
+		Usage:       "Log the responses served as attachments (Content-Disposition) and export them with their file name, size and type as a CSV file in the job's directory.",
+		Destination: &config.App.Flags.AttachmentsReport,
+	},
+	&cli.BoolFlag{
+		Name:        "seeds-report",
+		Usage:       "Track the capture outcome of every seed and export it as a CSV file in the job's directory at the end of the crawl.",
+		Destination: &config.App.Flags.SeedsReport,
+	},
+	&cli.BoolFlag{
+		Name:        "seencheck-seed-aliases",
+		Usage:       "Treat a seed and the final URL it redirects to as one resource: the seeds already captured under another form are skipped, and reported with the alias status.",
+		Destination: &config.App.Flags.SeencheckSeedAliases,
+	},
+	&cli.BoolFlag{
+		Name:        "resolve-seed-shorteners",
+		Usage:       "Replace the seeds pointing to a known URL shortener (bit.ly, t.co..) by the URL they redirect to before starting the crawl.",
+		Destination: &config.App.Flags.ResolveSeedShorteners,
+	},
+	&cli.BoolFlag{
+		Name:        "resolve-shorteners",
+		Usage:       "Replace the discovered links pointing to a known URL shortener (bit.ly, t.co..) by the URL they redirect to, so that they are seenchecked and scoped as their destination. The chains of redirections are logged.",
+		Destination: &config.App.Flags.ResolveShorteners,
+	},
 
 	&cli.BoolFlag{
 		Name:        "api",
@@ -102,37 +269,131 @@ var GlobalFlags = []cli.Flag{
 	&cli.IntFlag{
 		Name:        "max-redirect",
 		Value:       20,
-		Usage:       "Specifies the maximum number of redirections to follow for a resource.",
+		Usage:       "Specifies the maximum number of redirections to the same host to follow for a resource.",
 		Destination: &config.App.Flags.MaxRedirect,
 	},
+	&cli.IntFlag{
+		Name:        "max-cross-host-redirect",
+		Value:       20,
+		Usage:       "Specifies the maximum number of redirections to another host to follow for a resource, e.g. the hops of a chain of URL shorteners. They don't count towards --max-redirect.",
+		Destination: &config.App.Flags.MaxCrossHostRedirect,
+	},
+	&cli.StringFlag{
+		Name:        "redirect-policy",
+		Value:       "follow",
+		Usage:       "What to do with the redirections leaving the scope of the crawl: \"follow\" them, \"record\" the target in the logs and the link graph without following it, or \"drop\" them.",
+		Destination: &config.App.Flags.RedirectPolicy,
+	},
+	&cli.StringFlag{
+		Name:        "redirect-scope",
+		Value:       "domain",
+		Usage:       "Scope of the redirections for --redirect-policy: the same \"host\" or the same registered \"domain\" as the redirecting URL, excluded and non-included hosts are always out of scope.",
+		Destination: &config.App.Flags.RedirectScope,
+	},
+	&cli.StringFlag{
+		Name:        "nofollow-policy",
+		Value:       "follow",
+		Usage:       "What to do with the links marked rel=nofollow, ugc or sponsored: \"follow\" them, follow them and \"flag\" the discovered URLs in the logs, or \"skip\" them. They are counted in the API stats either way.",
+		Destination: &config.App.Flags.NofollowPolicy,
+	},
+	&cli.StringSliceFlag{
+		Name:        "body-rule",
+		Usage:       "Rule evaluated on the beginning of the response bodies, written as <action>:<regexp>. The action is skip-outlinks, skip-assets, or tag=<name> to tag the capture in the logs and the seeds report, e.g. tag=paywalled:paywall-detected. Can be specified multiple times.",
+		Destination: &config.App.Flags.BodyRules,
+	},
+	&cli.IntFlag{
+		Name:        "body-rule-max-size",
+		Value:       1024,
+		Usage:       "Size in KB of the beginning of the response bodies the --body-rule rules are evaluated on.",
+		Destination: &config.App.Flags.BodyRuleMaxSize,
+	},
+	&cli.IntFlag{
+		Name:        "max-url-length",
+		Value:       0,
+		Usage:       "Maximum length of the URLs to queue, longer URLs are dropped. 0 for no limit.",
+		Destination: &config.App.Flags.MaxURLLength,
+	},
+	&cli.IntFlag{
+		Name:        "max-query-params",
+		Value:       0,
+		Usage:       "Maximum number of query parameters of the URLs to queue, URLs with more parameters are dropped. 0 for no limit.",
+		Destination: &config.App.Flags.MaxQueryParams,
+	},
 	&cli.IntFlag{
 		Name:        "max-retry",
 		Value:       20,
 		Usage:       "Number of retry if error happen when executing HTTP request.",
 		Destination: &config.App.Flags.MaxRetry,
 	},
+	&cli.IntFlag{
+		Name:        "asset-max-retry",
+		Value:       0,
+		Usage:       "Number of retry if error happen when capturing an asset. Defaults to --max-retry.",
+		Destination: &config.App.Flags.AssetMaxRetry,
+	},
 	&cli.IntFlag{
 		Name:        "http-timeout",
 		Value:       30,
 		Usage:       "Number of seconds to wait before timing out a request.",
 		Destination: &config.App.Flags.HTTPTimeout,
 	},
+	&cli.IntFlag{
+		Name:        "asset-http-timeout",
+		Value:       0,
+		Usage:       "Number of seconds to wait before timing out an asset request, it can only be shorter than --http-timeout. Defaults to --http-timeout.",
+		Destination: &config.App.Flags.AssetHTTPTimeout,
+	},
 	&cli.BoolFlag{
 		Name:        "domains-crawl",
 		Usage:       "If this is turned on, seeds will be treated as domains to crawl, therefore same-domain outlinks will be added to the queue as hop=0.",
 		Destination: &config.App.Flags.DomainsCrawl,
 	},
+	&cli.IntFlag{
+		Name:        "pagination-depth",
+		Value:       0,
+		Usage:       "Follow the pagination links of the pages (rel=next/prev, ?page=N) at the same hop, up to this number of pages away from the first page of a listing. 0 to disable.",
+		Destination: &config.App.Flags.PaginationDepth,
+	},
 	&cli.StringSliceFlag{
 		Name:        "disable-html-tag",
 		Usage:       "Specify HTML tag to not extract assets from",
 		Destination: &config.App.Flags.DisabledHTMLTags,
 	},
+	&cli.IntFlag{
+		Name:        "html-tokenizer-threshold",
+		Value:       0,
+		Usage:       "Size in KB above which HTML documents are scraped with a streaming tokenizer instead of a full DOM parsing, 0 to disable.",
+		Destination: &config.App.Flags.HTMLTokenizerThreshold,
+	},
+	&cli.IntFlag{
+		Name:        "range-recovery-min-size",
+		Value:       0,
+		Usage:       "Size in MB above which an interrupted download is resumed with a Range request instead of failing, when the server supports it. 0 to disable.",
+		Destination: &config.App.Flags.RangeRecoveryMinSize,
+	},
+	&cli.IntFlag{
+		Name:        "archive-listing-max-size",
+		Value:       0,
+		Usage:       "Size in MB under which the content of the captured zip and tar archives is listed in a metadata record, without extracting the files. 0 to disable.",
+		Destination: &config.App.Flags.ArchiveListingMaxSize,
+	},
+	&cli.BoolFlag{
+		Name:        "capture-timings",
+		Usage:       "Record the DNS, connect, TLS, time to first byte and total timings of every capture in a metadata record, to analyze the performance of the hosts from the WARC files.",
+		Destination: &config.App.Flags.CaptureTimings,
+	},
 	&cli.BoolFlag{
 		Name:        "capture-alternate-pages",
 		Value:       false,
 		Usage:       "If turned on, <link> HTML tags with \"alternate\" values for their \"rel\" attribute will be archived.",
 		Destination: &config.App.Flags.CaptureAlternatePages,
 	},
+	&cli.BoolFlag{
+		Name:        "capture-mobile-versions",
+		Value:       false,
+		Usage:       "If turned on, the AMP (<link rel=\"amphtml\">) and mobile (<link rel=\"alternate\" media=...>) versions of the pages are captured as pages at the same hop, along with their assets.",
+		Destination: &config.App.Flags.CaptureMobileVersions,
+	},
 	&cli.StringSliceFlag{
 		Name:        "exclude-host",
 		Usage:       "Exclude a specific host from the crawl, note that it will not exclude the domain if it is encountered as an asset for another web page.",
@@ -143,18 +404,203 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Only crawl specific hosts, note that it will not include the domain if it is encountered as an asset for another web page.",
 		Destination: &config.App.Flags.IncludedHosts,
 	},
+	&cli.BoolFlag{
+		Name:        "strip-tracking-params",
+		Usage:       "Strip tracking parameters (utm_*, fbclid, gclid..) from the URLs before queueing them. Some sites use them for routing, so it is disabled by default.",
+		Destination: &config.App.Flags.StripTrackingParams,
+	},
+	&cli.StringSliceFlag{
+		Name:        "tracking-param",
+		Usage:       "Query parameter to strip when --strip-tracking-params is enabled, a trailing * matches any suffix (e.g. utm_*). Replaces the default list.",
+		Destination: &config.App.Flags.TrackingParams,
+	},
+	&cli.BoolFlag{
+		Name:        "host-security",
+		Usage:       "Record the Strict-Transport-Security and Alt-Svc headers of every host, upgrade the http:// links to the HSTS hosts to https:// and export the hosts security map as a CSV file in the job's directory at the end of the crawl.",
+		Destination: &config.App.Flags.HostSecurity,
+	},
 	&cli.IntFlag{
 		Name:        "max-concurrent-per-domain",
 		Value:       16,
 		Usage:       "Maximum number of concurrent requests per domain.",
 		Destination: &config.App.Flags.MaxConcurrentRequestsPerDomain,
 	},
+	&cli.IntFlag{
+		Name:        "max-concurrent-per-cdn",
+		Value:       64,
+		Usage:       "Maximum number of concurrent requests to the hosts of a CDN (see --cdn-suffix), shared by all the sites using it.",
+		Destination: &config.App.Flags.MaxConcurrentRequestsPerCDN,
+	},
+	&cli.IntFlag{
+		Name:        "max-consecutive-per-host",
+		Value:       0,
+		Usage:       "Maximum number of items of the same host a worker captures in a row when items of other hosts are waiting, to interleave the hosts. 0 to disable.",
+		Destination: &config.App.Flags.MaxConsecutivePerHost,
+	},
+	&cli.Float64Flag{
+		Name:        "target-rate",
+		Value:       0,
+		Usage:       "Number of URIs per second, assets included, the crawl is paced to by spacing the dispatch of the URLs to the workers. It can be changed while crawling with the API or --config-file. 0 to crawl as fast as the workers allow.",
+		Destination: &config.App.Flags.TargetRate,
+	},
+	&cli.IntFlag{
+		Name:        "recent-outlinks-size",
+		Value:       0,
+		Usage:       "Number of recently queued outlinks kept in memory, the identical outlinks found again within --recent-outlinks-window aren't queued again. Collapses the bursts of links found on every page of template-heavy sites before the seencheck. 0 to disable.",
+		Destination: &config.App.Flags.RecentOutlinksSize,
+	},
+	&cli.IntFlag{
+		Name:        "recent-outlinks-window",
+		Value:       60,
+		Usage:       "Number of seconds during which an outlink found again isn't queued again, with --recent-outlinks-size.",
+		Destination: &config.App.Flags.RecentOutlinksWindow,
+	},
+	&cli.StringSliceFlag{
+		Name:        "media-url-pattern",
+		Usage:       "Regular expression matching the URLs captured by the dedicated --media-workers, e.g. /video/ to isolate heavy media captures from the rest of the crawl. Can be used multiple times.",
+		Destination: &config.App.Flags.MediaURLPatterns,
+	},
+	&cli.IntFlag{
+		Name:        "media-workers",
+		Value:       4,
+		Usage:       "Number of workers dedicated to the URLs matching --media-url-pattern.",
+		Destination: &config.App.Flags.MediaWorkers,
+	},
+	&cli.IntFlag{
+		Name:        "media-max-bandwidth",
+		Value:       0,
+		Usage:       "Bandwidth in MB/s shared by the responses of the URLs matching --media-url-pattern, assets included. 0 to ignore the bandwidth.",
+		Destination: &config.App.Flags.MediaMaxBandwidth,
+	},
+	&cli.IntFlag{
+		Name:        "priority-workers",
+		Value:       2,
+		Usage:       "Number of workers dedicated to the urgent seeds, submitted with POST /urgent on the API or with the priority=urgent field in the queue backend. They bypass the queue to be captured within seconds. 0 to disable.",
+		Destination: &config.App.Flags.PriorityWorkers,
+	},
+	&cli.StringSliceFlag{
+		Name:        "cdn-suffix",
+		Value:       cli.NewStringSlice("cloudfront.net", "akamaized.net", "akamaihd.net", "edgesuite.net", "edgekey.net", "fastly.net", "azureedge.net", "b-cdn.net", "cdn77.org", "wp.com", "googleusercontent.com"),
+		Usage:       "Domain suffix of a CDN: its hosts share a single politeness bucket limited by --max-concurrent-per-cdn instead of being limited per host. Can be specified multiple times.",
+		Destination: &config.App.Flags.CDNSuffixes,
+	},
 	&cli.IntFlag{
 		Name:        "concurrent-sleep-length",
 		Value:       500,
 		Usage:       "Number of milliseconds to sleep when max concurrency per domain is reached.",
 		Destination: &config.App.Flags.RateLimitDelay,
 	},
+	&cli.IntFlag{
+		Name:        "circuit-breaker-threshold",
+		Value:       0,
+		Usage:       "Number of consecutive failed requests after which a host is skipped for --circuit-breaker-cooldown. 0 to disable.",
+		Destination: &config.App.Flags.CircuitBreakerThreshold,
+	},
+	&cli.IntFlag{
+		Name:        "circuit-breaker-cooldown",
+		Value:       600,
+		Usage:       "Number of seconds a host is skipped once its circuit is opened.",
+		Destination: &config.App.Flags.CircuitBreakerCooldown,
+	},
+	&cli.StringFlag{
+		Name:        "circuit-breaker-action",
+		Value:       "drop",
+		Usage:       "What to do with the URLs of a host whose circuit is open: \"drop\" them or \"defer\" them by sending them back to the queue.",
+		Destination: &config.App.Flags.CircuitBreakerAction,
+	},
+	&cli.IntFlag{
+		Name:        "host-time-budget",
+		Value:       0,
+		Usage:       "Maximum number of seconds spent on each host, counted from its first capture. The remaining URLs of the host are then written to deferred.csv instead of being captured. 0 to disable.",
+		Destination: &config.App.Flags.HostTimeBudget,
+	},
+	&cli.StringFlag{
+		Name:        "timegate",
+		Usage:       "URL of a Memento TimeGate queried before capturing an item, the URL of the item is appended to it, e.g. http://timetravel.mementoweb.org/timegate/. The items captured by another archive less than --timegate-max-age ago are skipped or demoted.",
+		Destination: &config.App.Flags.TimeGate,
+	},
+	&cli.IntFlag{
+		Name:        "timegate-max-age",
+		Value:       720,
+		Usage:       "Age in hours under which a memento found by the --timegate makes the capture of the item unnecessary.",
+		Destination: &config.App.Flags.TimeGateMaxAge,
+	},
+	&cli.StringFlag{
+		Name:        "timegate-action",
+		Value:       "skip",
+		Usage:       "What to do with the items recently captured by another archive: \"skip\" them, or \"demote\" them to the back of the queue to capture them once the rest has been.",
+		Destination: &config.App.Flags.TimeGateAction,
+	},
+	&cli.Float64Flag{
+		Name:        "alert-error-rate",
+		Value:       0,
+		Usage:       "Raise an alert when the share of failed requests (errors and 5xx) of the crawl or of a host is over this ratio, e.g. 0.2, for --alert-duration minutes. 0 to disable.",
+		Destination: &config.App.Flags.AlertErrorRate,
+	},
+	&cli.Float64Flag{
+		Name:        "alert-429-rate",
+		Value:       0,
+		Usage:       "Raise an alert when the share of 429 responses of the crawl or of a host is over this ratio for --alert-duration minutes. 0 to disable.",
+		Destination: &config.App.Flags.Alert429Rate,
+	},
+	&cli.IntFlag{
+		Name:        "alert-latency",
+		Value:       0,
+		Usage:       "Raise an alert when the average latency in milliseconds of the crawl or of a host is over this value for --alert-duration minutes. 0 to disable.",
+		Destination: &config.App.Flags.AlertLatency,
+	},
+	&cli.IntFlag{
+		Name:        "alert-duration",
+		Value:       5,
+		Usage:       "Number of consecutive minutes a threshold must be crossed before its alert is raised.",
+		Destination: &config.App.Flags.AlertDuration,
+	},
+	&cli.StringFlag{
+		Name:        "alert-webhook",
+		Usage:       "URL the alerts (and their recoveries) are POSTed to as JSON, in addition to being logged.",
+		Destination: &config.App.Flags.AlertWebhook,
+	},
+	&cli.BoolFlag{
+		Name:        "host-completion-events",
+		Usage:       "Log an event with the summary of the captures of a host when all the URLs queued for it have been processed, so that the QA of a site can start before the end of the crawl.",
+		Destination: &config.App.Flags.HostCompletionEvents,
+	},
+	&cli.StringFlag{
+		Name:        "host-completion-webhook",
+		Usage:       "URL the host completion events are POSTed to as JSON, in addition to being logged. Implies --host-completion-events.",
+		Destination: &config.App.Flags.HostCompletionWebhook,
+	},
+	&cli.Float64Flag{
+		Name:        "chaos-rate",
+		Value:       0,
+		Usage:       "Testing: percentage of the requests in which a synthetic failure (see --chaos-fault) is injected, to validate the retries, the circuit breaker and the reports of a configuration. 0 to disable.",
+		Destination: &config.App.Flags.ChaosRate,
+	},
+	&cli.StringSliceFlag{
+		Name:        "chaos-fault",
+		Value:       cli.NewStringSlice("timeout", "5xx", "truncate"),
+		Usage:       "Testing: kind of failure injected with --chaos-rate: \"timeout\", \"5xx\" or \"truncate\" (the body is cut). Can be specified multiple times.",
+		Destination: &config.App.Flags.ChaosFaults,
+	},
+
+	&cli.IntFlag{
+		Name:        "min-space-required",
+		Value:       20,
+		Usage:       "Minimum space required in GB on the WARC output volume, the crawl is paused below that and resumed when space is freed.",
+		Destination: &config.App.Flags.MinSpaceRequired,
+	},
+	&cli.IntFlag{
+		Name:        "disk-full-abort",
+		Value:       0,
+		Usage:       "Number of minutes the crawl stays paused for lack of disk space (see --min-space-required) before it is aborted, with exit code 5. 0 to wait indefinitely.",
+		Destination: &config.App.Flags.DiskFullAbort,
+	},
+	&cli.Float64Flag{
+		Name:        "exit-error-threshold",
+		Value:       0,
+		Usage:       "Share of failed captures, e.g. 0.1, above which Zeno exits with code 4 at the end of the crawl instead of 0. 0 to disable.",
+		Destination: &config.App.Flags.ExitErrorThreshold,
+	},
 
 	&cli.IntFlag{
 		Name:        "crawl-time-limit",
@@ -170,6 +616,18 @@ var GlobalFlags = []cli.Flag{
 		Destination: &config.App.Flags.MaxCrawlTimeLimit,
 	},
 
+	&cli.StringFlag{
+		Name:        "crawl-window",
+		Usage:       "Daily time window during which the crawl runs, as HH:MM-HH:MM, e.g. 22:00-06:00. The crawl is paused outside of it.",
+		Destination: &config.App.Flags.CrawlWindow,
+	},
+
+	&cli.StringFlag{
+		Name:        "crawl-window-timezone",
+		Usage:       "IANA time zone of --crawl-window, e.g. the one of the crawled site like Europe/Paris. Defaults to the local time zone.",
+		Destination: &config.App.Flags.CrawlWindowTimezone,
+	},
+
 	// Proxy flags
 	&cli.StringFlag{
 		Name:        "proxy",
@@ -214,12 +672,82 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "Number of concurrent WARC files to write.",
 		Destination: &config.App.Flags.WARCPoolSize,
 	},
+	&cli.StringFlag{
+		Name:        "warc-writers-routing",
+		Value:       "",
+		Usage:       "Give each of the --warc-pool-size WARC writers its own file and route records to them by \"host\" or by \"worker\", instead of sharing the writers. Does not apply to proxied requests.",
+		Destination: &config.App.Flags.WARCWritersRouting,
+	},
+	&cli.StringSliceFlag{
+		Name:        "warc-collection",
+		Usage:       "Write the records of the seeds annotated with this collection (collection:<name> in the seed list) and of their descendants to their own WARC files, prefixed with <warc-prefix>-<name>. Can be used multiple times.",
+		Destination: &config.App.Flags.WARCCollections,
+	},
+	&cli.IntFlag{
+		Name:        "warc-queue-size",
+		Value:       0,
+		Usage:       "Number of records waiting to be written above which the crawl is paused, it is slowed down at half that. Defaults to 8 times the number of workers.",
+		Destination: &config.App.Flags.WARCQueueSize,
+	},
 	&cli.StringFlag{
 		Name:        "warc-temp-dir",
 		Value:       "",
 		Usage:       "Custom directory to use for WARC temporary files.",
 		Destination: &config.App.Flags.WARCTempDir,
 	},
+	&cli.StringFlag{
+		Name:        "warc-spool-dir",
+		Value:       "",
+		Usage:       "Custom directory where the WARC files are written, they are moved to the job's warcs directory once finished. Defaults to the job's warcs directory.",
+		Destination: &config.App.Flags.WARCSpoolDir,
+	},
+	&cli.BoolFlag{
+		Name:        "warc-manifest",
+		Usage:       "Maintain a manifest of the finished WARC files (name, size, SHA-256 and number of records) in the job's directory.",
+		Destination: &config.App.Flags.WARCManifest,
+	},
+	&cli.BoolFlag{
+		Name:        "warc-index",
+		Usage:       "Write a CDX index next to every finished WARC file, with the offset and the length of the response and revisit records.",
+		Destination: &config.App.Flags.WARCIndex,
+	},
+	&cli.StringFlag{
+		Name:        "ias3-item",
+		Usage:       "Upload the finished WARC files, the CDX files and the reports to this archive.org item using the S3-like API. The item is created if it doesn't exist.",
+		Destination: &config.App.Flags.IAS3Item,
+	},
+	&cli.StringFlag{
+		Name:        "ias3-endpoint",
+		Value:       "https://s3.us.archive.org",
+		Usage:       "Endpoint of archive.org's S3-like API.",
+		Destination: &config.App.Flags.IAS3Endpoint,
+	},
+	&cli.StringFlag{
+		Name:        "ias3-access-key",
+		Usage:       "Access key for archive.org's S3-like API.",
+		Destination: &config.App.Flags.IAS3AccessKey,
+	},
+	&cli.StringFlag{
+		Name:        "ias3-secret-key",
+		Usage:       "Secret key for archive.org's S3-like API.",
+		Destination: &config.App.Flags.IAS3SecretKey,
+	},
+	&cli.StringFlag{
+		Name:        "ias3-collection",
+		Usage:       "Collection of the archive.org item, set when the item is created.",
+		Destination: &config.App.Flags.IAS3Collection,
+	},
+	&cli.StringFlag{
+		Name:        "ias3-mediatype",
+		Value:       "web",
+		Usage:       "Mediatype of the archive.org item, set when the item is created.",
+		Destination: &config.App.Flags.IAS3MediaType,
+	},
+	&cli.StringSliceFlag{
+		Name:        "ias3-subject",
+		Usage:       "Subject of the archive.org item, set when the item is created. Can be used multiple times.",
+		Destination: &config.App.Flags.IAS3Subjects,
+	},
 	&cli.BoolFlag{
 		Name:        "disable-local-dedupe",
 		Usage:       "Disable local URL agonistic deduplication.",
@@ -298,6 +826,37 @@ var GlobalFlags = []cli.Flag{
 		Usage:       "If turned on, the crawler will send back URLs that hit a rate limit to crawl HQ.",
 		Destination: &config.App.Flags.HQRateLimitingSendBack,
 	},
+
+	// Queue backend flags
+	&cli.StringFlag{
+		Name:        "queue-backend",
+		Usage:       "Remote queue the URLs to crawl are consumed from and the discovered URLs are produced to, shared with other crawlers: redis. Can't be used with --hq.",
+		Destination: &config.App.Flags.QueueBackend,
+	},
+	&cli.StringFlag{
+		Name:        "redis-url",
+		Value:       "redis://localhost:6379/0",
+		Usage:       "URL of the Redis server used by the redis queue backend.",
+		Destination: &config.App.Flags.RedisURL,
+	},
+	&cli.StringFlag{
+		Name:        "redis-stream",
+		Value:       "zeno",
+		Usage:       "Redis stream the URLs are consumed from and produced to.",
+		Destination: &config.App.Flags.RedisStream,
+	},
+	&cli.StringFlag{
+		Name:        "redis-group",
+		Value:       "zeno",
+		Usage:       "Consumer group of the Redis stream shared by the crawlers.",
+		Destination: &config.App.Flags.RedisGroup,
+	},
+	&cli.IntFlag{
+		Name:        "redis-claim-idle",
+		Value:       600,
+		Usage:       "Number of seconds after which the URLs left pending by another crawler of the group are claimed.",
+		Destination: &config.App.Flags.RedisClaimIdle,
+	},
 	&cli.StringFlag{
 		Name:        "es-url",
 		Usage:       "ElasticSearch URL to use for indexing crawl logs.",
@@ -12,7 +12,7 @@ import (
 func newGetListCmd() *cli.Command {
 	return &cli.Command{
 		Name:      "list",
-		Usage:     "Start crawling with a seed list.",
+		Usage:     "Start crawling with a seed list, one URL per line, optionally followed by its collection (collection:<name>, see --warc-collection) and by the scope of its descendants (regex:<expression> or surt:<prefix> <prefix>...).",
 		Action:    cmdGetList,
 		Flags:     []cli.Flag{},
 		UsageText: "<FILE> [ARGUMENTS]",
@@ -30,13 +30,39 @@ func cmdGetList(c *cli.Context) error {
 	crawl := cmd.InitCrawlWithCMD(config.App.Flags)
 
 	// Initialize initial seed list
-	crawl.SeedList, err = frontier.IsSeedList(c.Args().Get(0))
+	var seedsValidation *frontier.SeedsValidation
+
+	crawl.SeedList, seedsValidation, err = frontier.IsSeedList(c.Args().Get(0))
 	if err != nil || len(crawl.SeedList) <= 0 {
 		logrus.WithFields(logrus.Fields{
 			"input": c.Args().Get(0),
 			"err":   err.Error(),
 		}).Error("This is not a valid input")
-		return err
+		return cli.Exit(err, cmd.ExitConfigError)
+	}
+
+	if config.App.Flags.ResolveSeedShorteners {
+		frontier.ResolveShorteners(crawl.SeedList, seedsValidation, crawl.UserAgent)
+	}
+
+	// The seeds are deduplicated once normalized and resolved, the merged duplicates are reported
+	crawl.SeedList = frontier.DeduplicateSeeds(crawl.SeedList, seedsValidation)
+
+	logrus.WithFields(logrus.Fields{
+		"total":     seedsValidation.Total,
+		"valid":     seedsValidation.Valid,
+		"rewritten": len(seedsValidation.Rewritten),
+		"merged":    len(seedsValidation.Merged),
+		"rejected":  len(seedsValidation.Rejected),
+	}).Print("Seed list validated")
+
+	if len(seedsValidation.Rewritten)+len(seedsValidation.Merged)+len(seedsValidation.Rejected) > 0 {
+		err = seedsValidation.Write(crawl.JobPath)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err.Error(),
+			}).Warn("Unable to write the seeds validation report")
+		}
 	}
 
 	logrus.WithFields(logrus.Fields{
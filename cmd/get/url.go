@@ -1,8 +1,6 @@
 package get
 
 import (
-	"net/url"
-
 	"github.com/internetarchive/Zeno/cmd"
 	"github.com/internetarchive/Zeno/config"
 	"github.com/internetarchive/Zeno/internal/pkg/frontier"
@@ -31,17 +29,29 @@ func cmdGetURL(c *cli.Context) error {
 	crawl := cmd.InitCrawlWithCMD(config.App.Flags)
 
 	// Initialize initial seed list
-	input, err := url.Parse(c.Args().Get(0))
+	input, reason, err := frontier.NormalizeSeed(c.Args().Get(0))
 	if err != nil {
 		logrus.WithFields(logrus.Fields{
 			"input": c.Args().Get(0),
 			"err":   err.Error(),
 		}).Error("This is not a valid input")
-		return err
+		return cli.Exit(err, cmd.ExitConfigError)
+	}
+
+	if reason != "" {
+		logrus.WithFields(logrus.Fields{
+			"input":  c.Args().Get(0),
+			"url":    input.String(),
+			"reason": reason,
+		}).Info("Seed rewritten")
 	}
 
 	crawl.SeedList = append(crawl.SeedList, *frontier.NewItem(input, nil, "seed", 0, "", false))
 
+	if config.App.Flags.ResolveSeedShorteners {
+		frontier.ResolveShorteners(crawl.SeedList, new(frontier.SeedsValidation), crawl.UserAgent)
+	}
+
 	// Start crawl
 	err = crawl.Start()
 	if err != nil {
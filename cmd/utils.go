@@ -1,33 +1,88 @@
 package cmd
 
 import (
+	"os"
 	"path"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/internetarchive/Zeno/config"
 	"github.com/internetarchive/Zeno/internal/pkg/crawl"
 	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/upload"
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/paulbellamy/ratecounter"
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/sirupsen/logrus"
 )
 
+// ExitConfigError is the exit code used when the flags or the inputs are invalid
+const ExitConfigError = crawl.ExitConfigError
+
+// getJobPath return the directory of the job in --jobs-directory, depending on --output-layout:
+// "job" use a directory per job name, "daily" a directory per day in it and "run" a directory
+// per job ID in it. The names are sanitized to be valid directory names on every platform.
+func getJobPath(flags config.Flags, job, jobID string) string {
+	jobPath := path.Join(flags.JobsDirectory, utils.SanitizeFileName(job))
+
+	switch flags.OutputLayout {
+	case "job":
+	case "daily":
+		jobPath = path.Join(jobPath, time.Now().UTC().Format("2006-01-02"))
+	case "run":
+		jobPath = path.Join(jobPath, utils.SanitizeFileName(jobID))
+	default:
+		logrus.Fatalf("invalid --output-layout value: %s, must be \"job\", \"daily\" or \"run\"", flags.OutputLayout)
+	}
+
+	return jobPath
+}
+
 // InitCrawlWithCMD takes a config.Flags struct and return a
 // *crawl.Crawl initialized with it
 func InitCrawlWithCMD(flags config.Flags) *crawl.Crawl {
 	var c = new(crawl.Crawl)
 
+	// The invalid flags are fatal, with the configuration error exit code
+	logrus.StandardLogger().ExitFunc = func(int) { os.Exit(ExitConfigError) }
+	defer func() { logrus.StandardLogger().ExitFunc = os.Exit }()
+
 	// Statistics counters
 	c.CrawledSeeds = new(ratecounter.Counter)
 	c.CrawledAssets = new(ratecounter.Counter)
+	c.WARCWritingBlockedTime = new(ratecounter.Counter)
 	c.ActiveWorkers = new(ratecounter.Counter)
+	c.RejectedURLs = new(ratecounter.Counter)
+	c.ExpiredItems = new(ratecounter.Counter)
+	c.CollapsedOutlinks = new(ratecounter.Counter)
+	c.CircuitsOpened = new(ratecounter.Counter)
+	c.FailedCaptures = new(ratecounter.Counter)
 	c.URIsPerSecond = ratecounter.NewRateCounter(1 * time.Second)
 
 	c.LiveStats = flags.LiveStats
 	c.ElasticSearchURL = flags.ElasticSearchURL
 
+	c.HeritrixCrawlLog = flags.HeritrixCrawlLog
+	c.DryRun = flags.DryRun
+	c.ExportLinkGraph = flags.LinkGraph
+	c.ExportAttachments = flags.AttachmentsReport
+	c.SyslogAddress = flags.SyslogAddress
+	c.Journald = flags.Journald
+
+	// Log sampling is only enabled if at least one level is sampled
+	if flags.LogSampleInfo > 1 || flags.LogSampleWarning > 1 || flags.LogSampleError > 1 {
+		c.LogSampler = crawl.NewLogSampler(flags.LogSampleInfo, flags.LogSampleWarning, flags.LogSampleError, time.Duration(flags.LogSampleInterval)*time.Second)
+	}
+
+	c.SeencheckSeedAliases = flags.SeencheckSeedAliases
+	c.ResolveShorteners = flags.ResolveShorteners
+
+	if flags.SeedsReport {
+		c.SeedsReport = new(crawl.SeedsReport)
+	}
+
 	// Frontier
 	c.Frontier = new(frontier.Frontier)
 
@@ -47,17 +102,157 @@ func InitCrawlWithCMD(flags config.Flags) *crawl.Crawl {
 		c.Job = flags.Job
 	}
 
-	c.JobPath = path.Join("jobs", flags.Job)
+	// The job ID identifies this specific run of the job, a new one is generated at
+	// every start unless specified, so that the telemetry of multiple crawls can be separated
+	if flags.JobID == "" {
+		UUID, err := uuid.NewUUID()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+
+		c.JobID = UUID.String()
+	} else {
+		c.JobID = flags.JobID
+	}
+
+	c.JobPath = getJobPath(flags, c.Job, c.JobID)
+
+	// The WARC files are named <prefix>-<timestamp>-<serial>-<hostname>.warc.gz.open
+	if !utils.CheckPathLength(path.Join(c.JobPath, "warcs"), len(flags.WARCPrefix)+80) {
+		logrus.Warnf("the path of the WARC files in %s may exceed the maximum path length of Windows, "+
+			"use a shorter --jobs-directory or enable the long paths support of Windows", c.JobPath)
+	}
 
 	c.Workers = flags.Workers
-	c.WorkerPool = sizedwaitgroup.New(c.Workers)
+
+	// Defaults --min-workers and --max-workers to --workers, which means a fixed-size pool
+	c.MinWorkers = flags.MinWorkers
+	if c.MinWorkers == 0 || c.MinWorkers > c.Workers {
+		c.MinWorkers = c.Workers
+	}
+
+	c.MaxWorkers = flags.MaxWorkers
+	if c.MaxWorkers < c.Workers {
+		c.MaxWorkers = c.Workers
+	}
+
+	c.MaxMemory = flags.MaxMemory
+	c.MaxBandwidth = flags.MaxBandwidth
+	c.WorkerPool = sizedwaitgroup.New(c.MaxWorkers)
 	c.MaxConcurrentAssets = flags.MaxConcurrentAssets
+	c.MaxAssetsPerPage = flags.MaxAssetsPerPage
+	c.QueueOverflowAssets = flags.QueueOverflowAssets
+
+	if flags.AssetScope != "all" && flags.AssetScope != "origin" && flags.AssetScope != "domain" {
+		logrus.Fatalf("invalid --asset-scope value: %s, must be \"all\", \"origin\" or \"domain\"", flags.AssetScope)
+	}
+	c.AssetScope = flags.AssetScope
+	c.AssetAllowedHosts = flags.AssetAllowedHosts.Value()
+	c.UpgradeMixedContent = flags.UpgradeMixedContent
 
 	c.Seencheck = flags.Seencheck
 	c.HTTPTimeout = flags.HTTPTimeout
+	c.AssetHTTPTimeout = flags.AssetHTTPTimeout
 	c.MaxConcurrentRequestsPerDomain = flags.MaxConcurrentRequestsPerDomain
+	c.MaxConcurrentRequestsPerCDN = flags.MaxConcurrentRequestsPerCDN
+	c.MaxConsecutivePerHost = flags.MaxConsecutivePerHost
+
+	if flags.TargetRate < 0 {
+		logrus.Fatalf("invalid --target-rate value: %f, must be a positive number of URIs per second", flags.TargetRate)
+	}
+	c.TargetRate = crawl.NewRatePacer(flags.TargetRate)
+
+	if flags.RecentOutlinksSize > 0 {
+		c.RecentOutlinks = crawl.NewRecentOutlinks(flags.RecentOutlinksSize, time.Duration(flags.RecentOutlinksWindow)*time.Second)
+	}
+
+	if len(flags.MediaURLPatterns.Value()) > 0 {
+		if flags.MediaWorkers < 1 {
+			logrus.Fatal("--media-workers must be at least 1 with --media-url-pattern")
+		}
+
+		c.MediaLane = &crawl.MediaLane{Workers: flags.MediaWorkers}
+
+		for _, pattern := range flags.MediaURLPatterns.Value() {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				logrus.Fatalf("invalid --media-url-pattern value: %s: %s", pattern, err)
+			}
+			c.MediaLane.Patterns = append(c.MediaLane.Patterns, compiled)
+		}
+
+		if flags.MediaMaxBandwidth > 0 {
+			c.MediaLane.Bandwidth = crawl.NewBandwidthLimiter(int64(flags.MediaMaxBandwidth) * crawl.MB)
+		}
+	}
+	if flags.PriorityWorkers > 0 {
+		c.PriorityLane = &crawl.PriorityLane{Workers: flags.PriorityWorkers}
+	}
+
+	c.CDNSuffixes = flags.CDNSuffixes.Value()
 	c.RateLimitDelay = flags.RateLimitDelay
+
+	if flags.CircuitBreakerThreshold > 0 {
+		c.CircuitBreaker = crawl.NewCircuitBreaker(flags.CircuitBreakerThreshold, time.Duration(flags.CircuitBreakerCooldown)*time.Second)
+	}
+
+	if flags.CircuitBreakerAction != "drop" && flags.CircuitBreakerAction != "defer" {
+		logrus.Fatalf("invalid --circuit-breaker-action value: %s, must be \"drop\" or \"defer\"", flags.CircuitBreakerAction)
+	}
+	c.CircuitBreakerAction = flags.CircuitBreakerAction
+
+	if flags.HostTimeBudget > 0 {
+		hostBudget, err := crawl.NewHostTimeBudget(c.JobPath, time.Duration(flags.HostTimeBudget)*time.Second)
+		if err != nil {
+			logrus.Fatalf("unable to create the deferred URLs report: %s", err)
+		}
+		c.HostTimeBudget = hostBudget
+	}
+
+	if flags.TimeGate != "" {
+		if flags.TimeGateAction != "skip" && flags.TimeGateAction != "demote" {
+			logrus.Fatalf("invalid --timegate-action value: %s, must be \"skip\" or \"demote\"", flags.TimeGateAction)
+		}
+
+		// Crawl HQ doesn't keep the items' state, a demoted item would be checked and demoted again
+		if flags.TimeGateAction == "demote" && flags.UseHQ {
+			logrus.Fatal("--timegate-action demote can't be used with --hq")
+		}
+
+		if flags.TimeGateMaxAge <= 0 {
+			logrus.Fatalf("invalid --timegate-max-age value: %d, must be positive", flags.TimeGateMaxAge)
+		}
+
+		c.TimeGate = crawl.NewTimeGate(flags.TimeGate, time.Duration(flags.TimeGateMaxAge)*time.Hour, flags.TimeGateAction)
+	}
+
+	if flags.AlertErrorRate > 0 || flags.Alert429Rate > 0 || flags.AlertLatency > 0 {
+		c.Alerts = crawl.NewAlerts(flags.AlertErrorRate, flags.Alert429Rate, time.Duration(flags.AlertLatency)*time.Millisecond, flags.AlertDuration, flags.AlertWebhook)
+	}
+
+	if flags.HostCompletionEvents || flags.HostCompletionWebhook != "" {
+		c.HostCompletion = crawl.NewHostCompletion(flags.HostCompletionWebhook)
+	}
+
+	for _, fault := range flags.ChaosFaults.Value() {
+		if !utils.StringInSlice(fault, crawl.ChaosFaults) {
+			logrus.Fatalf("invalid --chaos-fault value: %s, must be \"timeout\", \"5xx\" or \"truncate\"", fault)
+		}
+	}
+	c.ChaosRate = flags.ChaosRate
+	c.ChaosFaults = flags.ChaosFaults.Value()
+
+	if c.ChaosRate > 0 {
+		logrus.Warnf("Chaos mode: synthetic failures are injected in %.2f%% of the requests, don't use it for a production crawl", c.ChaosRate)
+	}
 	c.CrawlTimeLimit = flags.CrawlTimeLimit
+	c.MinSpaceRequired = flags.MinSpaceRequired
+	c.DiskFullAbort = time.Duration(flags.DiskFullAbort) * time.Minute
+
+	if flags.ExitErrorThreshold < 0 || flags.ExitErrorThreshold > 1 {
+		logrus.Fatalf("invalid --exit-error-threshold value: %f, must be between 0 and 1", flags.ExitErrorThreshold)
+	}
+	c.ExitErrorThreshold = flags.ExitErrorThreshold
 
 	// Defaults --max-crawl-time-limit to 10% more than --crawl-time-limit
 	if flags.MaxCrawlTimeLimit == 0 && flags.CrawlTimeLimit != 0 {
@@ -66,8 +261,50 @@ func InitCrawlWithCMD(flags config.Flags) *crawl.Crawl {
 		c.MaxCrawlTimeLimit = flags.MaxCrawlTimeLimit
 	}
 
+	if flags.CrawlWindow != "" {
+		window, err := crawl.ParseCrawlWindow(flags.CrawlWindow, flags.CrawlWindowTimezone)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		c.CrawlWindow = window
+	}
+
 	c.MaxRetry = flags.MaxRetry
+	c.AssetMaxRetry = flags.AssetMaxRetry
 	c.MaxRedirect = flags.MaxRedirect
+	c.MaxCrossHostRedirect = flags.MaxCrossHostRedirect
+
+	if flags.RedirectPolicy != "follow" && flags.RedirectPolicy != "record" && flags.RedirectPolicy != "drop" {
+		logrus.Fatalf("invalid --redirect-policy value: %s, must be \"follow\", \"record\" or \"drop\"", flags.RedirectPolicy)
+	}
+	c.RedirectPolicy = flags.RedirectPolicy
+
+	if flags.RedirectScope != "host" && flags.RedirectScope != "domain" {
+		logrus.Fatalf("invalid --redirect-scope value: %s, must be \"host\" or \"domain\"", flags.RedirectScope)
+	}
+	c.RedirectScope = flags.RedirectScope
+
+	if flags.NofollowPolicy != "follow" && flags.NofollowPolicy != "flag" && flags.NofollowPolicy != "skip" {
+		logrus.Fatalf("invalid --nofollow-policy value: %s, must be \"follow\", \"flag\" or \"skip\"", flags.NofollowPolicy)
+	}
+	c.NofollowPolicy = flags.NofollowPolicy
+
+	for _, rule := range flags.BodyRules.Value() {
+		bodyRule, err := crawl.ParseBodyRule(rule)
+		if err != nil {
+			logrus.Fatalf("invalid --body-rule value: %s, %s", rule, err)
+		}
+
+		c.BodyRules = append(c.BodyRules, bodyRule)
+	}
+
+	if flags.BodyRuleMaxSize <= 0 {
+		logrus.Fatalf("invalid --body-rule-max-size value: %d, must be positive", flags.BodyRuleMaxSize)
+	}
+	c.BodyRuleMaxSize = flags.BodyRuleMaxSize
+
+	c.MaxURLLength = flags.MaxURLLength
+	c.MaxQueryParams = flags.MaxQueryParams
 	c.MaxHops = uint8(flags.MaxHops)
 	c.DomainsCrawl = flags.DomainsCrawl
 	c.DisableAssetsCapture = flags.DisableAssetsCapture
@@ -75,10 +312,26 @@ func InitCrawlWithCMD(flags config.Flags) *crawl.Crawl {
 	c.ExcludedHosts = flags.ExcludedHosts.Value()
 	c.IncludedHosts = flags.IncludedHosts.Value()
 	c.CaptureAlternatePages = flags.CaptureAlternatePages
+	c.CaptureMobileVersions = flags.CaptureMobileVersions
+	c.HTMLTokenizerThreshold = flags.HTMLTokenizerThreshold
+	c.RangeRecoveryMinSize = flags.RangeRecoveryMinSize
+	c.ArchiveListingMaxSize = flags.ArchiveListingMaxSize
+	c.CaptureTimings = flags.CaptureTimings
 	c.ExcludedStrings = flags.ExcludedStrings.Value()
 
+	// Defaults --tracking-param to the most common tracking parameters
+	c.StripTrackingParams = flags.StripTrackingParams
+	c.TrackingParams = flags.TrackingParams.Value()
+	if len(c.TrackingParams) == 0 {
+		c.TrackingParams = crawl.DefaultTrackingParams
+	}
+
+	if flags.HostSecurity {
+		c.HostSecurity = crawl.NewHostSecurity()
+	}
+
 	// WARC settings
-	c.WARCPrefix = flags.WARCPrefix
+	c.WARCPrefix = utils.SanitizeFileName(flags.WARCPrefix)
 	c.WARCOperator = flags.WARCOperator
 
 	if flags.WARCTempDir != "" {
@@ -87,14 +340,65 @@ func InitCrawlWithCMD(flags config.Flags) *crawl.Crawl {
 		c.WARCTempDir = path.Join(c.JobPath, "temp")
 	}
 
+	if flags.WARCSpoolDir != "" {
+		c.WARCSpoolDir = flags.WARCSpoolDir
+	} else {
+		c.WARCSpoolDir = path.Join(c.JobPath, "warcs")
+	}
+
 	c.CDXDedupeServer = flags.CDXDedupeServer
 	c.DisableLocalDedupe = flags.DisableLocalDedupe
 	c.CertValidation = flags.CertValidation
 	c.WARCFullOnDisk = flags.WARCFullOnDisk
 	c.WARCPoolSize = flags.WARCPoolSize
+
+	if flags.WARCWritersRouting != "" && flags.WARCWritersRouting != "host" && flags.WARCWritersRouting != "worker" {
+		logrus.Fatalf("invalid --warc-writers-routing value: %s, must be \"host\" or \"worker\"", flags.WARCWritersRouting)
+	}
+	c.WARCWritersRouting = flags.WARCWritersRouting
+
+	for _, collection := range flags.WARCCollections.Value() {
+		if collection == "" || strings.ContainsAny(collection, " \t") || utils.SanitizeFileName(collection) != collection {
+			logrus.Fatalf("invalid --warc-collection value: %q, must be usable in a file name", collection)
+		}
+	}
+	c.WARCCollections = flags.WARCCollections.Value()
+
+	// Defaults --warc-queue-size to 8 times the number of workers
+	if flags.WARCQueueSize == 0 {
+		c.WARCQueueSize = c.Workers * 8
+	} else {
+		c.WARCQueueSize = flags.WARCQueueSize
+	}
 	c.WARCDedupSize = flags.WARCDedupSize
 	c.WARCCustomCookie = flags.WARCCustomCookie
 
+	if flags.WARCManifest && !flags.DryRun {
+		manifest, err := crawl.NewWARCManifest(c.JobPath)
+		if err != nil {
+			logrus.Fatalf("unable to open the WARC manifest: %s", err)
+		}
+
+		c.WARCManifest = manifest
+	}
+
+	c.WARCIndex = flags.WARCIndex && !flags.DryRun
+
+	if flags.IAS3Item != "" && !flags.DryRun {
+		if flags.IAS3AccessKey == "" || flags.IAS3SecretKey == "" {
+			logrus.Fatal("--ias3-access-key and --ias3-secret-key are required to upload to archive.org")
+		}
+
+		uploader := upload.NewIAS3(flags.IAS3Endpoint, flags.IAS3AccessKey, flags.IAS3SecretKey, flags.IAS3Item, flags.IAS3Collection, flags.IAS3MediaType, flags.IAS3Subjects.Value())
+
+		uploadState, err := crawl.NewUploadState(c.JobPath, uploader)
+		if err != nil {
+			logrus.Fatalf("unable to load the upload state: %s", err)
+		}
+
+		c.UploadState = uploadState
+	}
+
 	c.API = flags.API
 	c.APIPort = flags.APIPort
 
@@ -115,15 +419,71 @@ func InitCrawlWithCMD(flags config.Flags) *crawl.Crawl {
 	}
 	c.Headless = flags.Headless
 
+	// There is no headless browser backend yet, the browser-only features
+	// (like the WebSocket recording) can't be enabled, the crawl uses standard GET requests
+	if c.Headless {
+		logrus.Warn("--headless is not implemented yet, standard GET requests will be used and browser-only features like WebSocket recording are unavailable")
+	}
+
 	c.CookieFile = flags.CookieFile
 	c.KeepCookies = flags.KeepCookies
 
+	if flags.CookiePolicy != "accept" && flags.CookiePolicy != "block" && flags.CookiePolicy != "session" && flags.CookiePolicy != "allowlist" {
+		logrus.Fatalf("invalid --cookie-policy value: %s, must be \"accept\", \"block\", \"session\" or \"allowlist\"", flags.CookiePolicy)
+	}
+	c.CookiePolicy = flags.CookiePolicy
+
+	for _, host := range flags.CookieAllowedHosts.Value() {
+		c.CookieAllowedHosts = append(c.CookieAllowedHosts, strings.ToLower(host))
+	}
+
+	credentials, err := crawl.ParseHTTPCredentials(flags.HTTPCredentials.Value())
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	c.HTTPCredentials = credentials
+
+	if flags.HostsFile != "" {
+		c.DNSOverrides, err = crawl.LoadHostsFile(flags.HostsFile)
+		if err != nil {
+			logrus.Fatalf("unable to load the hosts file: %s", err)
+		}
+	}
+
+	c.ConfigFile = flags.ConfigFile
+
+	if flags.QueueBackend != "" {
+		if flags.UseHQ {
+			logrus.Fatal("--queue-backend can't be used with --hq")
+		}
+
+		if !utils.StringInSlice(flags.QueueBackend, crawl.QueueBackends()) {
+			logrus.Fatalf("invalid --queue-backend value: %s, must be one of: %s", flags.QueueBackend, strings.Join(crawl.QueueBackends(), ", "))
+		}
+	}
+	c.QueueBackendName = flags.QueueBackend
+	c.RedisURL = flags.RedisURL
+	c.RedisStream = flags.RedisStream
+	c.RedisGroup = flags.RedisGroup
+
+	// Claiming the entries as soon as they are pending would steal the ones other crawlers are capturing
+	if flags.RedisClaimIdle <= 0 {
+		logrus.Fatalf("invalid --redis-claim-idle value: %d, must be a positive number of seconds", flags.RedisClaimIdle)
+	}
+	c.RedisClaimIdle = flags.RedisClaimIdle
+
 	// Proxy settings
 	c.Proxy = flags.Proxy
 	c.BypassProxy = flags.BypassProxy.Value()
 
 	// Crawl HQ settings
 	c.UseHQ = flags.UseHQ
+
+	// Crawl HQ only keeps the hop of the URLs, not how deep in a listing they are
+	if flags.PaginationDepth > 0 && flags.UseHQ {
+		logrus.Fatal("--pagination-depth can't be used with --hq")
+	}
+	c.PaginationDepth = flags.PaginationDepth
 	c.HQProject = flags.HQProject
 	c.HQAddress = flags.HQAddress
 	c.HQKey = flags.HQKey
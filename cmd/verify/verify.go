@@ -0,0 +1,65 @@
+package verify
+
+import (
+	"github.com/internetarchive/Zeno/cmd"
+	"github.com/internetarchive/Zeno/config"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	cmd.RegisterCommand(
+		cli.Command{
+			Name:      "verify",
+			Usage:     "Check the replay completeness of the pages captured by a job.",
+			Action:    cmdVerify,
+			UsageText: "[SEED_LIST] [ARGUMENTS]",
+			Flags: []cli.Flag{
+				&cli.IntFlag{
+					Name:  "sample",
+					Value: 100,
+					Usage: "Number of captured pages to verify, picked randomly. Ignored if a seed list is given, every captured seed is verified then.",
+				},
+			},
+		})
+}
+
+func cmdVerify(c *cli.Context) error {
+	crawl := cmd.InitCrawlWithCMD(config.App.Flags)
+
+	if c.Args().Len() > 0 {
+		seeds, _, err := frontier.IsSeedList(c.Args().Get(0))
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"input": c.Args().Get(0),
+				"err":   err.Error(),
+			}).Error("This is not a valid seed list")
+			return err
+		}
+
+		crawl.SeedList = seeds
+	}
+
+	pages, err := crawl.VerifyCaptures(c.Int("sample"))
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"err": err.Error(),
+		}).Error("Unable to verify the captures")
+		return err
+	}
+
+	var assets, missing int
+	for _, page := range pages {
+		assets += page.Assets
+		missing += page.Missing
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"pages":   len(pages),
+		"assets":  assets,
+		"missing": missing,
+	}).Info("Captures verified, the report has been written to verification.csv")
+
+	return nil
+}
@@ -5,47 +5,151 @@ import "github.com/urfave/cli/v2"
 type Flags struct {
 	UserAgent           string
 	Job                 string
+	JobID               string
+	JobsDirectory       string
+	OutputLayout        string
 	Workers             int
+	MinWorkers          int
+	MaxWorkers          int
+	MaxMemory           int
+	MaxBandwidth        int
 	MaxConcurrentAssets int
+	MaxAssetsPerPage    int
+	QueueOverflowAssets bool
+	AssetScope          string
+	AssetAllowedHosts   cli.StringSlice
+	UpgradeMixedContent bool
 	MaxHops             uint
 	Headless            bool
 	Seencheck           bool
 	JSON                bool
 	LiveStats           bool
+	SeedsReport         bool
+	HeritrixCrawlLog    bool
+	DryRun              bool
+	LinkGraph           bool
+	AttachmentsReport   bool
+	SyslogAddress       string
+	Journald            bool
+	LogSampleInfo       int
+	LogSampleWarning    int
+	LogSampleError      int
+	LogSampleInterval   int
 	Debug               bool
 
+	ResolveSeedShorteners          bool
+	ResolveShorteners              bool
+	SeencheckSeedAliases           bool
 	DisabledHTMLTags               cli.StringSlice
 	ExcludedHosts                  cli.StringSlice
 	IncludedHosts                  cli.StringSlice
+	StripTrackingParams            bool
+	TrackingParams                 cli.StringSlice
+	HostSecurity                   bool
 	DomainsCrawl                   bool
+	PaginationDepth                int
 	CaptureAlternatePages          bool
+	CaptureMobileVersions          bool
+	HTMLTokenizerThreshold         int
+	RangeRecoveryMinSize           int
+	ArchiveListingMaxSize          int
+	CaptureTimings                 bool
 	HTTPTimeout                    int
+	AssetHTTPTimeout               int
 	MaxRedirect                    int
+	MaxCrossHostRedirect           int
+	RedirectPolicy                 string
+	RedirectScope                  string
+	NofollowPolicy                 string
+	BodyRules                      cli.StringSlice
+	BodyRuleMaxSize                int
+	MaxURLLength                   int
+	MaxQueryParams                 int
 	MaxRetry                       int
+	AssetMaxRetry                  int
 	MaxConcurrentRequestsPerDomain int
+	MaxConcurrentRequestsPerCDN    int
+	MaxConsecutivePerHost          int
+	TargetRate                     float64
+	RecentOutlinksSize             int
+	RecentOutlinksWindow           int
+	MediaURLPatterns               cli.StringSlice
+	MediaWorkers                   int
+	MediaMaxBandwidth              int
+	PriorityWorkers                int
+	CDNSuffixes                    cli.StringSlice
 	RateLimitDelay                 int
+	CircuitBreakerThreshold        int
+	CircuitBreakerCooldown         int
+	CircuitBreakerAction           string
+	HostTimeBudget                 int
+	TimeGate                       string
+	TimeGateMaxAge                 int
+	TimeGateAction                 string
+	AlertErrorRate                 float64
+	Alert429Rate                   float64
+	AlertLatency                   int
+	AlertDuration                  int
+	AlertWebhook                   string
+	HostCompletionEvents           bool
+	HostCompletionWebhook          string
+	ChaosRate                      float64
+	ChaosFaults                    cli.StringSlice
 	CrawlTimeLimit                 int
 	MaxCrawlTimeLimit              int
+	CrawlWindow                    string
+	CrawlWindowTimezone            string
 	RandomLocalIP                  bool
+	MinSpaceRequired               int
+	DiskFullAbort                  int
+	ExitErrorThreshold             float64
 
 	Proxy       string
 	BypassProxy cli.StringSlice
 
-	CookieFile  string
-	KeepCookies bool
+	QueueBackend   string
+	RedisURL       string
+	RedisStream    string
+	RedisGroup     string
+	RedisClaimIdle int
+
+	CookieFile         string
+	KeepCookies        bool
+	CookiePolicy       string
+	CookieAllowedHosts cli.StringSlice
+
+	HTTPCredentials cli.StringSlice
+
+	HostsFile string
+
+	ConfigFile string
 
 	API              bool
 	APIPort          string
 	Prometheus       bool
 	PrometheusPrefix string
 
-	WARCPrefix       string
-	WARCOperator     string
-	WARCPoolSize     int
-	WARCDedupSize    int
-	WARCFullOnDisk   bool
-	WARCTempDir      string
-	WARCCustomCookie string
+	WARCPrefix         string
+	WARCOperator       string
+	WARCPoolSize       int
+	WARCWritersRouting string
+	WARCCollections    cli.StringSlice
+	WARCQueueSize      int
+	WARCDedupSize      int
+	WARCFullOnDisk     bool
+	WARCTempDir        string
+	WARCSpoolDir       string
+	WARCCustomCookie   string
+	WARCManifest       bool
+	WARCIndex          bool
+
+	IAS3Endpoint   string
+	IAS3AccessKey  string
+	IAS3SecretKey  string
+	IAS3Item       string
+	IAS3Collection string
+	IAS3MediaType  string
+	IAS3Subjects   cli.StringSlice
 
 	UseHQ                  bool
 	HQBatchSize            int64
@@ -0,0 +1,241 @@
+package crawl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// alertsInterval is the length of the windows the alert thresholds are evaluated on
+const alertsInterval = time.Minute
+
+// alertMinimumRequests is the number of requests below which a window isn't
+// evaluated, so that a host with a couple of failed requests doesn't raise an alert
+const alertMinimumRequests = 10
+
+// Alerts raise an alert, in the logs and with a webhook, when the error rate, the
+// 429 rate or the average latency of the whole crawl or of a single host is over its
+// threshold for Duration consecutive minutes, and when it goes back under it.
+type Alerts struct {
+	sync.Mutex
+	ErrorRate  float64
+	TooManyReq float64
+	Latency    time.Duration
+	Duration   int
+	Webhook    string
+	window     map[string]*alertWindow
+	firing     map[alertKey]int
+}
+
+type alertKey struct {
+	scope  string
+	metric string
+}
+
+type alertWindow struct {
+	requests      int
+	errors        int
+	tooManyReq    int
+	totalDuration time.Duration
+}
+
+// Alert is a threshold crossed (or recovered) by the crawl or a host, as sent to the webhook
+type Alert struct {
+	Status    string  `json:"status"`
+	Scope     string  `json:"scope"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Minutes   int     `json:"minutes"`
+	Job       string  `json:"job"`
+	JobID     string  `json:"jobId"`
+}
+
+// alertCrawlScope is the scope of the alerts about the whole crawl, the others are hosts
+const alertCrawlScope = "crawl"
+
+// NewAlerts return alerts for the given thresholds, a zero threshold disables its alert
+func NewAlerts(errorRate, tooManyReqRate float64, latency time.Duration, duration int, webhook string) *Alerts {
+	if duration < 1 {
+		duration = 1
+	}
+
+	return &Alerts{
+		ErrorRate:  errorRate,
+		TooManyReq: tooManyReqRate,
+		Latency:    latency,
+		Duration:   duration,
+		Webhook:    webhook,
+		window:     make(map[string]*alertWindow),
+		firing:     make(map[alertKey]int),
+	}
+}
+
+// record add a request to the current window of the crawl and of its host,
+// a request is an error if it failed or if the server answered with a 5xx
+func (a *Alerts) record(host string, resp *http.Response, err error, duration time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+
+	for _, scope := range []string{alertCrawlScope, host} {
+		window, exists := a.window[scope]
+		if !exists {
+			window = new(alertWindow)
+			a.window[scope] = window
+		}
+
+		window.requests++
+		window.totalDuration += duration
+
+		switch {
+		case err != nil || resp == nil || resp.StatusCode >= 500:
+			window.errors++
+		case resp.StatusCode == 429:
+			window.tooManyReq++
+		}
+	}
+}
+
+// evaluate close the current window and return the alerts that started
+// or recovered, the firing state of every scope and metric is kept between windows
+func (a *Alerts) evaluate() (alerts []*Alert) {
+	a.Lock()
+	defer a.Unlock()
+
+	var scopes []string
+	for scope := range a.window {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	over := make(map[alertKey]bool)
+
+	for _, scope := range scopes {
+		window := a.window[scope]
+		if window.requests < alertMinimumRequests {
+			continue
+		}
+
+		values := map[string]float64{
+			"error_rate": float64(window.errors) / float64(window.requests),
+			"429_rate":   float64(window.tooManyReq) / float64(window.requests),
+			"latency_ms": float64((window.totalDuration / time.Duration(window.requests)).Milliseconds()),
+		}
+		thresholds := map[string]float64{
+			"error_rate": a.ErrorRate,
+			"429_rate":   a.TooManyReq,
+			"latency_ms": float64(a.Latency.Milliseconds()),
+		}
+
+		for _, metric := range []string{"error_rate", "429_rate", "latency_ms"} {
+			if thresholds[metric] <= 0 || values[metric] < thresholds[metric] {
+				continue
+			}
+
+			key := alertKey{scope: scope, metric: metric}
+			over[key] = true
+			a.firing[key]++
+
+			// The alert is raised once, when the threshold has been crossed for long enough
+			if a.firing[key] == a.Duration {
+				alerts = append(alerts, &Alert{
+					Status:    "firing",
+					Scope:     scope,
+					Metric:    metric,
+					Value:     values[metric],
+					Threshold: thresholds[metric],
+					Minutes:   a.Duration,
+				})
+			}
+		}
+	}
+
+	for key, minutes := range a.firing {
+		if over[key] {
+			continue
+		}
+
+		if minutes >= a.Duration {
+			alerts = append(alerts, &Alert{
+				Status:  "resolved",
+				Scope:   key.scope,
+				Metric:  key.metric,
+				Minutes: minutes,
+			})
+		}
+
+		delete(a.firing, key)
+	}
+
+	a.window = make(map[string]*alertWindow)
+
+	return alerts
+}
+
+// recordAlertSample add the outcome of a request to the alerts windows, if the alerts are enabled
+func (c *Crawl) recordAlertSample(item *frontier.Item, resp *http.Response, err error, requestStart time.Time) {
+	if c.Alerts == nil {
+		return
+	}
+
+	c.Alerts.record(item.Host, resp, err, time.Since(requestStart))
+}
+
+// watchAlerts evaluate the alerts every minute, and log
+// and send to the webhook the ones that started or recovered
+func (c *Crawl) watchAlerts() {
+	for !c.Finished.Get() {
+		time.Sleep(alertsInterval)
+
+		for _, alert := range c.Alerts.evaluate() {
+			alert.Job = c.Job
+			alert.JobID = c.JobID
+
+			fields := c.genLogFields(nil, nil, map[string]interface{}{
+				"scope":   alert.Scope,
+				"metric":  alert.Metric,
+				"minutes": alert.Minutes,
+			})
+
+			if alert.Status == "firing" {
+				fields["value"] = alert.Value
+				fields["threshold"] = alert.Threshold
+				logWarning.WithFields(fields).Warn("alert firing")
+			} else {
+				logInfo.WithFields(fields).Info("alert resolved")
+			}
+
+			if c.Alerts.Webhook != "" {
+				go c.sendAlert(alert)
+			}
+		}
+	}
+}
+
+// sendAlert POST the alert as JSON to the --alert-webhook URL
+func (c *Crawl) sendAlert(alert *Alert) {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Post(c.Alerts.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logError.WithFields(c.genLogFields(err, c.Alerts.Webhook, nil)).Error("unable to send alert to the webhook")
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logError.WithFields(c.genLogFields(nil, c.Alerts.Webhook, map[string]interface{}{
+			"statusCode": resp.StatusCode,
+		})).Error("unable to send alert to the webhook")
+	}
+}
@@ -0,0 +1,45 @@
+package crawl
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlerts(t *testing.T) {
+	alerts := NewAlerts(0.5, 0, 0, 2, "")
+
+	failingMinute := func() {
+		for i := 0; i < alertMinimumRequests; i++ {
+			alerts.record("example.com", nil, errors.New("timeout"), time.Second)
+			alerts.record("example.org", &http.Response{StatusCode: 200}, nil, time.Second)
+		}
+	}
+
+	// The error rate of the crawl is exactly 0.5, the one of example.com is 1
+	failingMinute()
+	assert.Empty(t, alerts.evaluate())
+
+	failingMinute()
+	fired := alerts.evaluate()
+	assert.Len(t, fired, 2)
+	assert.Equal(t, "firing", fired[0].Status)
+	assert.Equal(t, "crawl", fired[0].Scope)
+	assert.Equal(t, "example.com", fired[1].Scope)
+	assert.Equal(t, "error_rate", fired[1].Metric)
+
+	// Already firing, not raised again
+	failingMinute()
+	assert.Empty(t, alerts.evaluate())
+
+	for i := 0; i < alertMinimumRequests; i++ {
+		alerts.record("example.com", &http.Response{StatusCode: 200}, nil, time.Second)
+	}
+
+	resolved := alerts.evaluate()
+	assert.Len(t, resolved, 2)
+	assert.Equal(t, "resolved", resolved[0].Status)
+}
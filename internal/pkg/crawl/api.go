@@ -6,6 +6,8 @@ import (
 
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -24,13 +26,166 @@ func (crawl *Crawl) startAPI() {
 		crawledSeeds := crawl.CrawledSeeds.Value()
 		crawledAssets := crawl.CrawledAssets.Value()
 
+		queueAge := crawl.Frontier.QueueAge.Percentiles(0.5, 0.9, 0.99)
+
 		c.JSON(200, gin.H{
 			"rate":          crawl.URIsPerSecond.Rate(),
 			"crawled":       crawledSeeds + crawledAssets,
 			"crawledSeeds":  crawledSeeds,
 			"crawledAssets": crawledAssets,
 			"queued":        crawl.Frontier.QueueCount.Value(),
+			"queueAgeP50":   queueAge[0].String(),
+			"queueAgeP90":   queueAge[1].String(),
+			"queueAgeP99":   queueAge[2].String(),
+			"skippedLinks":  crawl.SkippedLinks.Values(),
+			"nofollowLinks": crawl.NofollowLinks.Values(),
+			"botChallenges": crawl.BotChallenges.Values(),
+			"downloaded":    crawl.ByteCounters.DownloadedValues(),
+			"warcWritten":   crawl.ByteCounters.WrittenValues(),
+			"rejectedURLs":  crawl.RejectedURLs.Value(),
+			"expiredItems":  crawl.ExpiredItems.Value(),
+			"collapsedURLs": crawl.CollapsedOutlinks.Value(),
+			"openCircuits":  crawl.getOpenCircuits(),
+			"warcQueue":     crawl.getWARCWritingQueueSize(),
+			"warcBlocked":   time.Duration(crawl.WARCWritingBlockedTime.Value()).String(),
 			"uptime":        time.Since(crawl.StartTime).String(),
+			"jobId":         crawl.JobID,
+		})
+	})
+
+	// Control the size of the worker pool
+	r.GET("/workers", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"workers":       crawl.getWorkersCount(),
+			"activeWorkers": crawl.ActiveWorkers.Value(),
+			"minWorkers":    crawl.MinWorkers,
+			"maxWorkers":    crawl.MaxWorkers,
+		})
+	})
+
+	r.POST("/workers", func(c *gin.Context) {
+		var request struct {
+			Workers    int `json:"workers"`
+			MinWorkers int `json:"minWorkers"`
+			MaxWorkers int `json:"maxWorkers"`
+		}
+
+		err := c.ShouldBindJSON(&request)
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+
+		if request.MinWorkers != 0 || request.MaxWorkers != 0 {
+			if request.MinWorkers == 0 {
+				request.MinWorkers = crawl.MinWorkers
+			}
+
+			if request.MaxWorkers == 0 {
+				request.MaxWorkers = crawl.MaxWorkers
+			}
+
+			err = crawl.setWorkersBounds(request.MinWorkers, request.MaxWorkers)
+			if err != nil {
+				c.JSON(400, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		if request.Workers != 0 {
+			crawl.setWorkersCount(request.Workers)
+		}
+
+		logInfo.WithFields(crawl.genLogFields(nil, nil, map[string]interface{}{
+			"workers":    crawl.getWorkersCount(),
+			"minWorkers": crawl.MinWorkers,
+			"maxWorkers": crawl.MaxWorkers,
+		})).Info("worker pool changed through the API")
+
+		c.JSON(200, gin.H{
+			"workers":    crawl.getWorkersCount(),
+			"minWorkers": crawl.MinWorkers,
+			"maxWorkers": crawl.MaxWorkers,
+		})
+	})
+
+	// Control the number of URIs per second the crawl is paced to, 0 to disable the pacing
+	r.GET("/target-rate", func(c *gin.Context) {
+		c.JSON(200, gin.H{
+			"targetRate": crawl.TargetRate.Target(),
+			"rate":       crawl.URIsPerSecond.Rate(),
+		})
+	})
+
+	r.POST("/target-rate", func(c *gin.Context) {
+		var request struct {
+			TargetRate float64 `json:"targetRate"`
+		}
+
+		err := c.ShouldBindJSON(&request)
+		if err != nil || request.TargetRate < 0 {
+			c.JSON(400, gin.H{"error": "targetRate must be a positive number of URIs per second, or 0 to disable the pacing"})
+			return
+		}
+
+		crawl.TargetRate.SetTarget(request.TargetRate)
+
+		logInfo.WithFields(crawl.genLogFields(nil, nil, map[string]interface{}{
+			"targetRate": request.TargetRate,
+		})).Info("target rate changed through the API")
+
+		c.JSON(200, gin.H{"targetRate": crawl.TargetRate.Target()})
+	})
+
+	// Submit urgent seeds, captured within seconds by the priority workers
+	r.POST("/urgent", func(c *gin.Context) {
+		var request struct {
+			URLs []string `json:"urls"`
+		}
+
+		err := c.ShouldBindJSON(&request)
+		if err != nil || len(request.URLs) == 0 {
+			c.JSON(400, gin.H{"error": "urls must be a list of URLs"})
+			return
+		}
+
+		if crawl.PriorityLane == nil {
+			c.JSON(400, gin.H{"error": "the priority lane is disabled, see --priority-workers"})
+			return
+		}
+
+		var (
+			accepted []string
+			rejected = make(map[string]string)
+		)
+
+		for _, rawURL := range request.URLs {
+			URL, _, err := frontier.NormalizeSeed(rawURL)
+			if err != nil {
+				rejected[rawURL] = err.Error()
+				continue
+			}
+
+			item := frontier.NewItem(URL, nil, "seed", 0, "", false)
+			item.Urgent = true
+
+			err = crawl.submitUrgentItem(item)
+			if err != nil {
+				rejected[rawURL] = err.Error()
+				continue
+			}
+
+			accepted = append(accepted, utils.URLToString(URL))
+		}
+
+		logInfo.WithFields(crawl.genLogFields(nil, nil, map[string]interface{}{
+			"accepted": len(accepted),
+			"rejected": len(rejected),
+		})).Info("urgent seeds submitted through the API")
+
+		c.JSON(200, gin.H{
+			"accepted": accepted,
+			"rejected": rejected,
 		})
 	})
 
@@ -39,6 +194,7 @@ func (crawl *Crawl) startAPI() {
 		labels := make(map[string]string)
 
 		labels["crawljob"] = crawl.Job
+		labels["crawljobid"] = crawl.JobID
 		hostname, err := os.Hostname()
 		if err != nil {
 			logWarning.Warn("Unable to retrieve hostname of machine")
@@ -52,6 +208,65 @@ func (crawl *Crawl) startAPI() {
 			Help:        "The total number of crawled URI",
 		})
 
+		crawl.PrometheusMetrics.QueueAge = promauto.NewSummary(prometheus.SummaryOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "queue_age_seconds",
+			ConstLabels: labels,
+			Help:        "Time spent by URIs in the queue before being captured",
+			Objectives:  map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		})
+
+		crawl.PrometheusMetrics.WARCWritingQueueDepth = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "warc_writing_queue_depth",
+			ConstLabels: labels,
+			Help:        "The number of records waiting to be written to WARC files",
+		}, func() float64 {
+			return float64(crawl.getWARCWritingQueueSize())
+		})
+
+		crawl.PrometheusMetrics.WARCWritingBlockedTime = promauto.NewCounter(prometheus.CounterOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "warc_writing_blocked_seconds_total",
+			ConstLabels: labels,
+			Help:        "The total time spent waiting for the WARC writers",
+		})
+
+		crawl.PrometheusMetrics.CircuitsOpened = promauto.NewCounter(prometheus.CounterOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "circuits_opened_total",
+			ConstLabels: labels,
+			Help:        "The total number of times a host circuit has been opened after consecutive failures",
+		})
+
+		crawl.PrometheusMetrics.OpenCircuits = promauto.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "open_circuits",
+			ConstLabels: labels,
+			Help:        "The number of hosts whose circuit is currently open",
+		}, func() float64 {
+			return float64(crawl.getOpenCircuits())
+		})
+
+		crawl.PrometheusMetrics.BotChallenges = promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "bot_challenges_total",
+			ConstLabels: labels,
+			Help:        "The total number of bot challenge pages received instead of the pages, per vendor",
+		}, []string{"vendor"})
+
+		crawl.PrometheusMetrics.ExpiredItems = promauto.NewCounter(prometheus.CounterOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "expired_items_total",
+			ConstLabels: labels,
+			Help:        "The total number of items dropped because their deadline passed before they were captured",
+		})
+
+		crawl.PrometheusMetrics.DownloadedBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "downloaded_bytes_total",
+			ConstLabels: labels,
+			Help:        "The total number of bytes of response bodies downloaded, per MIME class",
+		}, []string{"mime_class"})
+
+		crawl.PrometheusMetrics.WARCWrittenBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+			Name:        crawl.PrometheusMetrics.Prefix + "warc_written_bytes_total",
+			ConstLabels: labels,
+			Help:        "The total number of bytes of WARC records written, per record type and MIME class",
+		}, []string{"record_type", "mime_class"})
+
 		logInfo.Info("Starting Prometheus export")
 		r.GET("/metrics", gin.WrapH(promhttp.Handler()))
 	}
@@ -0,0 +1,152 @@
+package crawl
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// archiveEntry is a file listed in the metadata record of a captured archive
+type archiveEntry struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// getArchiveFormat return the format of the archive served by the response, if any
+func getArchiveFormat(resp *http.Response) string {
+	var (
+		contentType = resp.Header.Get("Content-Type")
+		URLPath     = strings.ToLower(resp.Request.URL.Path)
+	)
+
+	switch {
+	case strings.Contains(contentType, "zip") && !strings.Contains(contentType, "gzip"), strings.HasSuffix(URLPath, ".zip"):
+		return "zip"
+	case strings.HasSuffix(URLPath, ".tar.gz"), strings.HasSuffix(URLPath, ".tgz"):
+		return "tar.gz"
+	case strings.Contains(contentType, "tar"), strings.HasSuffix(URLPath, ".tar"):
+		return "tar"
+	}
+
+	return ""
+}
+
+// discardArchiveBody read the body of the response for the WARC writing, like discardBody.
+// With --archive-listing-max-size, the content of the zip and tar archives under the
+// size limit is listed in a metadata record, without extracting the files.
+func (c *Crawl) discardArchiveBody(item *frontier.Item, resp *http.Response) error {
+	format := getArchiveFormat(resp)
+	maxSize := int64(c.ArchiveListingMaxSize * MB)
+
+	if c.ArchiveListingMaxSize <= 0 || format == "" || resp.ContentLength > maxSize {
+		return discardBody(resp.Body)
+	}
+
+	body, err := readBody(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return err
+	}
+	defer releaseBody(body)
+
+	// The size wasn't announced and the archive is too big
+	if int64(body.Len()) > maxSize {
+		return discardBody(resp.Body)
+	}
+
+	entries, err := listArchive(format, body.Bytes())
+	if err != nil {
+		if c.shouldLog(logrus.WarnLevel) {
+			logWarning.WithFields(c.genLogFields(err, item, map[string]interface{}{
+				"format": format,
+			})).Warn("unable to list the content of the archive")
+		}
+		return nil
+	}
+
+	listing, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	record := warc.NewRecord(c.WARCTempDir, c.WARCFullOnDisk)
+	record.Header.Set("WARC-Type", "metadata")
+	record.Header.Set("WARC-Target-URI", utils.URLToString(resp.Request.URL))
+	record.Header.Set("Content-Type", "application/json")
+
+	record.Content.Write(listing)
+
+	c.writeWARCRecords(item, record.Header.Get("WARC-Target-URI"), time.Now(), record)
+
+	if c.shouldLog(logrus.InfoLevel) {
+		logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"format":  format,
+			"entries": len(entries),
+		})).Info("archive content listed")
+	}
+
+	return nil
+}
+
+func listArchive(format string, content []byte) (entries []archiveEntry, err error) {
+	switch format {
+	case "zip":
+		reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range reader.File {
+			entries = append(entries, archiveEntry{
+				Name:     file.Name,
+				Size:     int64(file.UncompressedSize64),
+				Modified: file.Modified,
+			})
+		}
+
+		return entries, nil
+	case "tar", "tar.gz":
+		var reader io.Reader = bytes.NewReader(content)
+
+		if format == "tar.gz" {
+			gzipReader, err := gzip.NewReader(reader)
+			if err != nil {
+				return nil, err
+			}
+			defer gzipReader.Close()
+
+			reader = gzipReader
+		}
+
+		tarReader := tar.NewReader(reader)
+
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return entries, nil
+			} else if err != nil {
+				return entries, err
+			}
+
+			entries = append(entries, archiveEntry{
+				Name:     header.Name,
+				Size:     header.Size,
+				Modified: header.ModTime,
+			})
+		}
+	}
+
+	return nil, errors.New("unsupported archive format: " + format)
+}
@@ -0,0 +1,80 @@
+package crawl
+
+import (
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// assetStatsInterval is how often the aggregates of the asset captures are logged
+const assetStatsInterval = time.Minute
+
+// AssetStats aggregates the successful asset captures: they are only logged one by one
+// at the debug level, the aggregates are periodically logged at the info level
+type AssetStats struct {
+	count   int64
+	bytes   int64
+	latency int64
+}
+
+// countingBody counts the bytes read from the body of a response
+type countingBody struct {
+	io.ReadCloser
+	size int64
+}
+
+func (b *countingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.size += int64(n)
+
+	return n, err
+}
+
+// logAssetSuccess log a captured asset at the debug level, with its own latency and
+// size and the capture it belongs to, and add it to the aggregates
+func (c *Crawl) logAssetSuccess(item *frontier.Item, resp *http.Response, executionStart time.Time, size int64) {
+	latency := time.Since(executionStart)
+
+	atomic.AddInt64(&c.AssetStats.count, 1)
+	atomic.AddInt64(&c.AssetStats.bytes, size)
+	atomic.AddInt64(&c.AssetStats.latency, int64(latency))
+
+	logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+		"statusCode":      resp.StatusCode,
+		"contentType":     resp.Header.Get("Content-Type"),
+		"size":            size,
+		"executionTime":   latency.Milliseconds(),
+		"parentUrl":       utils.URLToString(item.ParentItem.URL),
+		"parentCaptureId": item.ParentItem.CaptureID,
+		"type":            "asset",
+	})).Debug("asset archived")
+}
+
+// logAssetAggregates periodically log the number of assets captured since the
+// last aggregate line, their total size and their average latency
+func (c *Crawl) logAssetAggregates() {
+	for !c.Finished.Get() {
+		time.Sleep(assetStatsInterval)
+
+		var (
+			count   = atomic.SwapInt64(&c.AssetStats.count, 0)
+			bytes   = atomic.SwapInt64(&c.AssetStats.bytes, 0)
+			latency = atomic.SwapInt64(&c.AssetStats.latency, 0)
+		)
+
+		if count == 0 {
+			continue
+		}
+
+		logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+			"assets":           count,
+			"size":             bytes,
+			"averageLatencyMs": (time.Duration(latency) / time.Duration(count)).Milliseconds(),
+			"interval":         assetStatsInterval.String(),
+		})).Info("assets archived")
+	}
+}
@@ -0,0 +1,46 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// filterAssetsScope remove the assets that are out of the --asset-scope of the item:
+// on another origin than the page, or on another registered domain. The assets on
+// the hosts given with --asset-allowed-host (or their subdomains) are always kept.
+func (c *Crawl) filterAssetsScope(item *frontier.Item, assets []*url.URL) (output []*url.URL) {
+	if c.AssetScope == "" || c.AssetScope == "all" {
+		return assets
+	}
+
+	for _, asset := range assets {
+		if c.isAssetInScope(item.URL, asset) {
+			output = append(output, asset)
+			continue
+		}
+
+		logInfo.WithFields(c.genLogFields(nil, asset, map[string]interface{}{
+			"parentUrl":  utils.URLToString(item.URL),
+			"assetScope": c.AssetScope,
+		})).Debug("asset out of scope, skipping")
+	}
+
+	return output
+}
+
+func (c *Crawl) isAssetInScope(page, asset *url.URL) bool {
+	for _, allowedHost := range c.AssetAllowedHosts {
+		if asset.Hostname() == allowedHost || strings.HasSuffix(asset.Hostname(), "."+allowedHost) {
+			return true
+		}
+	}
+
+	if c.AssetScope == "origin" {
+		return asset.Scheme == page.Scheme && asset.Host == page.Host
+	}
+
+	return registeredDomain(asset) == registeredDomain(page)
+}
@@ -18,7 +18,7 @@ func (c *Crawl) extractAssets(base *url.URL, item *frontier.Item, doc *goquery.D
 	if strings.Contains(base.Host, "cloudflarestream.com") {
 		cloudflarestreamURLs, err := cloudflarestream.GetSegments(base, *c.Client)
 		if err != nil {
-			logWarning.WithFields(c.genLogFields(err, item.URL, nil)).Warnln("error getting cloudflarestream segments")
+			logWarning.WithFields(c.genLogFields(err, item, nil)).Warnln("error getting cloudflarestream segments")
 		}
 
 		if len(cloudflarestreamURLs) > 0 {
@@ -35,6 +35,13 @@ func (c *Crawl) extractAssets(base *url.URL, item *frontier.Item, doc *goquery.D
 		}
 	})
 
+	// Get assets from the structured data (JSON-LD and microdata)
+	_, structuredDataAssets := extractStructuredData(doc)
+	rawAssets = append(rawAssets, structuredDataAssets...)
+
+	// Get the targets of the ESI and SSI includes
+	rawAssets = append(rawAssets, extractIncludes(doc)...)
+
 	// Check all elements style attributes for background-image & also data-preview
 	doc.Find("*").Each(func(index int, item *goquery.Selection) {
 		style, exists := item.Attr("style")
@@ -155,45 +162,22 @@ func (c *Crawl) extractAssets(base *url.URL, item *frontier.Item, doc *goquery.D
 			}
 
 			// Some <script> embed variable initialisation, we can strip the variable part and just scrape JSON
-			if !strings.HasPrefix(item.Text(), "{") {
-				jsonContent := strings.SplitAfterN(item.Text(), "=", 2)
-
-				if len(jsonContent) > 1 {
-					var (
-						openSeagullCount   int
-						closedSeagullCount int
-						payloadEndPosition int
-					)
-
-					// figure out the end of the payload
-					for pos, char := range jsonContent[1] {
-						if char == '{' {
-							openSeagullCount++
-						} else if char == '}' {
-							closedSeagullCount++
-						} else {
-							continue
-						}
-
-						if openSeagullCount > 0 {
-							if openSeagullCount == closedSeagullCount {
-								payloadEndPosition = pos
-								break
-							}
-						}
-					}
-
-					if len(jsonContent[1]) > payloadEndPosition {
-						URLsFromJSON, _ := getURLsFromJSON(jsonContent[1][:payloadEndPosition+1])
-						rawAssets = append(rawAssets, removeGoogleVideoURLs(URLsFromJSON)...)
-					}
-				}
-			}
+			rawAssets = append(rawAssets, getURLsFromScriptVariable(item.Text())...)
 		})
 	}
 
 	if !utils.StringInSlice("link", c.DisabledHTMLTags) {
 		doc.Find("link").Each(func(index int, item *goquery.Selection) {
+			// With --capture-mobile-versions, the AMP and mobile versions are captured as pages
+			if c.CaptureMobileVersions {
+				relation, _ := item.Attr("rel")
+				media, _ := item.Attr("media")
+
+				if isMobileVersionLink(relation, media) {
+					return
+				}
+			}
+
 			if !c.CaptureAlternatePages {
 				relation, exists := item.Attr("rel")
 				if exists && relation == "alternate" {
@@ -225,7 +209,11 @@ func (c *Crawl) extractAssets(base *url.URL, item *frontier.Item, doc *goquery.D
 			}
 			link, exists = item.Attr("content")
 			if exists {
-				if strings.Contains(link, "http") {
+				// The social previews can be given as protocol-relative or relative URLs
+				property, _ := item.Attr("property")
+				name, _ := item.Attr("name")
+
+				if strings.Contains(link, "http") || isSocialPreviewMeta(property) || isSocialPreviewMeta(name) {
 					rawAssets = append(rawAssets, link)
 				}
 			}
@@ -257,8 +245,15 @@ func (c *Crawl) extractAssets(base *url.URL, item *frontier.Item, doc *goquery.D
 		})
 	}
 
+	// Lazy-loading setups often put the real assets in a <noscript> fallback
+	if !utils.StringInSlice("noscript", c.DisabledHTMLTags) {
+		doc.Find("noscript").Each(func(index int, item *goquery.Selection) {
+			rawAssets = append(rawAssets, c.extractNoscriptAssets(item.Text())...)
+		})
+	}
+
 	// Turn strings into url.URL
-	assets = append(assets, utils.StringSliceToURLSlice(rawAssets)...)
+	assets = append(assets, utils.StringSliceToURLSlice(c.skipUnfetchableLinks(rawAssets))...)
 
 	// Ensure that excluded hosts aren't in the assets.
 	assets = c.excludeHosts(assets)
@@ -269,6 +264,133 @@ func (c *Crawl) extractAssets(base *url.URL, item *frontier.Item, doc *goquery.D
 	return utils.DedupeURLs(assets), nil
 }
 
+// getURLsFromScriptVariable extract the URLs from the JSON payload of
+// a script initialising a variable, like: var data = {...}
+func getURLsFromScriptVariable(script string) []string {
+	if strings.HasPrefix(script, "{") {
+		return nil
+	}
+
+	jsonContent := strings.SplitAfterN(script, "=", 2)
+	if len(jsonContent) < 2 {
+		return nil
+	}
+
+	var (
+		openSeagullCount   int
+		closedSeagullCount int
+		payloadEndPosition int
+	)
+
+	// figure out the end of the payload
+	for pos, char := range jsonContent[1] {
+		if char == '{' {
+			openSeagullCount++
+		} else if char == '}' {
+			closedSeagullCount++
+		} else {
+			continue
+		}
+
+		if openSeagullCount > 0 {
+			if openSeagullCount == closedSeagullCount {
+				payloadEndPosition = pos
+				break
+			}
+		}
+	}
+
+	if len(jsonContent[1]) > payloadEndPosition {
+		URLsFromJSON, _ := getURLsFromJSON(jsonContent[1][:payloadEndPosition+1])
+		return removeGoogleVideoURLs(URLsFromJSON)
+	}
+
+	return nil
+}
+
+// dedupeAssets remove the duplicates in the assets of an item, ignoring the fragments
+// as they aren't sent to the server, and the assets equal to the item or any of its ancestors
+func dedupeAssets(item *frontier.Item, assets []*url.URL) (output []*url.URL) {
+	seen := make(map[string]bool)
+
+	for ancestor := item; ancestor != nil; ancestor = ancestor.ParentItem {
+		seen[urlWithoutFragment(ancestor.URL)] = true
+	}
+
+	for _, asset := range assets {
+		key := urlWithoutFragment(asset)
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+		output = append(output, asset)
+	}
+
+	return output
+}
+
+func urlWithoutFragment(URL *url.URL) string {
+	withoutFragment := *URL
+	withoutFragment.Fragment = ""
+	withoutFragment.RawFragment = ""
+
+	return utils.URLToString(&withoutFragment)
+}
+
+// handleOverflowAssets log the assets exceeding --max-assets-per-page and,
+// if --queue-overflow-assets is set, queue them as regular frontier items
+func (c *Crawl) handleOverflowAssets(item *frontier.Item, overflow []*url.URL) {
+	logWarning.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+		"maxAssetsPerPage": c.MaxAssetsPerPage,
+		"overflowAssets":   len(overflow),
+		"queued":           c.QueueOverflowAssets,
+	})).Warn("page exceeds the maximum number of assets")
+
+	if !c.QueueOverflowAssets {
+		return
+	}
+
+	for _, asset := range overflow {
+		c.normalizeURL(asset)
+
+		if !c.isURLWithinLimits(asset) {
+			continue
+		}
+
+		c.queueItem(frontier.NewItem(asset, item, "asset", item.Hop, "", false))
+	}
+}
+
+// interleaveByHost reorder the assets so that consecutive assets are,
+// as much as possible, on different hosts, the order per host is kept
+func interleaveByHost(assets []*url.URL) []*url.URL {
+	var (
+		hosts      []string
+		assetsHost = make(map[string][]*url.URL)
+		output     = make([]*url.URL, 0, len(assets))
+	)
+
+	for _, asset := range assets {
+		if _, exists := assetsHost[asset.Host]; !exists {
+			hosts = append(hosts, asset.Host)
+		}
+
+		assetsHost[asset.Host] = append(assetsHost[asset.Host], asset)
+	}
+
+	for len(output) < len(assets) {
+		for _, host := range hosts {
+			if len(assetsHost[host]) > 0 {
+				output = append(output, assetsHost[host][0])
+				assetsHost[host] = assetsHost[host][1:]
+			}
+		}
+	}
+
+	return output
+}
+
 func removeGoogleVideoURLs(input []string) (output []string) {
 	for _, i := range input {
 		if !strings.Contains(i, "googlevideo.com") {
@@ -278,3 +400,24 @@ func removeGoogleVideoURLs(input []string) (output []string) {
 
 	return output
 }
+
+// socialPreviewMetas are the Open Graph and Twitter Card <meta> tags
+// holding the media displayed in the social previews of a page
+var socialPreviewMetas = []string{
+	"og:image",
+	"og:image:url",
+	"og:image:secure_url",
+	"og:video",
+	"og:video:url",
+	"og:video:secure_url",
+	"og:audio",
+	"og:audio:url",
+	"og:audio:secure_url",
+	"twitter:image",
+	"twitter:image:src",
+	"twitter:player:stream",
+}
+
+func isSocialPreviewMeta(property string) bool {
+	return utils.StringInSlice(strings.ToLower(property), socialPreviewMetas)
+}
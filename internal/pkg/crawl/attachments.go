@@ -0,0 +1,143 @@
+package crawl
+
+import (
+	"encoding/csv"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// AttachmentsReport writes the responses served as attachments (with a
+// Content-Disposition: attachment header) as a CSV file, one line per capture:
+// the URL, the file name suggested by the server, the size, the MIME type and the capture ID
+type AttachmentsReport struct {
+	sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+	count  int64
+	size   int64
+}
+
+// NewAttachmentsReport create (or append to) the attachments.csv file in the job's directory
+func NewAttachmentsReport(jobPath string) (*AttachmentsReport, error) {
+	err := os.MkdirAll(jobPath, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path.Join(jobPath, "attachments.csv"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AttachmentsReport{file: file, writer: csv.NewWriter(file)}
+
+	// Only write the header if the file is new
+	stat, err := file.Stat()
+	if err == nil && stat.Size() == 0 {
+		report.writer.Write([]string{"url", "filename", "size", "mime_type", "capture_id"})
+		report.writer.Flush()
+	}
+
+	return report, nil
+}
+
+// Totals return the number of attachments captured and their total size
+func (report *AttachmentsReport) Totals() (count int64, size int64) {
+	report.Lock()
+	defer report.Unlock()
+
+	return report.count, report.size
+}
+
+// Close flush the pending lines and closes the underlying attachments.csv file
+func (report *AttachmentsReport) Close() error {
+	report.Lock()
+	defer report.Unlock()
+
+	report.writer.Flush()
+
+	return report.file.Close()
+}
+
+func (report *AttachmentsReport) write(attachment *attachmentBody) {
+	report.Lock()
+	defer report.Unlock()
+
+	report.count++
+	report.size += attachment.size
+
+	report.writer.Write([]string{
+		utils.URLToString(attachment.item.URL),
+		attachment.filename,
+		strconv.FormatInt(attachment.size, 10),
+		attachment.mimeType,
+		attachment.item.CaptureID,
+	})
+	report.writer.Flush()
+}
+
+// wrapAttachmentBody replaces the body of a response served as an attachment with a
+// reader that counts the size of the payload, the attachment is logged and added to
+// the report when the body is closed
+func (c *Crawl) wrapAttachmentBody(item *frontier.Item, resp *http.Response) {
+	if c.AttachmentsReport == nil {
+		return
+	}
+
+	disposition, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	if err != nil || disposition != "attachment" {
+		return
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+
+	resp.Body = &attachmentBody{
+		ReadCloser: resp.Body,
+		crawl:      c,
+		item:       item,
+		filename:   params["filename"],
+		mimeType:   mimeType,
+	}
+}
+
+type attachmentBody struct {
+	io.ReadCloser
+	crawl    *Crawl
+	item     *frontier.Item
+	filename string
+	mimeType string
+	size     int64
+	once     sync.Once
+}
+
+func (b *attachmentBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.size += int64(n)
+
+	return n, err
+}
+
+func (b *attachmentBody) Close() error {
+	b.once.Do(func() {
+		b.crawl.AttachmentsReport.write(b)
+
+		if b.crawl.shouldLog(logrus.InfoLevel) {
+			logInfo.WithFields(b.crawl.genLogFields(nil, b.item.URL, map[string]interface{}{
+				"filename": b.filename,
+				"size":     b.size,
+				"mimeType": b.mimeType,
+			})).Info("attachment captured")
+		}
+	})
+
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,77 @@
+package crawl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+var (
+	// errAuthRequired is returned when a server asks for authentication and no credential is configured for it
+	errAuthRequired = errors.New("auth-required: the server requires authentication")
+
+	// errProxyAuthRequired is returned when the proxy asks for authentication
+	errProxyAuthRequired = errors.New("proxy-auth-required: the proxy requires authentication")
+)
+
+// authErrorClass return the error class of an authentication error, or an empty string
+func authErrorClass(err error) string {
+	switch {
+	case errors.Is(err, errAuthRequired):
+		return "auth-required"
+	case errors.Is(err, errProxyAuthRequired):
+		return "proxy-auth-required"
+	default:
+		return ""
+	}
+}
+
+// ParseHTTPCredentials parse the credentials given as host=user:password
+func ParseHTTPCredentials(rawCredentials []string) (map[string]*url.Userinfo, error) {
+	credentials := make(map[string]*url.Userinfo)
+
+	for _, rawCredential := range rawCredentials {
+		host, userPassword, found := strings.Cut(rawCredential, "=")
+		if !found || host == "" {
+			return nil, errors.New("invalid credential, expected host=user:password: " + rawCredential)
+		}
+
+		user, password, _ := strings.Cut(userPassword, ":")
+		credentials[strings.ToLower(host)] = url.UserPassword(user, password)
+	}
+
+	return credentials, nil
+}
+
+// handleAuthChallenge handle the 401 and 407 responses: when a credential is configured
+// for the host and the server accepts Basic authentication, the request is sent again
+// with the credential, else the challenge response is recorded and an auth error returned
+func (c *Crawl) handleAuthChallenge(item *frontier.Item, req *http.Request, resp *http.Response) (*http.Response, error) {
+	// The body is read so the challenge response is written to the WARC
+	discardBody(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode == 407 {
+		return nil, errProxyAuthRequired
+	}
+
+	credential, found := c.HTTPCredentials[strings.ToLower(req.URL.Hostname())]
+	if !found || req.Header.Get("Authorization") != "" {
+		return nil, errAuthRequired
+	}
+
+	if !strings.HasPrefix(strings.ToLower(resp.Header.Get("WWW-Authenticate")), "basic") {
+		return nil, fmt.Errorf("%w, unsupported scheme: %s", errAuthRequired, resp.Header.Get("WWW-Authenticate"))
+	}
+
+	password, _ := credential.Password()
+
+	authReq := req.Clone(req.Context())
+	authReq.SetBasicAuth(credential.Username(), password)
+
+	return c.executeGET(item, authReq, true)
+}
@@ -0,0 +1,105 @@
+package crawl
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Benchmark measures the throughput and the resource usage of the crawl, it is
+// used by zeno benchmark to crawl a synthetic website of a known size
+type Benchmark struct {
+	sync.Mutex
+	ExpectedURIs int
+
+	cpuProfile   *os.File
+	peakHeap     uint64
+	lastProgress time.Time
+}
+
+// startBenchmark start the CPU profiling and the sampling of the memory usage
+func (c *Crawl) startBenchmark() (err error) {
+	c.Benchmark.cpuProfile, err = os.Create(path.Join(c.JobPath, "benchmark-cpu.pprof"))
+	if err != nil {
+		return err
+	}
+
+	err = pprof.StartCPUProfile(c.Benchmark.cpuProfile)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		var (
+			memStats runtime.MemStats
+			crawled  int64
+		)
+
+		for !c.Finished.Get() {
+			runtime.ReadMemStats(&memStats)
+
+			c.Benchmark.Lock()
+			if memStats.HeapAlloc > c.Benchmark.peakHeap {
+				c.Benchmark.peakHeap = memStats.HeapAlloc
+			}
+
+			// The crawl is only finished a few seconds after the last capture,
+			// the throughput is measured up to the last capture
+			if total := c.CrawledSeeds.Value() + c.CrawledAssets.Value(); total != crawled {
+				crawled = total
+				c.Benchmark.lastProgress = time.Now()
+			}
+			c.Benchmark.Unlock()
+
+			time.Sleep(100 * time.Millisecond)
+		}
+	}()
+
+	return nil
+}
+
+// writeBenchmarkReport stop the profiling, write the heap profile and log the results
+func (c *Crawl) writeBenchmarkReport() {
+	pprof.StopCPUProfile()
+	c.Benchmark.cpuProfile.Close()
+
+	heapProfile, err := os.Create(path.Join(c.JobPath, "benchmark-mem.pprof"))
+	if err == nil {
+		runtime.GC()
+		err = pprof.WriteHeapProfile(heapProfile)
+		heapProfile.Close()
+	}
+
+	if err != nil {
+		c.Logger.Warning("[BENCHMARK] Unable to write the heap profile: " + err.Error())
+	}
+
+	c.Benchmark.Lock()
+	defer c.Benchmark.Unlock()
+
+	var (
+		crawled = c.CrawledSeeds.Value() + c.CrawledAssets.Value()
+		elapsed = c.Benchmark.lastProgress.Sub(c.StartTime)
+		usage   syscall.Rusage
+	)
+
+	c.Logger.Warning("[BENCHMARK] " + strconv.FormatInt(crawled, 10) + " URIs crawled out of " + strconv.Itoa(c.Benchmark.ExpectedURIs) + " in " + elapsed.Round(time.Millisecond).String())
+
+	if elapsed > 0 {
+		c.Logger.Warning("[BENCHMARK] Throughput: " + strconv.FormatFloat(float64(crawled)/elapsed.Seconds(), 'f', 1, 64) + " URI/s with " + strconv.Itoa(c.getWorkersCount()) + " workers")
+	}
+
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &usage) == nil {
+		CPUTime := time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+		c.Logger.Warning("[BENCHMARK] CPU time: " + CPUTime.Round(time.Millisecond).String() + ", peak heap: " + humanize.Bytes(c.Benchmark.peakHeap))
+	}
+
+	c.Logger.Warning("[BENCHMARK] CPU and memory profiles written to " + path.Join(c.JobPath, "benchmark-cpu.pprof") + " and " + path.Join(c.JobPath, "benchmark-mem.pprof"))
+}
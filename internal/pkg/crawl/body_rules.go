@@ -0,0 +1,150 @@
+package crawl
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// BodyRuleActions are the actions of the --body-rule rules, "tag" is given
+// the name of the tag, as tag=<name>
+var BodyRuleActions = []string{"skip-outlinks", "skip-assets", "tag"}
+
+// BodyRule is a --body-rule: when the beginning of the body of a response matches
+// its pattern, the outlinks or the assets of the capture are skipped, or the capture
+// is tagged in the logs and the seeds report, e.g. tag=paywalled:paywall-detected
+type BodyRule struct {
+	Action  string
+	Tag     string
+	Pattern *regexp.Regexp
+}
+
+// ParseBodyRule parse a rule written as <action>:<regexp>
+func ParseBodyRule(rule string) (*BodyRule, error) {
+	action, pattern, found := strings.Cut(rule, ":")
+	if !found || pattern == "" {
+		return nil, errors.New("must be <action>:<regexp>")
+	}
+
+	bodyRule := new(BodyRule)
+
+	if tag, isTag := strings.CutPrefix(action, "tag="); isTag {
+		if tag == "" {
+			return nil, errors.New("the tag action needs a name, as tag=<name>")
+		}
+
+		bodyRule.Action = "tag"
+		bodyRule.Tag = tag
+	} else if action == "skip-outlinks" || action == "skip-assets" {
+		bodyRule.Action = action
+	} else {
+		return nil, errors.New("unknown action " + action + ", must be skip-outlinks, skip-assets or tag=<name>")
+	}
+
+	var err error
+
+	bodyRule.Pattern, err = regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return bodyRule, nil
+}
+
+// bodyRulesBody keeps the first bytes of the body read through it, up to
+// --body-rule-max-size, so that the rules are evaluated on streamed bodies
+type bodyRulesBody struct {
+	io.ReadCloser
+	buffer []byte
+	limit  int
+}
+
+func (b *bodyRulesBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+
+	if remaining := b.limit - len(b.buffer); remaining > 0 && n > 0 {
+		b.buffer = append(b.buffer, p[:min(n, remaining)]...)
+	}
+
+	return n, err
+}
+
+// bodyVerdict is the outcome of the --body-rule rules for the body of a capture
+type bodyVerdict struct {
+	skipOutlinks bool
+	skipAssets   bool
+	tags         []string
+}
+
+// wrapBodyRules replaces the body of the response with a reader keeping its
+// beginning for the --body-rule rules, it returns nil if there is no rule
+func (c *Crawl) wrapBodyRules(resp *http.Response) *bodyRulesBody {
+	if len(c.BodyRules) == 0 {
+		return nil
+	}
+
+	body := &bodyRulesBody{
+		ReadCloser: resp.Body,
+		limit:      c.BodyRuleMaxSize * KB,
+	}
+
+	resp.Body = body
+
+	return body
+}
+
+// hasBodyRule return true if one of the --body-rule rules has the action
+func (c *Crawl) hasBodyRule(action string) bool {
+	for _, rule := range c.BodyRules {
+		if rule.Action == action {
+			return true
+		}
+	}
+
+	return false
+}
+
+// evaluateBodyRules match the rules against the part of the body read so far
+func (c *Crawl) evaluateBodyRules(body *bodyRulesBody) (verdict bodyVerdict) {
+	if body == nil {
+		return verdict
+	}
+
+	for _, rule := range c.BodyRules {
+		if !rule.Pattern.Match(body.buffer) {
+			continue
+		}
+
+		switch rule.Action {
+		case "skip-outlinks":
+			verdict.skipOutlinks = true
+		case "skip-assets":
+			verdict.skipAssets = true
+		case "tag":
+			verdict.tags = append(verdict.tags, rule.Tag)
+		}
+	}
+
+	return verdict
+}
+
+// tagCapture log the tags of the capture once its body has been read,
+// and add them to the outcome of the seed if it's an original seed
+func (c *Crawl) tagCapture(item *frontier.Item, body *bodyRulesBody, seedOutcome *SeedOutcome) {
+	verdict := c.evaluateBodyRules(body)
+	if len(verdict.tags) == 0 {
+		return
+	}
+
+	seedOutcome.addTags(verdict.tags)
+
+	logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+		"tags":         verdict.tags,
+		"skipOutlinks": verdict.skipOutlinks,
+		"skipAssets":   verdict.skipAssets,
+	})).Info("capture tagged by body rules")
+}
@@ -0,0 +1,60 @@
+package crawl
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBodyRule(t *testing.T) {
+	rule, err := ParseBodyRule("tag=paywalled:paywall-(detected|shown)")
+	assert.NoError(t, err)
+	assert.Equal(t, "tag", rule.Action)
+	assert.Equal(t, "paywalled", rule.Tag)
+	assert.True(t, rule.Pattern.MatchString("paywall-shown"))
+
+	// Only the first colon separates the action from the pattern
+	rule, err = ParseBodyRule("skip-outlinks:https?://")
+	assert.NoError(t, err)
+	assert.Equal(t, "https?://", rule.Pattern.String())
+
+	for _, invalid := range []string{"skip-outlinks", "tag=:x", "drop:x", "skip-assets:("} {
+		_, err = ParseBodyRule(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestEvaluateBodyRules(t *testing.T) {
+	c := &Crawl{BodyRuleMaxSize: 1}
+
+	for _, rawRule := range []string{"skip-outlinks:paywall", "tag=paywalled:paywall", "skip-assets:never"} {
+		rule, err := ParseBodyRule(rawRule)
+		assert.NoError(t, err)
+
+		c.BodyRules = append(c.BodyRules, rule)
+	}
+
+	// The marker is within the first KB of the body
+	resp := &http.Response{Body: io.NopCloser(strings.NewReader("<div>paywall</div>" + strings.Repeat("x", 4096)))}
+	body := c.wrapBodyRules(resp)
+
+	_, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Len(t, body.buffer, 1024)
+
+	verdict := c.evaluateBodyRules(body)
+	assert.True(t, verdict.skipOutlinks)
+	assert.False(t, verdict.skipAssets)
+	assert.Equal(t, []string{"paywalled"}, verdict.tags)
+
+	// The marker is past the first KB of the body
+	resp = &http.Response{Body: io.NopCloser(strings.NewReader(strings.Repeat("x", 4096) + "paywall"))}
+	body = c.wrapBodyRules(resp)
+
+	_, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.False(t, c.evaluateBodyRules(body).skipOutlinks)
+}
@@ -0,0 +1,105 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/paulbellamy/ratecounter"
+)
+
+// botChallengePeekSize is the number of bytes of the body looked at to detect a
+// challenge page, the signatures are found in the <head> of the interstitials
+const botChallengePeekSize = 8 * 1024
+
+// botChallengeVendors are the vendors of the bot challenges that are detected
+var botChallengeVendors = []string{"cloudflare", "akamai", "datadome", "perimeterx", "incapsula", "aws-waf"}
+
+// BotChallenges counts the bot challenge pages received, per vendor
+type BotChallenges map[string]*ratecounter.Counter
+
+// NewBotChallenges create the counters of bot challenges for every vendor
+func NewBotChallenges() BotChallenges {
+	botChallenges := make(BotChallenges)
+
+	for _, vendor := range botChallengeVendors {
+		botChallenges[vendor] = new(ratecounter.Counter)
+	}
+
+	return botChallenges
+}
+
+// Values return the number of bot challenges received for every vendor
+func (botChallenges BotChallenges) Values() map[string]int64 {
+	values := make(map[string]int64)
+
+	for vendor, counter := range botChallenges {
+		values[vendor] = counter.Value()
+	}
+
+	return values
+}
+
+// getBotChallengeVendor return the vendor of the bot challenge served instead of the
+// page, by looking at the headers and the beginning of the body, or an empty string
+func getBotChallengeVendor(header http.Header, body []byte) string {
+	server := strings.ToLower(header.Get("Server"))
+
+	switch {
+	case header.Get("Cf-Mitigated") == "challenge",
+		server == "cloudflare" && (bytes.Contains(body, []byte("/cdn-cgi/challenge-platform/")) || bytes.Contains(body, []byte("<title>Just a moment...</title>"))):
+		return "cloudflare"
+	case strings.HasPrefix(server, "akamaighost") && bytes.Contains(body, []byte("errors.edgesuite.net")):
+		return "akamai"
+	case header.Get("X-Datadome") != "" || bytes.Contains(body, []byte("captcha-delivery.com")):
+		return "datadome"
+	case bytes.Contains(body, []byte("px-captcha")) || bytes.Contains(body, []byte("captcha.px-cdn.net")):
+		return "perimeterx"
+	case bytes.Contains(body, []byte("_Incapsula_Resource")):
+		return "incapsula"
+	case header.Get("X-Amzn-Waf-Action") != "":
+		return "aws-waf"
+	}
+
+	return ""
+}
+
+// detectBotChallenge look for a bot challenge in the 401, 403 and 503 responses, the
+// challenges are logged and counted per vendor. The body is peeked at, it is left
+// untouched for the rest of the capture.
+func (c *Crawl) detectBotChallenge(item *frontier.Item, resp *http.Response) {
+	if resp.StatusCode != 401 && resp.StatusCode != 403 && resp.StatusCode != 503 {
+		return
+	}
+
+	reader := bufio.NewReaderSize(resp.Body, botChallengePeekSize)
+
+	// Peek returns what has been read when the body is shorter
+	body, _ := reader.Peek(botChallengePeekSize)
+
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{reader, resp.Body}
+
+	vendor := getBotChallengeVendor(resp.Header, body)
+	if vendor == "" {
+		return
+	}
+
+	if counter, exists := c.BotChallenges[vendor]; exists {
+		counter.Incr(1)
+	}
+
+	if c.Prometheus && c.PrometheusMetrics.BotChallenges != nil {
+		c.PrometheusMetrics.BotChallenges.WithLabelValues(vendor).Inc()
+	}
+
+	logWarning.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+		"statusCode": resp.StatusCode,
+		"challenge":  vendor,
+	})).Warn("bot challenge received instead of the page")
+}
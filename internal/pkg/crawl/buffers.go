@@ -0,0 +1,68 @@
+package crawl
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// maxPooledBufferSize is the maximum capacity of a buffer given back to the pool,
+// bigger buffers are left to the GC to avoid keeping huge buffers around forever
+const maxPooledBufferSize = 4 * MB
+
+// copyBufferSize is the size of the buffers used to copy the response bodies
+const copyBufferSize = 32 * KB
+
+// bodyBufferPool holds the buffers used to read response bodies in memory,
+// reusing them avoid allocating (and growing) a new buffer for every capture
+var bodyBufferPool = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// copyBufferPool holds the buffers the response bodies are copied through
+var copyBufferPool = sync.Pool{
+	New: func() any {
+		buffer := make([]byte, copyBufferSize)
+		return &buffer
+	},
+}
+
+// readBody read the whole body in a pooled buffer, the buffer has to be
+// given back with releaseBody when its content isn't used anymore
+func readBody(body io.Reader) (*bytes.Buffer, error) {
+	buffer := bodyBufferPool.Get().(*bytes.Buffer)
+	buffer.Reset()
+
+	_, err := copyBody(buffer, body)
+	if err != nil {
+		releaseBody(buffer)
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+func releaseBody(buffer *bytes.Buffer) {
+	if buffer.Cap() > maxPooledBufferSize {
+		return
+	}
+
+	bodyBufferPool.Put(buffer)
+}
+
+// discardBody read the body until EOF, which is needed for the WARC writing
+func discardBody(body io.Reader) error {
+	_, err := copyBody(io.Discard, body)
+	return err
+}
+
+// copyBody copy the body through a pooled buffer. The writer and the reader are
+// wrapped so that io.CopyBuffer uses it, instead of their ReadFrom or WriteTo methods.
+func copyBody(dst io.Writer, body io.Reader) (int64, error) {
+	buffer := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buffer)
+
+	return io.CopyBuffer(struct{ io.Writer }{dst}, struct{ io.Reader }{body}, *buffer)
+}
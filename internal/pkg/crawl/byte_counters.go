@@ -0,0 +1,240 @@
+package crawl
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CorentinB/warc"
+	"github.com/dustin/go-humanize"
+	"github.com/paulbellamy/ratecounter"
+)
+
+// mimeClasses are the classes of MIME types the bytes are counted by
+var mimeClasses = []string{"html", "css", "javascript", "json", "image", "video", "audio", "font", "pdf", "other"}
+
+// warcRecordTypes are the types of WARC records the written bytes are counted by
+var warcRecordTypes = []string{"request", "response", "revisit", "resource", "metadata", "other"}
+
+// recordHeaderPeekSize is the maximum size of the HTTP headers read
+// from a response record to find the MIME type of its payload
+const recordHeaderPeekSize = 64 * 1024
+
+// ByteCounters counts the bytes downloaded per MIME class, and the bytes written
+// to the WARC files per record type and MIME class, to forecast the storage needed
+type ByteCounters struct {
+	Downloaded map[string]*ratecounter.Counter
+	Written    map[string]map[string]*ratecounter.Counter
+}
+
+// NewByteCounters create the counters for every record type and MIME class
+func NewByteCounters() *ByteCounters {
+	counters := &ByteCounters{
+		Downloaded: make(map[string]*ratecounter.Counter),
+		Written:    make(map[string]map[string]*ratecounter.Counter),
+	}
+
+	for _, class := range mimeClasses {
+		counters.Downloaded[class] = new(ratecounter.Counter)
+	}
+
+	for _, recordType := range warcRecordTypes {
+		counters.Written[recordType] = make(map[string]*ratecounter.Counter)
+
+		for _, class := range mimeClasses {
+			counters.Written[recordType][class] = new(ratecounter.Counter)
+		}
+	}
+
+	return counters
+}
+
+// DownloadedValues return the number of bytes downloaded for every MIME class
+func (counters *ByteCounters) DownloadedValues() map[string]int64 {
+	values := make(map[string]int64)
+
+	for class, counter := range counters.Downloaded {
+		values[class] = counter.Value()
+	}
+
+	return values
+}
+
+// WrittenValues return the number of bytes written for every record type and MIME class
+func (counters *ByteCounters) WrittenValues() map[string]map[string]int64 {
+	values := make(map[string]map[string]int64)
+
+	for recordType, classes := range counters.Written {
+		values[recordType] = make(map[string]int64)
+
+		for class, counter := range classes {
+			values[recordType][class] = counter.Value()
+		}
+	}
+
+	return values
+}
+
+// getMIMEClass return the class of a Content-Type header value
+func getMIMEClass(contentType string) string {
+	mimeType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "other"
+	}
+
+	switch {
+	case mimeType == "text/html", mimeType == "application/xhtml+xml":
+		return "html"
+	case mimeType == "text/css":
+		return "css"
+	case strings.HasSuffix(mimeType, "javascript"), mimeType == "application/ecmascript":
+		return "javascript"
+	case mimeType == "application/json", strings.HasSuffix(mimeType, "+json"):
+		return "json"
+	case mimeType == "application/pdf":
+		return "pdf"
+	case strings.HasPrefix(mimeType, "font/"), strings.HasPrefix(mimeType, "application/font-"):
+		return "font"
+	}
+
+	switch strings.Split(mimeType, "/")[0] {
+	case "image", "video", "audio":
+		return strings.Split(mimeType, "/")[0]
+	}
+
+	return "other"
+}
+
+// wrapByteCountersBody replaces the body of the response with a reader
+// that counts the bytes downloaded for the MIME class of the response
+func (c *Crawl) wrapByteCountersBody(resp *http.Response) {
+	if c.ByteCounters == nil {
+		return
+	}
+
+	class := getMIMEClass(resp.Header.Get("Content-Type"))
+
+	resp.Body = &downloadCountingBody{
+		ReadCloser: resp.Body,
+		crawl:      c,
+		class:      class,
+	}
+}
+
+type downloadCountingBody struct {
+	io.ReadCloser
+	crawl *Crawl
+	class string
+}
+
+func (b *downloadCountingBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if n > 0 {
+		b.crawl.ByteCounters.Downloaded[b.class].Incr(int64(n))
+
+		if b.crawl.Prometheus && b.crawl.PrometheusMetrics.DownloadedBytes != nil {
+			b.crawl.PrometheusMetrics.DownloadedBytes.WithLabelValues(b.class).Add(float64(n))
+		}
+	}
+
+	return n, err
+}
+
+// countWrittenBytes add the size of the records of a batch to the written bytes, all the
+// records of the batch are counted in the MIME class of the payload of the capture
+func (c *Crawl) countWrittenBytes(batch *warc.RecordBatch) {
+	if c.ByteCounters == nil || len(batch.Records) == 0 {
+		return
+	}
+
+	class := getMIMEClass(batch.Records[0].Header.Get("Content-Type"))
+	for _, record := range batch.Records {
+		if record.Header.Get("WARC-Type") == "response" {
+			class = getMIMEClass(getResponseRecordContentType(record))
+			break
+		}
+	}
+
+	for _, record := range batch.Records {
+		recordType := record.Header.Get("WARC-Type")
+		if _, known := c.ByteCounters.Written[recordType]; !known {
+			recordType = "other"
+		}
+
+		size := getRecordSize(record)
+
+		c.ByteCounters.Written[recordType][class].Incr(size)
+
+		if c.Prometheus && c.PrometheusMetrics.WARCWrittenBytes != nil {
+			c.PrometheusMetrics.WARCWrittenBytes.WithLabelValues(recordType, class).Add(float64(size))
+		}
+	}
+}
+
+// getRecordSize return the size of the content block of a record, the records
+// written by Zeno don't have a Content-Length until they are written
+func getRecordSize(record *warc.Record) int64 {
+	if size, err := strconv.ParseInt(record.Header.Get("Content-Length"), 10, 64); err == nil {
+		return size
+	}
+
+	size, err := record.Content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0
+	}
+
+	record.Content.Seek(0, io.SeekStart)
+
+	return size
+}
+
+// getResponseRecordContentType return the Content-Type of the HTTP response of a response record
+func getResponseRecordContentType(record *warc.Record) string {
+	defer record.Content.Seek(0, io.SeekStart)
+
+	_, err := record.Content.Seek(0, io.SeekStart)
+	if err != nil {
+		return ""
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(io.LimitReader(record.Content, recordHeaderPeekSize)), nil)
+	if err != nil {
+		return ""
+	}
+
+	return resp.Header.Get("Content-Type")
+}
+
+// sumByteCounters return the sum of the counters
+func sumByteCounters(values map[string]int64) (sum int64) {
+	for _, value := range values {
+		sum += value
+	}
+
+	return sum
+}
+
+// byteCountersSummary return a human readable summary of non-zero counters, largest first
+func byteCountersSummary(values map[string]int64) string {
+	var keys []string
+
+	for key, value := range values {
+		if value > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return values[keys[i]] > values[keys[j]]
+	})
+
+	for i, key := range keys {
+		keys[i] = key + ": " + humanize.Bytes(uint64(values[key]))
+	}
+
+	return strings.Join(keys, ", ")
+}
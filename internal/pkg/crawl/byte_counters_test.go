@@ -0,0 +1,29 @@
+package crawl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetMIMEClass(t *testing.T) {
+	classes := map[string]string{
+		"text/html; charset=utf-8": "html",
+		"application/xhtml+xml":    "html",
+		"text/css":                 "css",
+		"application/javascript":   "javascript",
+		"text/javascript":          "javascript",
+		"application/ld+json":      "json",
+		"image/webp":               "image",
+		"video/mp4":                "video",
+		"audio/mpeg":               "audio",
+		"font/woff2":               "font",
+		"application/pdf":          "pdf",
+		"application/octet-stream": "other",
+		"":                         "other",
+	}
+
+	for contentType, class := range classes {
+		assert.Equal(t, class, getMIMEClass(contentType), contentType)
+	}
+}
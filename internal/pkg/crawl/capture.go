@@ -1,6 +1,7 @@
 package crawl
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/clbanning/mxj/v2"
+	"github.com/google/uuid"
 	"github.com/internetarchive/Zeno/internal/pkg/crawl/sitespecific/cloudflarestream"
 	"github.com/internetarchive/Zeno/internal/pkg/crawl/sitespecific/libsyn"
 	"github.com/internetarchive/Zeno/internal/pkg/crawl/sitespecific/telegram"
@@ -21,7 +23,7 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/crawl/sitespecific/vk"
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/remeh/sizedwaitgroup"
-	"github.com/tomnomnom/linkheader"
+	"github.com/sirupsen/logrus"
 
 	"github.com/internetarchive/Zeno/internal/pkg/frontier"
 )
@@ -34,6 +36,11 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 		URL            *url.URL
 	)
 
+	// Don't send requests to the hosts whose circuit is open, they aren't counted as downloaded
+	if c.CircuitBreaker != nil && c.CircuitBreaker.isOpen(item.Host) {
+		return nil, errCircuitOpen
+	}
+
 	defer func() {
 		if c.Prometheus {
 			c.PrometheusMetrics.DownloadedURI.Inc()
@@ -55,32 +62,41 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 
 	// Temporarily pause crawls for individual hosts if they are over our configured maximum concurrent requests per domain.
 	// If the request is a redirection, we do not pause the crawl because we want to follow the redirection.
+	// The hosts of a CDN share the same politeness bucket.
 	if !isRedirection {
-		for c.shouldPause(item.Host) {
-			time.Sleep(time.Millisecond * time.Duration(c.RateLimitDelay))
+		politenessKey := c.getPolitenessKey(item.Host)
+
+		for c.shouldPause(politenessKey) {
+			time.Sleep(time.Millisecond * time.Duration(c.getRateLimitDelay()))
 		}
 
-		c.Frontier.IncrHostActive(item.Host)
+		c.Frontier.IncrHostActive(politenessKey)
+
+		defer c.Frontier.DecrHostActive(politenessKey)
+	}
+
+	// With --capture-timings, the timings of the request are recorded
+	req, timings := c.traceRequest(req)
 
-		defer c.Frontier.DecrHostActive(item.Host)
+	// Assets can have their own retry budget
+	maxRetry := c.MaxRetry
+	if item.Type == "asset" && c.AssetMaxRetry > 0 {
+		maxRetry = c.AssetMaxRetry
 	}
 
 	// Retry on 429 error
-	for retry := 0; retry < c.MaxRetry; retry++ {
+	for retry := 0; retry < maxRetry; retry++ {
+		requestStart := time.Now()
+
 		// Execute GET request
-		if c.ClientProxied == nil || utils.StringContainsSliceElements(req.URL.Host, c.BypassProxy) {
-			resp, err = c.Client.Do(req)
-			if err != nil {
-				if retry+1 >= c.MaxRetry {
-					return resp, err
-				}
-			}
-		} else {
-			resp, err = c.ClientProxied.Do(req)
-			if err != nil {
-				if retry+1 >= c.MaxRetry {
-					return resp, err
-				}
+		resp, err = c.doWithChaos(c.getCaptureClient(item, req.URL.Host).Do, req)
+		c.recordAlertSample(item, resp, err, requestStart)
+		c.HostCompletion.record(item.Host, resp, err)
+		if err != nil {
+			if retry+1 >= maxRetry {
+				c.logCrawlLogError(executionStart, item, err)
+				c.recordHostFailure(item)
+				return resp, err
 			}
 		}
 
@@ -89,10 +105,14 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 
 		if err != nil {
 			if strings.Contains(err.Error(), "unsupported protocol scheme") || strings.Contains(err.Error(), "no such host") {
+				c.logCrawlLogError(executionStart, item, err)
+				c.recordHostFailure(item)
 				return nil, err
 			}
 
-			logError.WithFields(c.genLogFields(err, req.URL, nil)).Error("error while executing GET request, retrying")
+			if c.shouldLog(logrus.ErrorLevel) {
+				logError.WithFields(c.genLogFields(err, req.URL, nil)).Error("error while executing GET request, retrying")
+			}
 
 			time.Sleep(sleepTime)
 
@@ -108,13 +128,13 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 
 			// This ensures we aren't leaving the warc dialer hanging.
 			// Do note, 429s are filtered out by WARC writer regardless.
-			io.Copy(io.Discard, resp.Body)
+			discardBody(resp.Body)
 			resp.Body.Close()
 
 			// If --hq-rate-limiting-send-back is enabled, we send the URL back to HQ
 			if c.UseHQ && c.HQRateLimitingSendBack {
 				return nil, errors.New("URL is being rate limited, sending back to HQ")
-			} else {
+			} else if c.shouldLog(logrus.WarnLevel) {
 				logWarning.WithFields(c.genLogFields(err, req.URL, map[string]interface{}{
 					"sleepTime":  sleepTime.String(),
 					"retryCount": retry,
@@ -125,20 +145,58 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 			continue
 		} else {
 			c.logCrawlSuccess(executionStart, resp.StatusCode, item)
+			c.wrapRangeRecoveryBody(item, req, resp)
+			c.wrapCrawlLogBody(executionStart, item, resp)
+			c.wrapAttachmentBody(item, resp)
+			c.wrapTimingsBody(item, resp, timings)
+			c.wrapMediaBandwidthBody(item, resp)
+			c.wrapByteCountersBody(resp)
+
+			// The server errors count as failures of the host too
+			if resp.StatusCode >= 500 {
+				c.recordHostFailure(item)
+			} else {
+				c.recordHostSuccess(item)
+			}
+
 			break
 		}
 	}
 
+	// Bot challenges (Cloudflare, Akamai...) are classified in the logs and the metrics
+	c.detectBotChallenge(item, resp)
+
+	// Authentication challenges are retried with the configured credential, if any
+	if resp.StatusCode == 401 || resp.StatusCode == 407 {
+		return c.handleAuthChallenge(item, req, resp)
+	}
+
 	// If a redirection is catched, then we execute the redirection
 	if isStatusCodeRedirect(resp.StatusCode) {
-		if resp.Header.Get("location") == utils.URLToString(req.URL) || item.Redirect >= c.MaxRedirect {
+		if resp.Header.Get("location") == utils.URLToString(req.URL) {
+			return resp, nil
+		}
+
+		// The same-host and cross-host redirections have their own budget,
+		// and the chain isn't followed further once it loops
+		if target, err := req.URL.Parse(resp.Header.Get("location")); err == nil && !c.isRedirectionAllowed(item, req.URL, target) {
 			return resp, nil
 		}
+
+		// With --redirect-policy, the redirections leaving the scope of the crawl aren't followed
+		if c.RedirectPolicy != "follow" {
+			target, err := req.URL.Parse(resp.Header.Get("location"))
+			if err == nil && !c.isRedirectionInScope(req.URL, target) {
+				c.handleOutOfScopeRedirection(item, target)
+				return resp, nil
+			}
+		}
+
 		defer resp.Body.Close()
 
 		// Needed for WARC writing
 		// IMPORTANT! This will write redirects to WARC!
-		io.Copy(io.Discard, resp.Body)
+		discardBody(resp.Body)
 
 		URL, err = url.Parse(resp.Header.Get("location"))
 		if err != nil {
@@ -151,6 +209,21 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 			URL = req.URL.ResolveReference(URL)
 		}
 
+		c.recordLinks(item, []*url.URL{URL}, nil, "redirect")
+
+		newItem = frontier.NewItem(URL, item, item.Type, item.Hop, item.ID, false)
+		newItem.Redirect = item.Redirect + 1
+		newItem.CrossHostRedir = item.CrossHostRedir
+		if isCrossHostRedirection(req.URL, URL) {
+			newItem.CrossHostRedir++
+		}
+
+		// The final URL of a redirected seed is recorded as its alias
+		err = c.recordSeedAlias(newItem)
+		if err != nil {
+			return nil, err
+		}
+
 		// Seencheck the URL
 		if c.Seencheck {
 			found := c.seencheckURL(utils.URLToString(URL), "seed")
@@ -168,9 +241,6 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 			}
 		}
 
-		newItem = frontier.NewItem(URL, item, item.Type, item.Hop, item.ID, false)
-		newItem.Redirect = item.Redirect + 1
-
 		// Prepare GET request
 		newReq, err = http.NewRequest("GET", utils.URLToString(URL), nil)
 		if err != nil {
@@ -188,7 +258,12 @@ func (c *Crawl) executeGET(item *frontier.Item, req *http.Request, isRedirection
 }
 
 func (c *Crawl) captureAsset(item *frontier.Item, cookies []*http.Cookie) error {
-	var resp *http.Response
+	var (
+		resp           *http.Response
+		executionStart = time.Now()
+	)
+
+	item.CaptureID = uuid.NewString()
 
 	// Prepare GET request
 	req, err := http.NewRequest("GET", utils.URLToString(item.URL), nil)
@@ -199,21 +274,41 @@ func (c *Crawl) captureAsset(item *frontier.Item, cookies []*http.Cookie) error
 	req.Header.Set("Referer", utils.URLToString(item.ParentItem.URL))
 	req.Header.Set("User-Agent", c.UserAgent)
 
-	// Apply cookies obtained from the original URL captured
-	for i := range cookies {
-		req.AddCookie(cookies[i])
+	// Assets can have their own timeout, it can only be shorter than --http-timeout
+	// as the timeout of the HTTP client still applies
+	if c.AssetHTTPTimeout > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), time.Duration(c.AssetHTTPTimeout)*time.Second)
+		defer cancel()
+
+		req = req.WithContext(ctx)
+	}
+
+	// Apply cookies obtained from the original URL captured, if the cookie policy allows it
+	if c.isCookieAllowed(req.URL) {
+		for i := range cookies {
+			req.AddCookie(cookies[i])
+		}
 	}
 
 	resp, err = c.executeGET(item, req, false)
 	if err != nil && err.Error() == "URL from redirection has already been seen" {
 		return nil
 	} else if err != nil {
+		c.FailedCaptures.Incr(1)
 		return err
 	}
 	defer resp.Body.Close()
 
+	body := &countingBody{ReadCloser: resp.Body}
+	resp.Body = body
+
 	// needed for WARC writing
-	io.Copy(io.Discard, resp.Body)
+	err = c.discardArchiveBody(item, resp)
+	if err != nil {
+		return err
+	}
+
+	c.logAssetSuccess(item, resp, executionStart, body.size)
 
 	return nil
 }
@@ -225,18 +320,46 @@ func (c *Crawl) Capture(item *frontier.Item) {
 		waitGroup sync.WaitGroup
 	)
 
+	// The capture ID ties the logs, the WARC records written by Zeno and the
+	// report lines of the capture (and of its assets) together
+	item.CaptureID = uuid.NewString()
+
+	seedOutcome := c.getSeedOutcome(item)
+
+	// The item is processed once its assets and outlinks have been handled
+	c.HostCompletion.started(item.Host)
+	defer c.HostCompletion.finished(item.Host)
+
 	defer func(i *frontier.Item) {
 		waitGroup.Wait()
 
-		if c.UseHQ && i.ID != "" {
-			c.HQFinishedChannel <- i
+		if seedOutcome != nil {
+			atomic.StoreUint64(&seedOutcome.Assets, atomic.LoadUint64(&i.LocallyCrawled))
 		}
+
+		c.markItemDone(i)
 	}(item)
 
+	// With --seencheck-seed-aliases, the seeds already captured under another form are skipped
+	if seed, isAlias := c.getSeedAlias(item); isAlias {
+		seedOutcome.setAlias(seed)
+
+		logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"seed": seed,
+		})).Info(errSeedAlias.Error())
+		return
+	}
+
+	// Gemini and Gopher URLs have their own fetchers
+	if isSmolnetURL(item.URL) {
+		c.captureSmolnet(item)
+		return
+	}
+
 	// Prepare GET request
 	req, err := http.NewRequest("GET", utils.URLToString(item.URL), nil)
 	if err != nil {
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while preparing GET request")
+		logError.WithFields(c.genLogFields(err, item, nil)).Error("error while preparing GET request")
 		return
 	}
 
@@ -251,10 +374,10 @@ func (c *Crawl) Capture(item *frontier.Item) {
 		// Get the API URL from the URL
 		apiURL, err := truthsocial.GenerateAPIURL(utils.URLToString(item.URL))
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while generating API URL")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while generating API URL")
 		} else {
 			if apiURL == nil {
-				logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while generating API URL")
+				logError.WithFields(c.genLogFields(err, item, nil)).Error("error while generating API URL")
 			} else {
 				// Then we create an item
 				apiItem := frontier.NewItem(apiURL, item, item.Type, item.Hop, item.ID, false)
@@ -266,7 +389,7 @@ func (c *Crawl) Capture(item *frontier.Item) {
 			// Grab few embeds that are needed for the playback
 			embedURLs, err := truthsocial.EmbedURLs()
 			if err != nil {
-				logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while getting embed URLs")
+				logError.WithFields(c.genLogFields(err, item, nil)).Error("error while getting embed URLs")
 			} else {
 				for _, embedURL := range embedURLs {
 					// Create the embed item
@@ -281,10 +404,10 @@ func (c *Crawl) Capture(item *frontier.Item) {
 		// Generate the highwinds URL
 		highwindsURL, err := libsyn.GenerateHighwindsURL(utils.URLToString(item.URL))
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while generating libsyn URL")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while generating libsyn URL")
 		} else {
 			if highwindsURL == nil {
-				logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while generating libsyn URL")
+				logError.WithFields(c.genLogFields(err, item, nil)).Error("error while generating libsyn URL")
 			} else {
 				c.Capture(frontier.NewItem(highwindsURL, item, item.Type, item.Hop, item.ID, false))
 			}
@@ -306,69 +429,112 @@ func (c *Crawl) Capture(item *frontier.Item) {
 
 	// Execute request
 	resp, err = c.executeGET(item, req, false)
+	if errors.Is(err, errSeedAlias) {
+		seedOutcome.setAlias("")
+
+		logInfo.WithFields(c.genLogFields(err, item, nil)).Info("seed redirects to an already captured seed")
+		return
+	} else if err != nil {
+		seedOutcome.setError(err)
+	}
+
 	if err != nil && err.Error() == "URL from redirection has already been seen" {
 		return
 	} else if err != nil && err.Error() == "URL is being rate limited, sending back to HQ" {
 		c.HQProducerChannel <- frontier.NewItem(item.URL, item.ParentItem, item.Type, item.Hop, "", true)
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("URL is being rate limited, sending back to HQ")
+		logError.WithFields(c.genLogFields(err, item, nil)).Error("URL is being rate limited, sending back to HQ")
+		return
+	} else if errorClass := authErrorClass(err); errorClass != "" {
+		if c.shouldLog(logrus.WarnLevel) {
+			logWarning.WithFields(c.genLogFields(err, item, map[string]interface{}{
+				"errorClass": errorClass,
+			})).Warn("URL requires authentication")
+		}
 		return
 	} else if err != nil {
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while executing GET request")
+		c.FailedCaptures.Incr(1)
+
+		if c.shouldLog(logrus.ErrorLevel) {
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while executing GET request")
+		}
 		return
 	}
 	defer resp.Body.Close()
 
-	// Scrape potential URLs from Link HTTP header
-	var (
-		links      = linkheader.Parse(resp.Header.Get("link"))
-		discovered []string
-	)
+	seedOutcome.setCaptured(resp.StatusCode, utils.URLToString(resp.Request.URL))
 
-	for _, link := range links {
-		discovered = append(discovered, link.URL)
-	}
+	c.HostSecurity.record(resp)
 
-	waitGroup.Add(1)
-	go c.queueOutlinks(utils.MakeAbsolute(item.URL, utils.StringSliceToURLSlice(discovered)), item, &waitGroup)
+	// With --body-rule, the beginning of the body is kept to evaluate the rules on it
+	rulesBody := c.wrapBodyRules(resp)
+	defer c.tagCapture(item, rulesBody, seedOutcome)
 
 	// Store the base URL to turn relative links into absolute links later
 	base, err := url.Parse(utils.URLToString(resp.Request.URL))
 	if err != nil {
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while parsing base URL")
+		logError.WithFields(c.genLogFields(err, item, nil)).Error("error while parsing base URL")
 		return
 	}
 
+	// Scrape potential URLs from the Link, Refresh and Location HTTP headers
+	var discovered []string
+
+	headerOutlinks, headerAssets, headerHints := c.extractHeaderLinks(base, resp)
+
+	seedOutcome.addOutlinks(len(headerOutlinks))
+
+	waitGroup.Add(1)
+	go c.queueOutlinks(headerOutlinks, headerHints, item, &waitGroup)
+
+	// The assets announced by the headers are captured once the body has been read,
+	// with the assets of the page if it's an HTML document, or on their own otherwise
+	if !c.DisableAssetsCapture {
+		defer func() {
+			if len(headerAssets) > 0 {
+				c.captureAssets(item, headerAssets, resp.Cookies())
+			}
+		}()
+	}
+
 	// If the response is a JSON document, we want to scrape it for links
 	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
-		jsonBody, err := io.ReadAll(resp.Body)
+		jsonBody, err := readBody(resp.Body)
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while reading JSON body")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while reading JSON body")
 			return
 		}
 
-		outlinksFromJSON, err := getURLsFromJSON(string(jsonBody))
+		outlinksFromJSON, err := getURLsFromJSON(jsonBody.String())
+		releaseBody(jsonBody)
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while getting URLs from JSON")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while getting URLs from JSON")
+			return
+		}
+
+		if c.evaluateBodyRules(rulesBody).skipOutlinks {
 			return
 		}
 
+		seedOutcome.addOutlinks(len(outlinksFromJSON))
+
 		waitGroup.Add(1)
-		go c.queueOutlinks(utils.MakeAbsolute(item.URL, utils.StringSliceToURLSlice(outlinksFromJSON)), item, &waitGroup)
+		go c.queueOutlinks(utils.MakeAbsolute(item.URL, utils.StringSliceToURLSlice(c.skipUnfetchableLinks(outlinksFromJSON))), nil, item, &waitGroup)
 
 		return
 	}
 
 	// If the response is an XML document, we want to scrape it for links
 	if strings.Contains(resp.Header.Get("Content-Type"), "xml") {
-		xmlBody, err := io.ReadAll(resp.Body)
+		xmlBody, err := readBody(resp.Body)
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while reading XML body")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while reading XML body")
 			return
 		}
 
-		mv, err := mxj.NewMapXml(xmlBody)
+		mv, err := mxj.NewMapXml(xmlBody.Bytes())
+		releaseBody(xmlBody)
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while parsing XML body")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while parsing XML body")
 			return
 		}
 
@@ -385,107 +551,227 @@ func (c *Crawl) Capture(item *frontier.Item) {
 	// We also aren't going to scrape if assets and outlinks are turned off.
 	if !strings.Contains(resp.Header.Get("Content-Type"), "text/") || (c.DisableAssetsCapture && !c.DomainsCrawl && (c.MaxHops <= item.Hop)) {
 		// Enforce reading all data from the response for WARC writing
-		_, err := io.Copy(io.Discard, resp.Body)
+		err := c.discardArchiveBody(item, resp)
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while reading response body")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while reading response body")
 		}
 
 		return
 	}
 
-	// Turn the response into a doc that we will scrape for outlinks and assets.
-	doc, err := goquery.NewDocumentFromReader(resp.Body)
-	if err != nil {
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while creating goquery document")
-		return
+	// Large documents are scraped with the streaming tokenizer instead of building
+	// the whole DOM, the cloudflarestream site-specific code needs the full document.
+	var (
+		useTokenizer           = c.HTMLTokenizerThreshold > 0 && !strings.Contains(base.Host, "cloudflarestream.com")
+		threshold              = int64(c.HTMLTokenizerThreshold * KB)
+		large                  = useTokenizer && resp.ContentLength >= threshold
+		body         io.Reader = resp.Body
+		links        *pageLinks
+	)
+
+	// When the size of the document is unknown, its beginning is read in a pooled buffer to
+	// find out if it's a large document, the rest of it is read while the document is parsed
+	if useTokenizer && resp.ContentLength < 0 {
+		prefix, err := readBody(io.LimitReader(resp.Body, threshold))
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while reading HTML body")
+			return
+		}
+		defer releaseBody(prefix)
+
+		large = int64(prefix.Len()) >= threshold
+		body = io.MultiReader(prefix, resp.Body)
 	}
 
-	// Execute site-specific code on the document
-	if strings.Contains(base.Host, "cloudflarestream.com") {
-		// Look for JS files necessary for the playback of the video
-		cfstreamURLs, err := cloudflarestream.GetJSFiles(doc, base, *c.Client)
+	if large {
+		links = c.tokenizePageLinks(base, item, body, rulesBody)
+	} else {
+		// Turn the response into a doc that we will scrape for outlinks and assets.
+		doc, err := goquery.NewDocumentFromReader(body)
 		if err != nil {
-			logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while getting JS files from cloudflarestream")
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while creating goquery document")
 			return
 		}
 
-		// Seencheck the URLs we captured, we ignore the returned value here
-		// because we already archived the URLs, we just want them to be added
-		// to the seencheck table.
-		if c.Seencheck {
-			for _, cfstreamURL := range cfstreamURLs {
-				c.seencheckURL(cfstreamURL, "asset")
-			}
-		} else if c.UseHQ {
-			_, err := c.HQSeencheckURLs(utils.StringSliceToURLSlice(cfstreamURLs))
+		// Execute site-specific code on the document
+		if strings.Contains(base.Host, "cloudflarestream.com") {
+			// Look for JS files necessary for the playback of the video
+			cfstreamURLs, err := cloudflarestream.GetJSFiles(doc, base, *c.Client)
 			if err != nil {
-				logError.WithFields(c.genLogFields(err, item.URL, map[string]interface{}{
-					"urls": cfstreamURLs,
-				})).Error("error while seenchecking assets via HQ")
+				logError.WithFields(c.genLogFields(err, item, nil)).Error("error while getting JS files from cloudflarestream")
+				return
+			}
+
+			// Seencheck the URLs we captured, we ignore the returned value here
+			// because we already archived the URLs, we just want them to be added
+			// to the seencheck table.
+			if c.Seencheck {
+				for _, cfstreamURL := range cfstreamURLs {
+					c.seencheckURL(cfstreamURL, "asset")
+				}
+			} else if c.UseHQ {
+				_, err := c.HQSeencheckURLs(utils.StringSliceToURLSlice(cfstreamURLs))
+				if err != nil {
+					logError.WithFields(c.genLogFields(err, item, map[string]interface{}{
+						"urls": cfstreamURLs,
+					})).Error("error while seenchecking assets via HQ")
+				}
+			}
+
+			// Log the archived URLs
+			for _, cfstreamURL := range cfstreamURLs {
+				logInfo.WithFields(c.genLogFields(err, cfstreamURL, map[string]interface{}{
+					"parentHop": item.Hop,
+					"parentUrl": utils.URLToString(item.URL),
+					"type":      "asset",
+				})).Info("URL archived")
 			}
 		}
 
-		// Log the archived URLs
-		for _, cfstreamURL := range cfstreamURLs {
-			logInfo.WithFields(c.genLogFields(err, cfstreamURL, map[string]interface{}{
-				"parentHop": item.Hop,
-				"parentUrl": utils.URLToString(item.URL),
-				"type":      "asset",
-			})).Info("URL archived")
+		// Websites can use a <base> tag to specify a base for relative URLs in every other tags.
+		// This checks for the "base" tag and resets the "base" URL variable with the new base URL specified
+		// https://developer.mozilla.org/en-US/docs/Web/HTML/Element/base
+		if !utils.StringInSlice("base", c.DisabledHTMLTags) {
+			oldBase := base
+
+			doc.Find("base").Each(func(index int, goitem *goquery.Selection) {
+				// If a new base got scraped, stop looking for one
+				if oldBase != base {
+					return
+				}
+
+				// Attempt to get a new base value from the base HTML tag
+				link, exists := goitem.Attr("href")
+				if exists {
+					baseTagValue, err := url.Parse(link)
+					if err != nil {
+						logError.WithFields(c.genLogFields(err, item, nil)).Error("error while parsing base tag value")
+					} else {
+						base = baseTagValue
+					}
+				}
+			})
+		}
+
+		links, err = c.extractPageLinks(base, item, doc)
+		if err != nil {
+			return
 		}
 	}
 
-	// Websites can use a <base> tag to specify a base for relative URLs in every other tags.
-	// This checks for the "base" tag and resets the "base" URL variable with the new base URL specified
-	// https://developer.mozilla.org/en-US/docs/Web/HTML/Element/base
-	if !utils.StringInSlice("base", c.DisabledHTMLTags) {
-		oldBase := base
+	// With --body-rule, the outlinks or the assets of the pages matching a rule are skipped
+	verdict := c.evaluateBodyRules(rulesBody)
 
-		doc.Find("base").Each(func(index int, goitem *goquery.Selection) {
-			// If a new base got scraped, stop looking for one
-			if oldBase != base {
-				return
-			}
+	if !verdict.skipOutlinks {
+		// With --pagination-depth, the next and previous pages of a listing are followed at the same hop
+		if c.shouldFollowPagination(item) {
+			links.outlinks = removePaginationLinks(links.outlinks, links.pagination)
 
-			// Attempt to get a new base value from the base HTML tag
-			link, exists := goitem.Attr("href")
-			if exists {
-				baseTagValue, err := url.Parse(link)
-				if err != nil {
-					logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while parsing base tag value")
-				} else {
-					base = baseTagValue
-				}
-			}
-		})
+			seedOutcome.addOutlinks(len(links.pagination))
+
+			waitGroup.Add(1)
+			go c.queuePaginationLinks(links.pagination, item, &waitGroup)
+		}
+
+		seedOutcome.addOutlinks(links.streamed + len(links.outlinks))
+
+		waitGroup.Add(1)
+		go c.queueOutlinks(links.outlinks, links.hints, item, &waitGroup)
+
+		// With --capture-mobile-versions, the AMP and mobile versions are captured alongside the page
+		if len(links.mobileVersions) > 0 {
+			waitGroup.Add(1)
+			go c.queueMobileVersions(links.mobileVersions, item, &waitGroup)
+		}
 	}
 
-	// Extract outlinks
-	outlinks, err := extractOutlinks(base, doc)
-	if err != nil {
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while extracting outlinks")
+	if c.DisableAssetsCapture || verdict.skipAssets {
+		headerAssets = nil
 		return
 	}
 
-	waitGroup.Add(1)
-	go c.queueOutlinks(outlinks, item, &waitGroup)
+	c.captureAssets(item, append(links.assets, headerAssets...), resp.Cookies())
+	headerAssets = nil
+}
 
-	if c.DisableAssetsCapture {
-		return
-	}
+// pageLinks holds the links extracted from a HTML document, either with goquery or
+// with the streaming tokenizer, streamed is the number of outlinks already queued
+// by the tokenizer while the document was parsed
+type pageLinks struct {
+	outlinks       []*url.URL
+	hints          map[string]*frontier.LinkHints
+	pagination     []*url.URL
+	mobileVersions []*url.URL
+	assets         []*url.URL
+	streamed       int
+}
+
+// extractPageLinks extract the links of a HTML document parsed with goquery
+func (c *Crawl) extractPageLinks(base *url.URL, item *frontier.Item, doc *goquery.Document) (links *pageLinks, err error) {
+	links = new(pageLinks)
 
-	// Extract and capture assets
-	assets, err := c.extractAssets(base, item, doc)
+	links.outlinks, err = c.extractOutlinks(base, doc)
 	if err != nil {
-		logError.WithFields(c.genLogFields(err, item.URL, nil)).Error("error while extracting assets")
-		return
+		logError.WithFields(c.genLogFields(err, item, nil)).Error("error while extracting outlinks")
+		return nil, err
+	}
+
+	links.hints = extractLinkHints(base, doc)
+
+	if c.shouldFollowPagination(item) {
+		links.pagination = c.extractPaginationLinks(base, doc)
+	}
+
+	if c.CaptureMobileVersions {
+		links.mobileVersions = c.extractMobileVersions(base, doc)
+	}
+
+	if !c.DisableAssetsCapture {
+		links.assets, err = c.extractAssets(base, item, doc)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while extracting assets")
+			return nil, err
+		}
+	}
+
+	return links, nil
+}
+
+// captureAssets seencheck and capture the assets extracted from an item
+func (c *Crawl) captureAssets(item *frontier.Item, assets []*url.URL, cookies []*http.Cookie) {
+	// Just making sure we do not over archive by archiving the same asset multiple times,
+	// or by archiving the original URL or any of its ancestors as an asset
+	for _, asset := range assets {
+		c.normalizeURL(asset)
 	}
 
+	// With --upgrade-mixed-content, the http:// assets of an https:// page are upgraded
+	originals := c.upgradeMixedContent(item, assets)
+
+	assets = dedupeAssets(item, assets)
+
+	// With --asset-scope, the assets from other origins or domains are skipped
+	assets = c.filterAssetsScope(item, assets)
+
 	// If we didn't find any assets, let's stop here
 	if len(assets) == 0 {
 		return
 	}
 
+	c.recordLinks(item, assets, nil, "asset")
+
+	// In dry run mode, the assets are only recorded in the link graph
+	if c.DryRun {
+		return
+	}
+
+	// Pathological pages can reference tens of thousands of assets,
+	// only the first --max-assets-per-page are captured with the page
+	if c.MaxAssetsPerPage > 0 && len(assets) > c.MaxAssetsPerPage {
+		c.handleOverflowAssets(item, assets[c.MaxAssetsPerPage:])
+		assets = assets[:c.MaxAssetsPerPage]
+	}
+
 	// If --local-seencheck is enabled, then we check if the assets are in the
 	// seencheck DB. If they are, then they are skipped.
 	// Else, if we use HQ, then we use HQ's seencheck.
@@ -527,17 +813,27 @@ func (c *Crawl) Capture(item *frontier.Item) {
 	}
 
 	c.Frontier.QueueCount.Incr(int64(len(assets)))
-	swg := sizedwaitgroup.New(c.MaxConcurrentAssets)
-	excluded := false
+
+	// The assets are fetched concurrently, interleaving them by host makes sure that
+	// a page's assets hosted on a slow host don't hold back the ones hosted elsewhere.
+	// The number of concurrent requests to the same host for a single page is also
+	// bounded, so that one page can't take all the --max-concurrent-per-domain slots.
+	var (
+		swg          = sizedwaitgroup.New(c.MaxConcurrentAssets)
+		hostSlots    = make(map[string]chan struct{})
+		slotsPerHost = c.MaxConcurrentAssets
+		excluded     = false
+	)
+
+	if maxPerDomain := c.getMaxConcurrentRequestsPerDomain(); maxPerDomain > 0 && maxPerDomain < slotsPerHost {
+		slotsPerHost = maxPerDomain
+	}
+
+	assets = interleaveByHost(assets)
 
 	for _, asset := range assets {
 		c.Frontier.QueueCount.Incr(-1)
 
-		// Just making sure we do not over archive by archiving the original URL
-		if utils.URLToString(item.URL) == utils.URLToString(asset) {
-			continue
-		}
-
 		// We ban googlevideo.com URLs because they are heavily rate limited by default, and
 		// we don't want the crawler to spend an innapropriate amount of time archiving them
 		if strings.Contains(item.Host, "googlevideo.com") {
@@ -545,7 +841,7 @@ func (c *Crawl) Capture(item *frontier.Item) {
 		}
 
 		// If the URL match any excluded string, we ignore it
-		for _, excludedString := range c.ExcludedStrings {
+		for _, excludedString := range c.getExcludedStrings() {
 			if strings.Contains(utils.URLToString(asset), excludedString) {
 				excluded = true
 				break
@@ -557,22 +853,47 @@ func (c *Crawl) Capture(item *frontier.Item) {
 			continue
 		}
 
+		if _, exists := hostSlots[asset.Host]; !exists {
+			hostSlots[asset.Host] = make(chan struct{}, slotsPerHost)
+		}
+
 		swg.Add()
 		c.URIsPerSecond.Incr(1)
 
-		go func(asset *url.URL, swg *sizedwaitgroup.SizedWaitGroup) {
+		go func(asset *url.URL, swg *sizedwaitgroup.SizedWaitGroup, slots chan struct{}) {
 			defer swg.Done()
 
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
 			// Create the asset's item
 			newAsset := frontier.NewItem(asset, item, "asset", item.Hop, "", false)
 
 			// Capture the asset
-			err = c.captureAsset(newAsset, resp.Cookies())
+			err := c.captureAsset(newAsset, cookies)
+
+			// An upgraded asset that can't be captured over https:// falls back to its original URL
+			if original, upgraded := originals[utils.URLToString(asset)]; err != nil && upgraded {
+				logInfo.WithFields(c.genLogFields(err, newAsset, map[string]interface{}{
+					"parentUrl": utils.URLToString(item.URL),
+					"original":  utils.URLToString(original),
+				})).Info("unable to capture upgraded mixed content asset, falling back to its original URL")
+
+				newAsset = frontier.NewItem(original, item, "asset", item.Hop, "", false)
+				err = c.captureAsset(newAsset, cookies)
+			}
+
 			if err != nil {
+				if !c.shouldLog(logrus.ErrorLevel) {
+					return
+				}
+
 				logError.WithFields(c.genLogFields(err, &asset, map[string]interface{}{
-					"parentHop": item.Hop,
-					"parentUrl": utils.URLToString(item.URL),
-					"type":      "asset",
+					"parentHop":       item.Hop,
+					"parentUrl":       utils.URLToString(item.URL),
+					"parentCaptureId": item.CaptureID,
+					"captureId":       newAsset.CaptureID,
+					"type":            "asset",
 				})).Error("error while capturing asset")
 				return
 			}
@@ -580,7 +901,7 @@ func (c *Crawl) Capture(item *frontier.Item) {
 			// If we made it to this point, it means that the asset have been crawled successfully,
 			// then we can increment the locallyCrawled variable
 			atomic.AddUint64(&item.LocallyCrawled, 1)
-		}(asset, &swg)
+		}(asset, &swg, hostSlots[asset.Host])
 	}
 
 	swg.Wait()
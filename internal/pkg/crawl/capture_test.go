@@ -0,0 +1,193 @@
+package crawl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/testsite"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/paulbellamy/ratecounter"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestCrawl return a crawl ready to capture items, without starting it: the items
+// queued by the captures are left in the frontier's push channel and the records are
+// written in the warcs directory of the job once the client is closed
+func newTestCrawl(t *testing.T, maxHops uint8) *Crawl {
+	t.Helper()
+
+	jobPath := t.TempDir()
+
+	logInfo, logWarning, logError = utils.SetupLogging(jobPath, false, "", "", false)
+
+	c := &Crawl{
+		JobPath:             jobPath,
+		WARCSpoolDir:        path.Join(jobPath, "warcs"),
+		WARCPrefix:          "ZENO-TEST",
+		WARCPoolSize:        1,
+		WARCQueueSize:       16,
+		WARCTempDir:         path.Join(jobPath, "temp"),
+		HTTPTimeout:         10,
+		MaxHops:             maxHops,
+		MaxRedirect:         10,
+		MaxRetry:            1,
+		MaxConcurrentAssets: 4,
+
+		MaxConcurrentRequestsPerDomain: 4,
+		RateLimitDelay:                 10,
+		Frontier: &frontier.Frontier{
+			PushChan:   make(chan *frontier.Item, 1024),
+			HostPool:   new(sync.Map),
+			QueueCount: new(ratecounter.Counter),
+			Paused:     new(utils.TAtomBool),
+		},
+		SkippedLinks:  NewSkippedLinks(),
+		NofollowLinks: NewNofollowLinks(),
+		BotChallenges: NewBotChallenges(),
+		ByteCounters:  NewByteCounters(),
+		AssetStats:    new(AssetStats),
+
+		CrawledSeeds:           new(ratecounter.Counter),
+		CrawledAssets:          new(ratecounter.Counter),
+		WARCWritingBlockedTime: new(ratecounter.Counter),
+		ActiveWorkers:          new(ratecounter.Counter),
+		RejectedURLs:           new(ratecounter.Counter),
+		ExpiredItems:           new(ratecounter.Counter),
+		CollapsedOutlinks:      new(ratecounter.Counter),
+		CircuitsOpened:         new(ratecounter.Counter),
+		FailedCaptures:         new(ratecounter.Counter),
+		URIsPerSecond:          ratecounter.NewRateCounter(time.Second),
+
+		Paused:        new(utils.TAtomBool),
+		DiskFull:      new(utils.TAtomBool),
+		OutsideWindow: new(utils.TAtomBool),
+		Finished:      new(utils.TAtomBool),
+	}
+
+	var err error
+
+	c.Client, err = c.newWARCWritingHTTPClient(warc.HTTPClientSettings{
+		RotatorSettings: c.initWARCRotatorSettings(),
+		DedupeOptions:   warc.DedupeOptions{LocalDedupe: true},
+		DecompressBody:  true,
+		TempDir:         c.WARCTempDir,
+	})
+	assert.NoError(t, err)
+
+	return c
+}
+
+// crawlTestSite capture the seed and the items it leads to, one at a time, like the
+// workers would with the local seencheck, then return the captured URIs by record type
+func crawlTestSite(t *testing.T, c *Crawl, seed string) map[string][]string {
+	t.Helper()
+
+	URL, err := url.Parse(seed)
+	assert.NoError(t, err)
+
+	var (
+		queue = []*frontier.Item{frontier.NewItem(URL, nil, "seed", 0, "", false)}
+		seen  = map[string]bool{seed: true}
+	)
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		c.Capture(item)
+
+		for len(c.Frontier.PushChan) > 0 {
+			next := <-c.Frontier.PushChan
+
+			if !seen[utils.URLToString(next.URL)] {
+				seen[utils.URLToString(next.URL)] = true
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	assert.NoError(t, c.Client.Close())
+
+	WARCPaths, err := filepath.Glob(path.Join(c.WARCSpoolDir, "*.warc.gz"))
+	assert.NoError(t, err)
+	assert.NotEmpty(t, WARCPaths)
+
+	captured := make(map[string][]string)
+
+	for _, WARCPath := range WARCPaths {
+		assert.NoError(t, readWARCRecords(WARCPath, func(record *warc.Record) {
+			recordType := record.Header.Get("WARC-Type")
+			captured[recordType] = append(captured[recordType], record.Header.Get("WARC-Target-URI"))
+		}))
+	}
+
+	return captured
+}
+
+// countRequests wrap the handler to count the requests received for every path
+func countRequests(handler http.Handler) (http.Handler, func(string) int) {
+	var (
+		mutex    sync.Mutex
+		requests = make(map[string]int)
+	)
+
+	counting := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		requests[r.URL.Path]++
+		mutex.Unlock()
+
+		handler.ServeHTTP(w, r)
+	})
+
+	return counting, func(path string) int {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		return requests[path]
+	}
+}
+
+func TestCaptureTestSite(t *testing.T) {
+	config := testsite.Config{
+		Depth:         1,
+		LinksPerPage:  3,
+		AssetsPerPage: 2,
+		AssetSize:     512,
+		Redirects:     2,
+		Trap:          true,
+	}
+
+	handler, requests := countRequests(testsite.Handler(config))
+
+	site := httptest.NewServer(handler)
+	defer site.Close()
+
+	// The trap is only followed up to --max-hops
+	c := newTestCrawl(t, 3)
+
+	captured := crawlTestSite(t, c, site.URL+"/")
+
+	// Every page is captured once through its redirection chain, with its assets
+	assert.Equal(t, 1, requests("/"))
+	assert.Equal(t, 1, requests("/r/2/p/0"))
+	assert.Equal(t, 1, requests("/r/1/p/0"))
+	assert.Equal(t, 1, requests("/p/0"))
+	assert.Equal(t, 1, requests("/a/p/0/1.png"))
+	assert.Equal(t, 1, requests("/a/p/2/0.png"))
+
+	// The home page links to /calendar/0 (hop 1), which leads to /calendar/2 at hop 3
+	assert.Equal(t, 1, requests("/calendar/2"))
+	assert.Equal(t, 0, requests("/calendar/3"))
+
+	// The pages, their assets, the redirections and the trap pages are all in the WARC files
+	assert.Len(t, captured["response"], config.URIs()+3)
+	assert.Len(t, captured["request"], config.URIs()+3)
+}
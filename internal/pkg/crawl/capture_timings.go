@@ -0,0 +1,150 @@
+package crawl
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// captureTimings holds the timestamps of the phases of a request, set by the
+// hooks of the httptrace.ClientTrace, which are called from the transport
+type captureTimings struct {
+	sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+func (timings *captureTimings) set(timestamp *time.Time) {
+	timings.Lock()
+	defer timings.Unlock()
+
+	*timestamp = time.Now()
+}
+
+// traceRequest attach a trace to the request to record its timings, with --capture-timings
+func (c *Crawl) traceRequest(req *http.Request) (*http.Request, *captureTimings) {
+	if !c.CaptureTimings {
+		return req, nil
+	}
+
+	timings := &captureTimings{start: time.Now()}
+
+	trace := &httptrace.ClientTrace{
+		// Called at the beginning of every attempt, the timings are the ones of the last attempt
+		GetConn: func(hostPort string) {
+			timings.Lock()
+			defer timings.Unlock()
+
+			*timings = captureTimings{start: time.Now()}
+		},
+		DNSStart:          func(httptrace.DNSStartInfo) { timings.set(&timings.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { timings.set(&timings.dnsDone) },
+		ConnectStart:      func(network, addr string) { timings.set(&timings.connectStart) },
+		ConnectDone:       func(network, addr string, err error) { timings.set(&timings.connectDone) },
+		TLSHandshakeStart: func() { timings.set(&timings.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { timings.set(&timings.tlsDone) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			timings.Lock()
+			defer timings.Unlock()
+
+			timings.gotConn = time.Now()
+			timings.reused = info.Reused
+		},
+		GotFirstResponseByte: func() { timings.set(&timings.firstByte) },
+	}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), timings
+}
+
+// fields return the timings as WARC named fields, in milliseconds. The phases
+// that didn't happen (e.g. on a reused connection) are left out.
+func (timings *captureTimings) fields(URL string, end time.Time) string {
+	timings.Lock()
+	defer timings.Unlock()
+
+	var fields strings.Builder
+
+	writeDuration := func(name string, start, end time.Time) {
+		if start.IsZero() || end.IsZero() {
+			return
+		}
+
+		fmt.Fprintf(&fields, "%s: %d\r\n", name, end.Sub(start).Milliseconds())
+	}
+
+	writeDuration("dns-ms", timings.dnsStart, timings.dnsDone)
+	writeDuration("connect-ms", timings.connectStart, timings.connectDone)
+
+	// The WARC dialer does the TLS handshake itself, right after connecting, so
+	// the hooks of the transport aren't called: the handshake is what's left
+	// between the TCP connection and the connection being handed to the request
+	if !timings.tlsStart.IsZero() {
+		writeDuration("tls-ms", timings.tlsStart, timings.tlsDone)
+	} else if strings.HasPrefix(URL, "https://") && !timings.reused {
+		writeDuration("tls-ms", timings.connectDone, timings.gotConn)
+	}
+
+	writeDuration("ttfb-ms", timings.start, timings.firstByte)
+	writeDuration("total-ms", timings.start, end)
+
+	fmt.Fprintf(&fields, "connection-reused: %t\r\n", timings.reused)
+
+	return fields.String()
+}
+
+// wrapTimingsBody write the timings of the capture in a metadata record once the body
+// has been read, so that the total includes the transfer of the body
+func (c *Crawl) wrapTimingsBody(item *frontier.Item, resp *http.Response, timings *captureTimings) {
+	if timings == nil {
+		return
+	}
+
+	resp.Body = &timingsBody{
+		ReadCloser: resp.Body,
+		crawl:      c,
+		item:       item,
+		URL:        utils.URLToString(resp.Request.URL),
+		timings:    timings,
+	}
+}
+
+type timingsBody struct {
+	io.ReadCloser
+	crawl   *Crawl
+	item    *frontier.Item
+	URL     string
+	timings *captureTimings
+	once    sync.Once
+}
+
+func (b *timingsBody) Close() error {
+	b.once.Do(func() {
+		record := warc.NewRecord(b.crawl.WARCTempDir, b.crawl.WARCFullOnDisk)
+		record.Header.Set("WARC-Type", "metadata")
+		record.Header.Set("WARC-Target-URI", b.URL)
+		record.Header.Set("Content-Type", "application/warc-fields")
+
+		record.Content.Write([]byte(b.timings.fields(b.URL, time.Now())))
+
+		b.crawl.writeWARCRecords(b.item, b.URL, time.Now(), record)
+	})
+
+	return b.ReadCloser.Close()
+}
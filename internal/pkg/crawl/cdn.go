@@ -0,0 +1,30 @@
+package crawl
+
+import (
+	"net"
+	"strings"
+)
+
+// cdnPolitenessPrefix is the prefix of the politeness keys of the CDNs, so
+// that they can't collide with a host
+const cdnPolitenessPrefix = "cdn:"
+
+// getPolitenessKey return the key of the politeness bucket of the host: the hosts of a
+// CDN given with --cdn-suffix share the bucket of the CDN, whatever the site they serve,
+// the other hosts have their own bucket
+func (c *Crawl) getPolitenessKey(host string) string {
+	hostname, _, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname = host
+	}
+
+	hostname = strings.ToLower(hostname)
+
+	for _, suffix := range c.CDNSuffixes {
+		if hostname == suffix || strings.HasSuffix(hostname, "."+suffix) {
+			return cdnPolitenessPrefix + suffix
+		}
+	}
+
+	return host
+}
@@ -0,0 +1,99 @@
+package crawl
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChaosFaults are the synthetic failures that can be injected with --chaos-rate
+var ChaosFaults = []string{"timeout", "5xx", "truncate"}
+
+// chaosTimeoutError mimics the timeout of a request, it satisfies net.Error
+type chaosTimeoutError struct{}
+
+func (chaosTimeoutError) Error() string   { return "chaos: injected timeout" }
+func (chaosTimeoutError) Timeout() bool   { return true }
+func (chaosTimeoutError) Temporary() bool { return true }
+
+// pickChaosFault return the fault to inject in the request, or an empty
+// string if the request must be executed normally
+func (c *Crawl) pickChaosFault() string {
+	if c.ChaosRate <= 0 || len(c.ChaosFaults) == 0 || rand.Float64()*100 >= c.ChaosRate {
+		return ""
+	}
+
+	return c.ChaosFaults[rand.Intn(len(c.ChaosFaults))]
+}
+
+// doWithChaos execute the request, injecting a synthetic failure in --chaos-rate percent
+// of them. The timeouts and the 5xx are injected without sending the request, so
+// nothing is written to the WARC files, the truncated bodies are real responses.
+func (c *Crawl) doWithChaos(do func(req *http.Request) (*http.Response, error), req *http.Request) (*http.Response, error) {
+	fault := c.pickChaosFault()
+	if fault == "" {
+		return do(req)
+	}
+
+	if c.shouldLog(logrus.DebugLevel) {
+		logInfo.WithFields(c.genLogFields(nil, req.URL, map[string]interface{}{
+			"fault": fault,
+		})).Debug("chaos: injecting fault")
+	}
+
+	switch fault {
+	case "timeout":
+		return nil, chaosTimeoutError{}
+	case "5xx":
+		return &http.Response{
+			Status:        "503 Service Unavailable",
+			StatusCode:    503,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        make(http.Header),
+			Body:          io.NopCloser(bytes.NewReader(nil)),
+			ContentLength: 0,
+			Request:       req,
+		}, nil
+	default:
+		resp, err := do(req)
+		if err != nil {
+			return resp, err
+		}
+
+		// The body is cut in half, or after 1KB if its size is unknown
+		limit := resp.ContentLength / 2
+		if limit <= 0 {
+			limit = 1024
+		}
+
+		resp.Body = &truncatedBody{ReadCloser: resp.Body, remaining: limit}
+
+		return resp, nil
+	}
+}
+
+// truncatedBody fails with io.ErrUnexpectedEOF once the limit has been read
+type truncatedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *truncatedBody) Read(p []byte) (n int, err error) {
+	if b.remaining <= 0 {
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+
+	n, err = b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+
+	return n, err
+}
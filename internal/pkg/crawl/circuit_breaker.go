@@ -0,0 +1,258 @@
+package crawl
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// errCircuitOpen is returned when a request targets a host whose circuit is open
+var errCircuitOpen = errors.New("circuit open for this host, request not sent")
+
+// CircuitBreaker stops sending requests to the hosts that failed too many times
+// in a row: after --circuit-breaker-threshold consecutive failures the circuit of
+// the host is opened for --circuit-breaker-cooldown. After the cooldown, the next
+// request is let through and a single failure opens the circuit again.
+// With --circuit-breaker-action defer, the items of a host whose circuit is
+// open are held until the circuit closes, then sent back to the queue.
+type CircuitBreaker struct {
+	sync.Mutex
+	Threshold int
+	Cooldown  time.Duration
+	hosts     map[string]*hostCircuit
+
+	held      int64
+	heldHosts map[string]*heldItems
+	heldWg    sync.WaitGroup
+	released  bool
+}
+
+type hostCircuit struct {
+	failures  int
+	openUntil time.Time
+}
+
+// heldItems are the items of a host held until its circuit closes,
+// a single timer is used for all of them
+type heldItems struct {
+	requeues []func()
+	timer    *time.Timer
+}
+
+// NewCircuitBreaker return a circuit breaker opening the circuit of a host
+// after threshold consecutive failures, for the given cooldown
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Threshold: threshold,
+		Cooldown:  cooldown,
+		hosts:     make(map[string]*hostCircuit),
+		heldHosts: make(map[string]*heldItems),
+	}
+}
+
+func (b *CircuitBreaker) isOpen(host string) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	circuit, exists := b.hosts[host]
+	if !exists {
+		return false
+	}
+
+	return time.Now().Before(circuit.openUntil)
+}
+
+// recordFailure return true if the failure opened the circuit of the host
+func (b *CircuitBreaker) recordFailure(host string) bool {
+	b.Lock()
+	defer b.Unlock()
+
+	circuit, exists := b.hosts[host]
+	if !exists {
+		circuit = new(hostCircuit)
+		b.hosts[host] = circuit
+	}
+
+	circuit.failures++
+
+	if circuit.failures >= b.Threshold && !time.Now().Before(circuit.openUntil) {
+		circuit.openUntil = time.Now().Add(b.Cooldown)
+
+		// Once the cooldown is over, a single failure is enough to open it again
+		circuit.failures = b.Threshold - 1
+
+		return true
+	}
+
+	return false
+}
+
+func (b *CircuitBreaker) recordSuccess(host string) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.hosts, host)
+}
+
+// hold call requeue once the circuit of the host closes, or as soon as
+// the held items are released because the crawl is finishing
+func (b *CircuitBreaker) hold(host string, requeue func()) {
+	atomic.AddInt64(&b.held, 1)
+	b.heldWg.Add(1)
+
+	b.Lock()
+	if b.released {
+		b.Unlock()
+		b.requeue([]func(){requeue})
+		return
+	}
+
+	held, exists := b.heldHosts[host]
+	if !exists {
+		var openUntil time.Time
+		if circuit, exists := b.hosts[host]; exists {
+			openUntil = circuit.openUntil
+		}
+
+		held = new(heldItems)
+		held.timer = time.AfterFunc(time.Until(openUntil), func() {
+			b.releaseHost(host)
+		})
+		b.heldHosts[host] = held
+	}
+
+	held.requeues = append(held.requeues, requeue)
+	b.Unlock()
+}
+
+// releaseHost send the items held for the host back to the queue
+func (b *CircuitBreaker) releaseHost(host string) {
+	b.Lock()
+	held, exists := b.heldHosts[host]
+	delete(b.heldHosts, host)
+	b.Unlock()
+
+	if exists {
+		b.requeue(held.requeues)
+	}
+}
+
+func (b *CircuitBreaker) requeue(requeues []func()) {
+	for _, requeue := range requeues {
+		requeue()
+		atomic.AddInt64(&b.held, -1)
+		b.heldWg.Done()
+	}
+}
+
+// Held return the number of items held until the circuit of their host closes
+func (b *CircuitBreaker) Held() int64 {
+	if b == nil {
+		return 0
+	}
+
+	return atomic.LoadInt64(&b.held)
+}
+
+// releaseHeld send the held items back to the queue right away, and
+// wait for them to be queued, it is called when the crawl is finishing
+func (b *CircuitBreaker) releaseHeld() {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	b.released = true
+
+	var requeues []func()
+	for host, held := range b.heldHosts {
+		// When the timer already fired, the items are being requeued by releaseHost
+		if held.timer.Stop() {
+			requeues = append(requeues, held.requeues...)
+			delete(b.heldHosts, host)
+		}
+	}
+	b.Unlock()
+
+	b.requeue(requeues)
+
+	b.heldWg.Wait()
+}
+
+// OpenCircuits return the number of hosts whose circuit is currently open
+func (b *CircuitBreaker) OpenCircuits() (count int) {
+	b.Lock()
+	defer b.Unlock()
+
+	now := time.Now()
+	for _, circuit := range b.hosts {
+		if now.Before(circuit.openUntil) {
+			count++
+		}
+	}
+
+	return count
+}
+
+func (c *Crawl) getOpenCircuits() int {
+	if c.CircuitBreaker == nil {
+		return 0
+	}
+
+	return c.CircuitBreaker.OpenCircuits()
+}
+
+// recordHostFailure count a failed request for the host, and log
+// the event if it opened the circuit of the host
+func (c *Crawl) recordHostFailure(item *frontier.Item) {
+	if c.CircuitBreaker == nil || !c.CircuitBreaker.recordFailure(item.Host) {
+		return
+	}
+
+	c.CircuitsOpened.Incr(1)
+
+	if c.Prometheus && c.PrometheusMetrics.CircuitsOpened != nil {
+		c.PrometheusMetrics.CircuitsOpened.Inc()
+	}
+
+	logWarning.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+		"host":     item.Host,
+		"cooldown": c.CircuitBreaker.Cooldown.String(),
+	})).Warn("too many consecutive failures, circuit opened for host")
+}
+
+func (c *Crawl) recordHostSuccess(item *frontier.Item) {
+	if c.CircuitBreaker == nil {
+		return
+	}
+
+	c.CircuitBreaker.recordSuccess(item.Host)
+}
+
+// handleOpenCircuit drop or defer an item whose host circuit is open, depending on --circuit-breaker-action
+func (c *Crawl) handleOpenCircuit(item *frontier.Item) {
+	if c.CircuitBreakerAction == "defer" {
+		// The item is sent back to the queue once the circuit closes, bypassing the seencheck
+		deferred := frontier.NewItem(item.URL, item.ParentItem, item.Type, item.Hop, "", true)
+		deferred.Scope = item.Scope
+		deferred.Collection = item.Collection
+		deferred.Hints = item.Hints
+
+		c.CircuitBreaker.hold(item.Host, func() {
+			c.queueItem(deferred)
+		})
+
+		// HQ and the queue backends give a new entry to the deferred item
+		c.markItemDone(item)
+
+		return
+	}
+
+	// Mark the item as done for HQ or the queue backend
+	c.markItemDone(item)
+
+	logInfo.WithFields(c.genLogFields(errCircuitOpen, item.URL, nil)).Debug("item dropped")
+}
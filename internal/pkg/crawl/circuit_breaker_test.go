@@ -0,0 +1,53 @@
+package crawl
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreakerHoldUntilClosed(t *testing.T) {
+	breaker := NewCircuitBreaker(2, 50*time.Millisecond)
+
+	assert.False(t, breaker.recordFailure("example.com"))
+	assert.True(t, breaker.recordFailure("example.com"))
+	assert.True(t, breaker.isOpen("example.com"))
+
+	requeued := make(chan time.Time, 1)
+	breaker.hold("example.com", func() {
+		requeued <- time.Now()
+	})
+	assert.Equal(t, int64(1), breaker.Held())
+
+	// The item is only sent back to the queue once the circuit closed
+	at := <-requeued
+	assert.False(t, breaker.isOpen("example.com"))
+	assert.False(t, at.IsZero())
+
+	breaker.releaseHeld()
+	assert.Equal(t, int64(0), breaker.Held())
+}
+
+func TestCircuitBreakerReleaseHeld(t *testing.T) {
+	breaker := NewCircuitBreaker(1, time.Hour)
+	assert.True(t, breaker.recordFailure("example.com"))
+
+	var requeued int64
+	for i := 0; i < 3; i++ {
+		breaker.hold("example.com", func() {
+			atomic.AddInt64(&requeued, 1)
+		})
+	}
+
+	// The items of a host share a single timer
+	breaker.Lock()
+	assert.Len(t, breaker.heldHosts, 1)
+	breaker.Unlock()
+
+	// When the crawl finishes, the held items are queued without waiting for the cooldown
+	breaker.releaseHeld()
+	assert.Equal(t, int64(3), atomic.LoadInt64(&requeued))
+	assert.Equal(t, int64(0), breaker.Held())
+}
@@ -0,0 +1,189 @@
+package crawl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReloadableConfig holds the settings that can be changed while the crawl is running,
+// by editing the file given with --config-file or by sending SIGHUP to the process.
+// The settings missing from the file are left untouched.
+type ReloadableConfig struct {
+	ExcludedHosts                  *[]string `json:"excludedHosts"`
+	IncludedHosts                  *[]string `json:"includedHosts"`
+	ExcludedStrings                *[]string `json:"excludedStrings"`
+	RateLimitDelay                 *int      `json:"rateLimitDelay"`
+	MaxConcurrentRequestsPerDomain *int      `json:"maxConcurrentRequestsPerDomain"`
+	Workers                        *int      `json:"workers"`
+	TargetRate                     *float64  `json:"targetRate"`
+	LogLevel                       *string   `json:"logLevel"`
+}
+
+// getExcludedHosts and the following accessors read the settings of ReloadableConfig,
+// which the workers use while the configuration file can be reloaded. The slices are
+// replaced when reloaded, never modified in place, so they can be used unlocked.
+func (c *Crawl) getExcludedHosts() []string {
+	c.reloadable.RLock()
+	defer c.reloadable.RUnlock()
+
+	return c.ExcludedHosts
+}
+
+func (c *Crawl) getIncludedHosts() []string {
+	c.reloadable.RLock()
+	defer c.reloadable.RUnlock()
+
+	return c.IncludedHosts
+}
+
+func (c *Crawl) getExcludedStrings() []string {
+	c.reloadable.RLock()
+	defer c.reloadable.RUnlock()
+
+	return c.ExcludedStrings
+}
+
+func (c *Crawl) getRateLimitDelay() int {
+	c.reloadable.RLock()
+	defer c.reloadable.RUnlock()
+
+	return c.RateLimitDelay
+}
+
+func (c *Crawl) getMaxConcurrentRequestsPerDomain() int {
+	c.reloadable.RLock()
+	defer c.reloadable.RUnlock()
+
+	return c.MaxConcurrentRequestsPerDomain
+}
+
+// watchConfigFile reload the configuration file when it is modified or
+// when the process receives SIGHUP
+func (c *Crawl) watchConfigFile() {
+	var lastModification time.Time
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		stat, err := os.Stat(c.ConfigFile)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+				"configFile": c.ConfigFile,
+			})).Error("unable to stat configuration file")
+		} else if stat.ModTime().After(lastModification) {
+			lastModification = stat.ModTime()
+			c.reloadConfigFile()
+		}
+
+		select {
+		case <-signals:
+			logInfo.Info("SIGHUP received, reloading configuration file")
+			c.reloadConfigFile()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Crawl) reloadConfigFile() {
+	var config ReloadableConfig
+
+	content, err := os.ReadFile(c.ConfigFile)
+	if err != nil {
+		logError.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+			"configFile": c.ConfigFile,
+		})).Error("unable to read configuration file")
+		return
+	}
+
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		logError.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+			"configFile": c.ConfigFile,
+		})).Error("unable to parse configuration file")
+		return
+	}
+
+	changes := make(map[string]interface{})
+
+	if config.ExcludedHosts != nil && !reflect.DeepEqual(*config.ExcludedHosts, c.ExcludedHosts) {
+		changes["excludedHosts"] = fmt.Sprintf("%v -> %v", c.ExcludedHosts, *config.ExcludedHosts)
+		c.reloadable.Lock()
+		c.ExcludedHosts = *config.ExcludedHosts
+		c.reloadable.Unlock()
+	}
+
+	if config.IncludedHosts != nil && !reflect.DeepEqual(*config.IncludedHosts, c.IncludedHosts) {
+		changes["includedHosts"] = fmt.Sprintf("%v -> %v", c.IncludedHosts, *config.IncludedHosts)
+		c.reloadable.Lock()
+		c.IncludedHosts = *config.IncludedHosts
+		c.reloadable.Unlock()
+	}
+
+	if config.ExcludedStrings != nil && !reflect.DeepEqual(*config.ExcludedStrings, c.ExcludedStrings) {
+		changes["excludedStrings"] = fmt.Sprintf("%v -> %v", c.ExcludedStrings, *config.ExcludedStrings)
+		c.reloadable.Lock()
+		c.ExcludedStrings = *config.ExcludedStrings
+		c.reloadable.Unlock()
+	}
+
+	if config.RateLimitDelay != nil && *config.RateLimitDelay != c.RateLimitDelay {
+		changes["rateLimitDelay"] = fmt.Sprintf("%d -> %d", c.RateLimitDelay, *config.RateLimitDelay)
+		c.reloadable.Lock()
+		c.RateLimitDelay = *config.RateLimitDelay
+		c.reloadable.Unlock()
+	}
+
+	if config.MaxConcurrentRequestsPerDomain != nil && *config.MaxConcurrentRequestsPerDomain != c.MaxConcurrentRequestsPerDomain {
+		changes["maxConcurrentRequestsPerDomain"] = fmt.Sprintf("%d -> %d", c.MaxConcurrentRequestsPerDomain, *config.MaxConcurrentRequestsPerDomain)
+		c.reloadable.Lock()
+		c.MaxConcurrentRequestsPerDomain = *config.MaxConcurrentRequestsPerDomain
+		c.reloadable.Unlock()
+	}
+
+	if config.Workers != nil && *config.Workers != c.getWorkersCount() {
+		previousWorkers := c.getWorkersCount()
+
+		c.setWorkersCount(*config.Workers)
+
+		// The count is bounded by --min-workers and --max-workers
+		changes["workers"] = fmt.Sprintf("%d -> %d", previousWorkers, c.getWorkersCount())
+	}
+
+	if config.TargetRate != nil && *config.TargetRate >= 0 && *config.TargetRate != c.TargetRate.Target() {
+		changes["targetRate"] = fmt.Sprintf("%g -> %g", c.TargetRate.Target(), *config.TargetRate)
+		c.TargetRate.SetTarget(*config.TargetRate)
+	}
+
+	if config.LogLevel != nil {
+		level, err := logrus.ParseLevel(*config.LogLevel)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+				"configFile": c.ConfigFile,
+			})).Error("invalid log level in configuration file")
+		} else if level != logInfo.GetLevel() {
+			changes["logLevel"] = fmt.Sprintf("%s -> %s", logInfo.GetLevel(), level)
+
+			logrus.SetLevel(level)
+			logInfo.SetLevel(level)
+			logWarning.SetLevel(level)
+			logError.SetLevel(level)
+		}
+	}
+
+	if len(changes) == 0 {
+		return
+	}
+
+	logInfo.WithFields(c.genLogFields(nil, nil, changes)).Info("configuration reloaded")
+}
@@ -0,0 +1,80 @@
+package crawl
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+)
+
+// isCookieAllowed return true if cookies can be sent to or accepted from the URL
+// according to --cookie-policy: never with "block", only for the hosts given
+// with --cookie-allowed-host (and their subdomains) with "allowlist"
+func (c *Crawl) isCookieAllowed(URL *url.URL) bool {
+	switch c.CookiePolicy {
+	case "block":
+		return false
+	case "allowlist":
+		host := strings.ToLower(URL.Hostname())
+
+		for _, allowedHost := range c.CookieAllowedHosts {
+			if host == allowedHost || strings.HasSuffix(host, "."+allowedHost) {
+				return true
+			}
+		}
+
+		return false
+	default:
+		return true
+	}
+}
+
+// policyCookieJar apply --cookie-policy to the jar loaded from the cookie file.
+// With the "session" policy, the cookies received during the crawl are kept
+// in memory and never written back to the cookie file.
+type policyCookieJar struct {
+	crawl      *Crawl
+	persistent http.CookieJar
+	session    http.CookieJar
+}
+
+func (c *Crawl) newPolicyCookieJar(persistent http.CookieJar) http.CookieJar {
+	jar := &policyCookieJar{
+		crawl:      c,
+		persistent: persistent,
+	}
+
+	if c.CookiePolicy == "session" {
+		// cookiejar.New never return an error
+		jar.session, _ = cookiejar.New(nil)
+	}
+
+	return jar
+}
+
+func (j *policyCookieJar) SetCookies(URL *url.URL, cookies []*http.Cookie) {
+	if !j.crawl.isCookieAllowed(URL) {
+		return
+	}
+
+	if j.session != nil {
+		j.session.SetCookies(URL, cookies)
+		return
+	}
+
+	j.persistent.SetCookies(URL, cookies)
+}
+
+func (j *policyCookieJar) Cookies(URL *url.URL) (cookies []*http.Cookie) {
+	if !j.crawl.isCookieAllowed(URL) {
+		return nil
+	}
+
+	cookies = j.persistent.Cookies(URL)
+
+	if j.session != nil {
+		cookies = append(cookies, j.session.Cookies(URL)...)
+	}
+
+	return cookies
+}
@@ -1,7 +1,13 @@
 package crawl
 
 import (
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,7 +20,6 @@ import (
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/sirupsen/logrus"
 	"github.com/telanflow/cookiejar"
-	"mvdan.cc/xurls/v2"
 )
 
 var (
@@ -27,6 +32,20 @@ var (
 type PrometheusMetrics struct {
 	Prefix        string
 	DownloadedURI prometheus.Counter
+	QueueAge      prometheus.Summary
+
+	WARCWritingQueueDepth  prometheus.GaugeFunc
+	WARCWritingBlockedTime prometheus.Counter
+
+	CircuitsOpened prometheus.Counter
+	OpenCircuits   prometheus.GaugeFunc
+
+	BotChallenges *prometheus.CounterVec
+
+	ExpiredItems prometheus.Counter
+
+	DownloadedBytes  *prometheus.CounterVec
+	WARCWrittenBytes *prometheus.CounterVec
 }
 
 // Crawl define the parameters of a crawl process
@@ -35,46 +54,126 @@ type Crawl struct {
 	StartTime        time.Time
 	SeedList         []frontier.Item
 	Paused           *utils.TAtomBool
+	DiskFull         *utils.TAtomBool
+	OutsideWindow    *utils.TAtomBool
 	Finished         *utils.TAtomBool
 	LiveStats        bool
 	ElasticSearchURL string
+	SeedsReport      *SeedsReport
+	HeritrixCrawlLog bool
+	SyslogAddress    string
+	Journald         bool
+	LogSampler       *LogSampler
+	CrawlLog         *CrawlLog
+	DryRun           bool
+	ExportLinkGraph  bool
+	LinkGraph        *LinkGraph
+
+	// Responses served as attachments, with --attachments-report
+	ExportAttachments bool
+	AttachmentsReport *AttachmentsReport
 
 	// Frontier
 	Frontier *frontier.Frontier
 
 	// Crawl settings
 	WorkerPool                     sizedwaitgroup.SizedWaitGroup
+	WorkerPoolState                WorkerPoolState
+	MinWorkers                     int
+	MaxWorkers                     int
+	MaxMemory                      int
+	MaxBandwidth                   int
 	MaxConcurrentAssets            int
+	MaxAssetsPerPage               int
+	QueueOverflowAssets            bool
+	AssetScope                     string
+	AssetAllowedHosts              []string
+	UpgradeMixedContent            bool
 	Client                         *warc.CustomHTTPClient
+	Clients                        []*warc.CustomHTTPClient
+	CollectionClients              map[string]*warc.CustomHTTPClient
+	CollectionProxiedClients       map[string]*warc.CustomHTTPClient
 	ClientProxied                  *warc.CustomHTTPClient
 	Logger                         logrus.Logger
 	DisabledHTMLTags               []string
 	ExcludedHosts                  []string
 	IncludedHosts                  []string
 	ExcludedStrings                []string
+	StripTrackingParams            bool
+	TrackingParams                 []string
+	HostSecurity                   *HostSecurity
 	UserAgent                      string
 	Job                            string
+	JobID                          string
 	JobPath                        string
 	MaxHops                        uint8
 	MaxRetry                       int
+	AssetMaxRetry                  int
 	MaxRedirect                    int
+	MaxCrossHostRedirect           int
+	RedirectPolicy                 string
+	RedirectScope                  string
+	MaxURLLength                   int
+	MaxQueryParams                 int
 	HTTPTimeout                    int
+	AssetHTTPTimeout               int
 	MaxConcurrentRequestsPerDomain int
+	MaxConcurrentRequestsPerCDN    int
+	MaxConsecutivePerHost          int
+	CDNSuffixes                    []string
 	RateLimitDelay                 int
 	CrawlTimeLimit                 int
 	MaxCrawlTimeLimit              int
+	CrawlWindow                    *CrawlWindow
 	DisableAssetsCapture           bool
 	CaptureAlternatePages          bool
+	CaptureMobileVersions          bool
+	HTMLTokenizerThreshold         int
+	RangeRecoveryMinSize           int
+	ArchiveListingMaxSize          int
+	CaptureTimings                 bool
 	DomainsCrawl                   bool
+	PaginationDepth                int
 	Headless                       bool
 	Seencheck                      bool
+	SeencheckSeedAliases           bool
+	ResolveShorteners              bool
 	Workers                        int
 	RandomLocalIP                  bool
+	MinSpaceRequired               int
+
+	// Forms under which the seeds have been captured, with --seencheck-seed-aliases
+	seedForms sync.Map
+
+	// Parsed scope expressions of the seeds, shared by their descendants
+	seedScopes sync.Map
 
 	// Cookie-related settings
-	CookieFile  string
-	KeepCookies bool
-	CookieJar   http.CookieJar
+	CookieFile         string
+	KeepCookies        bool
+	CookieJar          http.CookieJar
+	CookiePolicy       string
+	CookieAllowedHosts []string
+
+	// Credentials used to answer the 401 challenges, by host
+	HTTPCredentials map[string]*url.Userinfo
+
+	// IPs forced for some hostnames, from --hosts-file, and the resolver answering them
+	DNSOverrides map[string][]net.IP
+	DNSResolver  *net.Resolver
+
+	// Reloadable settings file, the settings it can change are guarded by reloadable
+	ConfigFile string
+	reloadable sync.RWMutex
+
+	// Remote queue backend, with --queue-backend
+	QueueBackendName string
+	QueueBackend     QueueBackend
+	QueueBackendWg   sync.WaitGroup
+	RedisURL         string
+	RedisStream      string
+	RedisGroup       string
+	RedisClaimIdle   int
 
 	// proxy settings
 	Proxy       string
@@ -91,6 +190,81 @@ type Crawl struct {
 	ActiveWorkers *ratecounter.Counter
 	CrawledSeeds  *ratecounter.Counter
 	CrawledAssets *ratecounter.Counter
+	AssetStats    *AssetStats
+
+	// Links skipped by the extractors because their scheme can't be captured
+	SkippedLinks SkippedLinks
+
+	// Bot challenge pages received instead of the pages, per vendor
+	BotChallenges BotChallenges
+
+	// Alerts raised when the error rate, the 429 rate or the latency is too high
+	Alerts *Alerts
+
+	// Events emitted when all the URLs queued for a host have been processed
+	HostCompletion *HostCompletion
+
+	// Bytes downloaded and written to the WARC files, per record type and MIME class
+	ByteCounters *ByteCounters
+
+	// Outlinks marked rel=nofollow, ugc or sponsored, and what is done with them
+	NofollowLinks  NofollowLinks
+	NofollowPolicy string
+
+	// Rules evaluated on the beginning of the bodies, with --body-rule
+	BodyRules       []*BodyRule
+	BodyRuleMaxSize int
+
+	// URLs not queued because they exceed --max-url-length or --max-query-params
+	RejectedURLs *ratecounter.Counter
+
+	// Items dropped because their deadline passed before they were captured
+	ExpiredItems *ratecounter.Counter
+
+	// Captures that failed, Zeno exits with ExitErrorThreshold when their
+	// share is over --exit-error-threshold at the end of the crawl
+	FailedCaptures     *ratecounter.Counter
+	ExitErrorThreshold float64
+
+	// With --disk-full-abort, the crawl is aborted when the disk stays full for that long
+	DiskFullAbort time.Duration
+
+	// With --target-rate, the crawl is paced to a number of URIs per second
+	TargetRate *RatePacer
+
+	// Last time the main loop ran, in nanoseconds, for the systemd watchdog
+	heartbeat int64
+
+	// Outlinks already queued recently, not queued again
+	RecentOutlinks    *RecentOutlinks
+	CollapsedOutlinks *ratecounter.Counter
+
+	// Hosts skipped after too many consecutive failures
+	CircuitBreaker       *CircuitBreaker
+	CircuitBreakerAction string
+	CircuitsOpened       *ratecounter.Counter
+
+	// Dedicated workers for the URLs matching --media-url-pattern
+	MediaLane *MediaLane
+
+	// Dedicated workers for the urgent seeds
+	PriorityLane *PriorityLane
+
+	// Items deferred once the --host-time-budget of their host is spent
+	HostTimeBudget *HostTimeBudget
+
+	// Memento TimeGate queried before capturing the items, with --timegate
+	TimeGate *TimeGate
+
+	// Set by zeno benchmark
+	Benchmark *Benchmark
+
+	// Synthetic failures injected in a percentage of the requests, to test the configuration
+	ChaosRate   float64
+	ChaosFaults []string
+
+	// Time spent (in nanoseconds) waiting for the WARC writers
+	WARCWritingBlockedTime *ratecounter.Counter
 
 	// WARC settings
 	WARCPrefix         string
@@ -98,13 +272,21 @@ type Crawl struct {
 	WARCWriter         chan *warc.RecordBatch
 	WARCWriterFinish   chan bool
 	WARCTempDir        string
+	WARCSpoolDir       string
+	WARCSpoolWatcher   *WARCSpoolWatcher
 	CDXDedupeServer    string
 	WARCFullOnDisk     bool
 	WARCPoolSize       int
+	WARCWritersRouting string
+	WARCCollections    []string
+	WARCQueueSize      int
 	WARCDedupSize      int
 	DisableLocalDedupe bool
 	CertValidation     bool
 	WARCCustomCookie   string
+	WARCManifest       *WARCManifest
+	WARCIndex          bool
+	UploadState        *UploadState
 
 	// Crawl HQ settings
 	UseHQ                  bool
@@ -126,9 +308,15 @@ type Crawl struct {
 func (c *Crawl) Start() (err error) {
 	c.StartTime = time.Now()
 	c.Paused = new(utils.TAtomBool)
+	c.DiskFull = new(utils.TAtomBool)
+	c.OutsideWindow = new(utils.TAtomBool)
 	c.Finished = new(utils.TAtomBool)
 	c.HQChannelsWg = new(sync.WaitGroup)
-	regexOutlinks = xurls.Relaxed()
+	c.SkippedLinks = NewSkippedLinks()
+	c.NofollowLinks = NewNofollowLinks()
+	c.BotChallenges = NewBotChallenges()
+	c.ByteCounters = NewByteCounters()
+	c.AssetStats = new(AssetStats)
 
 	// Setup the --crawl-time-limit clock
 	if c.CrawlTimeLimit != 0 {
@@ -153,7 +341,7 @@ func (c *Crawl) Start() (err error) {
 			}
 		}()
 
-		logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, c.LiveStats, c.ElasticSearchURL)
+		logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, c.LiveStats, c.ElasticSearchURL, c.SyslogAddress, c.Journald)
 
 		go func() {
 			// Get the current time in UTC and figure out when the next midnight will occur
@@ -173,10 +361,67 @@ func (c *Crawl) Start() (err error) {
 			<-timer.C
 
 			// Call your function
-			logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, c.LiveStats, c.ElasticSearchURL)
+			logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, c.LiveStats, c.ElasticSearchURL, c.SyslogAddress, c.Journald)
 		}()
 	} else {
-		logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, c.LiveStats, c.ElasticSearchURL)
+		logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, c.LiveStats, c.ElasticSearchURL, c.SyslogAddress, c.Journald)
+	}
+
+	// Start the background process that will periodically log
+	// the number of entries suppressed by the log sampling
+	if c.LogSampler != nil {
+		go c.logSamplingAggregates()
+	}
+
+	// The assets are only logged one by one at the debug level
+	go c.logAssetAggregates()
+
+	// With --alert-error-rate, --alert-429-rate or --alert-latency,
+	// the thresholds are evaluated every minute
+	if c.Alerts != nil {
+		go c.watchAlerts()
+	}
+
+	// With --host-completion-events, the hosts are checked for completion periodically
+	if c.HostCompletion != nil {
+		go c.watchHostCompletion()
+	}
+
+	// zeno benchmark profiles the crawl
+	if c.Benchmark != nil {
+		err = c.startBenchmark()
+		if err != nil {
+			logrus.Fatalf("Unable to start the benchmark: %s", err)
+		}
+	}
+
+	// Open the Heritrix-compatible crawl.log if asked
+	if c.HeritrixCrawlLog {
+		c.CrawlLog, err = NewCrawlLog(c.JobPath)
+		if err != nil {
+			logrus.Fatalf("Unable to open crawl.log: %s", err)
+		}
+	}
+
+	// Open the link graph if asked, in dry run mode the
+	// discovered URLs are always written as a link graph
+	if c.ExportLinkGraph || c.DryRun {
+		c.LinkGraph, err = NewLinkGraph(c.JobPath)
+		if err != nil {
+			logrus.Fatalf("Unable to open graph.csv: %s", err)
+		}
+	}
+
+	// Open the attachments report if asked
+	if c.ExportAttachments {
+		c.AttachmentsReport, err = NewAttachmentsReport(c.JobPath)
+		if err != nil {
+			logrus.Fatalf("Unable to open attachments.csv: %s", err)
+		}
+	}
+
+	if c.DryRun {
+		logrus.Warn("Dry run: no WARC will be written, the discovered URLs are written to graph.csv")
 	}
 
 	// Start the background process that will handle os signals
@@ -198,20 +443,56 @@ func (c *Crawl) Start() (err error) {
 		}
 	}()
 
-	c.Frontier.Init(c.JobPath, frontierLoggingChan, c.Workers, c.Seencheck)
-	c.Frontier.Load()
+	// The queue and the frontier state of a resumed job are its checkpoint,
+	// if they are corrupted the job can't be resumed
+	err = c.Frontier.Init(c.JobPath, frontierLoggingChan, c.Workers, c.Seencheck)
+	if err != nil {
+		logrus.Errorf("Unable to open the queue or the seencheck of the job: %s", err)
+		os.Exit(ExitCheckpointCorrupt)
+	}
+
+	err = c.Frontier.Load()
+	if err != nil {
+		logrus.Errorf("Unable to decode the frontier of the job (frontier.gob): %s", err)
+		os.Exit(ExitCheckpointCorrupt)
+	}
+
+	// The items delivered by HQ or the queue backend and dropped by the seencheck are done
+	c.Frontier.SeenFunc = c.markItemDone
+
 	c.Frontier.Start()
 
 	// Start the background process that will periodically check if the disk
 	// have enough free space, and potentially pause the crawl if it doesn't
 	go c.handleCrawlPause()
 
+	// Pause the crawl outside of the --crawl-window
+	if c.CrawlWindow != nil {
+		go c.handleCrawlWindow()
+	}
+
 	// Function responsible for writing to disk the frontier's hosts pool
 	// and other stats needed to resume the crawl. The process happen every minute.
 	// The actual queue used during the crawl and seencheck aren't included in this,
 	// because they are written to disk in real-time.
 	go c.writeFrontierToDisk()
 
+	// Repair the WARC files left open by a crash before the writers
+	// create new ones, and move the finished files out of the spool
+	if !c.DryRun {
+		c.recoverOpenWARCs()
+
+		if path.Clean(c.WARCSpoolDir) != path.Clean(path.Join(c.JobPath, "warcs")) {
+			c.WARCSpoolWatcher = newWARCSpoolWatcher()
+			go c.watchWARCSpool()
+		}
+	}
+
+	// Force the IPs of the hostnames given with --hosts-file
+	if len(c.DNSOverrides) > 0 {
+		c.installDNSOverrides()
+	}
+
 	// Initialize WARC writer
 	logrus.Info("Initializing WARC writer..")
 
@@ -235,38 +516,97 @@ func (c *Crawl) Start() (err error) {
 		RandomLocalIP:       c.RandomLocalIP,
 	}
 
-	c.Client, err = warc.NewWARCWritingHTTPClient(HTTPClientSettings)
-	if err != nil {
-		logrus.Fatalf("Unable to init WARC writing HTTP client: %s", err)
+	if c.WARCWritersRouting == "" {
+		c.Client, err = c.newWARCWritingHTTPClient(HTTPClientSettings)
+		if err != nil {
+			logrus.Fatalf("Unable to init WARC writing HTTP client: %s", err)
+		}
+	} else {
+		// When the records are routed, each WARC writer is owned by its own client,
+		// the client used for a capture is then picked by host or by worker
+		for i := 0; i < c.WARCPoolSize; i++ {
+			routedHTTPClientSettings := HTTPClientSettings
+			routedHTTPClientSettings.RotatorSettings = c.initWARCRotatorSettings()
+			routedHTTPClientSettings.RotatorSettings.Prefix = fmt.Sprintf("%s-%02d", c.WARCPrefix, i)
+			routedHTTPClientSettings.RotatorSettings.WARCWriterPoolSize = 1
+
+			client, err := c.newWARCWritingHTTPClient(routedHTTPClientSettings)
+			if err != nil {
+				logrus.Fatalf("Unable to init WARC writing HTTP client: %s", err)
+			}
+
+			c.Clients = append(c.Clients, client)
+		}
+
+		c.Client = c.Clients[0]
+		logrus.Infof("%d WARC writers initialized, records routed by %s", c.WARCPoolSize, c.WARCWritersRouting)
 	}
 
-	go func() {
-		for err := range c.Client.ErrChan {
-			logError.WithFields(c.genLogFields(err, nil, nil)).Errorf("WARC HTTP client error")
+	// Each collection gets its own WARC writers, so that a crawl shared by
+	// multiple collections produces files that are already separated
+	if len(c.WARCCollections) > 0 {
+		c.CollectionClients = make(map[string]*warc.CustomHTTPClient)
+		c.CollectionProxiedClients = make(map[string]*warc.CustomHTTPClient)
+
+		for _, collection := range c.WARCCollections {
+			collectionHTTPClientSettings := HTTPClientSettings
+			collectionHTTPClientSettings.RotatorSettings = c.initWARCRotatorSettings()
+			collectionHTTPClientSettings.RotatorSettings.Prefix = c.WARCPrefix + "-" + collection
+			collectionHTTPClientSettings.RotatorSettings.WarcinfoContent.Set("collection", collection)
+
+			client, err := c.newWARCWritingHTTPClient(collectionHTTPClientSettings)
+			if err != nil {
+				logrus.Fatalf("Unable to init WARC writing HTTP client: %s", err)
+			}
+
+			c.CollectionClients[collection] = client
+
+			// With --proxy, the collection's captures going through the proxy are written
+			// by their own client, to the WARC files of the collection as well
+			if c.Proxy != "" {
+				collectionHTTPClientSettings.Proxy = c.Proxy
+
+				client, err = c.newWARCWritingHTTPClient(collectionHTTPClientSettings)
+				if err != nil {
+					logrus.Fatalf("Unable to init WARC writing (proxy) HTTP client: %s", err)
+				}
+
+				c.CollectionProxiedClients[collection] = client
+			}
 		}
-	}()
 
-	c.Client.Timeout = time.Duration(c.HTTPTimeout) * time.Second
+		logrus.Infof("WARC writers initialized for collections %s", strings.Join(c.WARCCollections, ", "))
+	}
+
 	logrus.Infof("HTTP client timeout set to %d seconds", c.HTTPTimeout)
 
 	if c.Proxy != "" {
 		proxyHTTPClientSettings := HTTPClientSettings
 		proxyHTTPClientSettings.Proxy = c.Proxy
 
-		c.ClientProxied, err = warc.NewWARCWritingHTTPClient(proxyHTTPClientSettings)
+		c.ClientProxied, err = c.newWARCWritingHTTPClient(proxyHTTPClientSettings)
 		if err != nil {
 			logError.Fatal("unable to init WARC writing (proxy) HTTP client")
 		}
-
-		go func() {
-			for err := range c.ClientProxied.ErrChan {
-				logError.WithFields(c.genLogFields(err, nil, nil)).Error("WARC HTTP client error")
-			}
-		}()
 	}
 
 	logrus.Info("WARC writer initialized")
 
+	// Keep the manifest of the finished WARC files up to date
+	if c.WARCManifest != nil {
+		go c.updateWARCManifest()
+	}
+
+	// Index the WARC files as they are finished
+	if c.WARCIndex {
+		go c.indexWARCs()
+	}
+
+	// Upload the WARC files as they are finished
+	if c.UploadState != nil {
+		go c.uploadWARCs()
+	}
+
 	// Process responsible for slowing or pausing the crawl
 	// when the WARC writing queue gets too big
 	go c.crawlSpeedLimiter()
@@ -276,21 +616,43 @@ func (c *Crawl) Start() (err error) {
 	}
 
 	// Parse input cookie file if specified
-	if c.CookieFile != "" {
-		cookieJar, err := cookiejar.NewFileJar(c.CookieFile, nil)
+	if c.CookieFile != "" && c.CookiePolicy == "block" {
+		logWarning.Warn("--cookies is ignored with --cookie-policy block")
+	} else if c.CookieFile != "" {
+		fileJar, err := cookiejar.NewFileJar(c.CookieFile, nil)
 		if err != nil {
 			logError.WithFields(c.genLogFields(err, nil, nil)).Fatal("unable to parse cookie file")
 		}
 
-		c.Client.Jar = cookieJar
+		cookieJar := c.newPolicyCookieJar(fileJar)
+
+		for _, client := range c.getWARCClients() {
+			client.Jar = cookieJar
+		}
+	}
+
+	// Fire up the desired amount of workers, and the media workers they hand the
+	// items matching --media-url-pattern over to
+	if c.MediaLane != nil {
+		c.startMediaLane()
 	}
 
-	// Fire up the desired amount of workers
-	for i := 0; i < c.Workers; i++ {
-		c.WorkerPool.Add()
-		go c.Worker()
+	// The urgent seeds are captured by their own workers
+	if c.PriorityLane != nil {
+		c.startPriorityLane()
 	}
 
+	c.setWorkersCount(c.Workers)
+
+	// Watch the configuration file to apply the changes of the reloadable settings
+	if c.ConfigFile != "" {
+		go c.watchConfigFile()
+	}
+
+	// Start the process responsible for scaling the worker pool
+	// between --min-workers and --max-workers
+	go c.scaleWorkers()
+
 	// Start the process responsible for printing live stats on the standard output
 	if c.LiveStats {
 		go c.printLiveStats()
@@ -313,16 +675,37 @@ func (c *Crawl) Start() (err error) {
 		go c.HQFinisher()
 		go c.HQWebsocket()
 	} else {
+		// If a queue backend is specified, the items to crawl are also
+		// consumed from it and the discovered items are produced to it
+		if c.QueueBackendName != "" {
+			err = c.initQueueBackend()
+			if err != nil {
+				logrus.Fatalf("Unable to initialize the queue backend: %s", err)
+			}
+
+			c.QueueBackendWg.Add(1)
+			go c.consumeQueueBackend()
+		}
+
 		// Push the seed list to the queue
 		logrus.Info("Pushing seeds in the local queue..")
 		for _, item := range c.SeedList {
 			item := item
+			c.getSeedOutcome(&item)
 			c.Frontier.PushChan <- &item
 		}
 		c.SeedList = nil
 		logrus.Info("All seeds are now in queue, crawling will start")
 	}
 
+	// The dispatch rate of --target-rate is adjusted to the measured rate
+	if c.TargetRate != nil {
+		go c.paceTargetRate()
+	}
+
+	// When running as a systemd service, tell systemd the crawl started
+	c.notifySystemdReady()
+
 	// Start the background process that will catch when there
 	// is nothing more to crawl
 	if !c.UseHQ {
@@ -0,0 +1,228 @@
+package crawl
+
+import (
+	"crypto/sha1"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// CrawlLog writes Heritrix-compatible crawl.log lines, so that the existing
+// QA tooling built around Heritrix can be used on Zeno crawls
+type CrawlLog struct {
+	sync.Mutex
+	file *os.File
+}
+
+// NewCrawlLog create (or append to) the crawl.log file in the logs directory of the job
+func NewCrawlLog(jobPath string) (*CrawlLog, error) {
+	logsDirectory := path.Join(jobPath, "logs")
+
+	err := os.MkdirAll(logsDirectory, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path.Join(logsDirectory, "crawl.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CrawlLog{file: file}, nil
+}
+
+// Close closes the underlying crawl.log file
+func (l *CrawlLog) Close() error {
+	l.Lock()
+	defer l.Unlock()
+
+	return l.file.Close()
+}
+
+// crawlLogLine is a single line of a Heritrix crawl.log
+type crawlLogLine struct {
+	statusCode     int
+	size           int64
+	item           *frontier.Item
+	mimeType       string
+	executionStart time.Time
+	digest         string
+}
+
+func (l *CrawlLog) write(line *crawlLogLine) {
+	var (
+		size     = "-"
+		referer  = "-"
+		mimeType = "no-type"
+		digest   = "-"
+		now      = time.Now().UTC()
+	)
+
+	if line.size >= 0 {
+		size = fmt.Sprintf("%d", line.size)
+	}
+
+	if line.item.ParentItem != nil {
+		referer = utils.URLToString(line.item.ParentItem.URL)
+	}
+
+	if line.mimeType != "" {
+		mimeType = strings.TrimSpace(strings.Split(line.mimeType, ";")[0])
+	}
+
+	if line.digest != "" {
+		digest = "sha1:" + line.digest
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	fmt.Fprintf(l.file, "%s %5d %10s %s %s %s %s #%03d %s+%d %s - - %s\n",
+		now.Format("2006-01-02T15:04:05.000Z"),
+		line.statusCode,
+		size,
+		utils.URLToString(line.item.URL),
+		discoveryPath(line.item),
+		referer,
+		mimeType,
+		workerID(line.item),
+		fetchTimestamp(line.executionStart),
+		now.Sub(line.executionStart).Milliseconds(),
+		digest,
+		annotations(line.item),
+	)
+}
+
+// fetchTimestamp return the start of the fetch in the yyyyMMddHHmmssSSS form of Heritrix,
+// Go only formats the milliseconds after a dot, which is then removed
+func fetchTimestamp(executionStart time.Time) string {
+	return strings.Replace(executionStart.UTC().Format("20060102150405.000"), ".", "", 1)
+}
+
+// annotations return the JSON extra info field of the line, it holds the capture ID
+// that can be used to find the logs and the WARC records written by Zeno for the capture
+func annotations(item *frontier.Item) string {
+	if item.CaptureID == "" {
+		return "{}"
+	}
+
+	return fmt.Sprintf(`{"captureId":%q}`, item.CaptureID)
+}
+
+// discoveryPath return the Heritrix-style hops path of an item:
+// L for each link hop, R for each redirect and E for an embed (asset)
+func discoveryPath(item *frontier.Item) (hopsPath string) {
+	hopsPath = strings.Repeat("L", int(item.Hop)) + strings.Repeat("R", item.Redirect)
+
+	if item.Type == "asset" {
+		hopsPath += "E"
+	}
+
+	if hopsPath == "" {
+		return "-"
+	}
+
+	return hopsPath
+}
+
+// workerID return the ID of the worker that processed the item, or the closest
+// parent of the item that has one, assets and redirections not being processed
+// directly by a worker
+func workerID(item *frontier.Item) int {
+	for i := item; i != nil; i = i.ParentItem {
+		if i.WorkerID != 0 {
+			return i.WorkerID
+		}
+	}
+
+	return 0
+}
+
+// crawlLogStatusCode turns a fetch error into the negative
+// status codes used by Heritrix for failed fetches
+func crawlLogStatusCode(err error) int {
+	var netErr net.Error
+
+	if strings.Contains(err.Error(), "no such host") {
+		return -1
+	}
+
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return -4
+	}
+
+	return -2
+}
+
+func (c *Crawl) logCrawlLogError(executionStart time.Time, item *frontier.Item, err error) {
+	if c.CrawlLog == nil {
+		return
+	}
+
+	c.CrawlLog.write(&crawlLogLine{
+		statusCode:     crawlLogStatusCode(err),
+		size:           -1,
+		item:           item,
+		executionStart: executionStart,
+	})
+}
+
+// wrapCrawlLogBody replaces the response's body with a reader that computes
+// the size and digest of the payload as it is consumed, the crawl.log line
+// is written when the body is closed
+func (c *Crawl) wrapCrawlLogBody(executionStart time.Time, item *frontier.Item, resp *http.Response) {
+	if c.CrawlLog == nil {
+		return
+	}
+
+	resp.Body = &crawlLogBody{
+		ReadCloser: resp.Body,
+		hasher:     sha1.New(),
+		log:        c.CrawlLog,
+		line: &crawlLogLine{
+			statusCode:     resp.StatusCode,
+			item:           item,
+			mimeType:       resp.Header.Get("Content-Type"),
+			executionStart: executionStart,
+		},
+	}
+}
+
+type crawlLogBody struct {
+	io.ReadCloser
+	hasher hash.Hash
+	log    *CrawlLog
+	line   *crawlLogLine
+	once   sync.Once
+}
+
+func (b *crawlLogBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if n > 0 {
+		b.hasher.Write(p[:n])
+		b.line.size += int64(n)
+	}
+
+	return n, err
+}
+
+func (b *crawlLogBody) Close() error {
+	b.once.Do(func() {
+		b.line.digest = base32.StdEncoding.EncodeToString(b.hasher.Sum(nil))
+		b.log.write(b.line)
+	})
+
+	return b.ReadCloser.Close()
+}
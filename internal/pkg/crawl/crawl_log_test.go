@@ -0,0 +1,42 @@
+package crawl
+
+import (
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrawlLogFetchTimestamp(t *testing.T) {
+	jobPath := t.TempDir()
+
+	crawlLog, err := NewCrawlLog(jobPath)
+	assert.NoError(t, err)
+
+	URL, err := url.Parse("https://example.com/")
+	assert.NoError(t, err)
+
+	executionStart := time.Date(2024, 3, 7, 9, 5, 2, 42*int(time.Millisecond), time.UTC)
+
+	crawlLog.write(&crawlLogLine{
+		statusCode:     200,
+		size:           512,
+		item:           frontier.NewItem(URL, nil, "seed", 0, "", false),
+		mimeType:       "text/html",
+		executionStart: executionStart,
+	})
+	assert.NoError(t, crawlLog.Close())
+
+	content, err := os.ReadFile(path.Join(jobPath, "logs", "crawl.log"))
+	assert.NoError(t, err)
+
+	// The fetch timestamp has its milliseconds, followed by the duration of the fetch
+	fields := strings.Fields(string(content))
+	assert.Len(t, fields, 13)
+	assert.True(t, strings.HasPrefix(fields[8], "20240307090502042+"))
+}
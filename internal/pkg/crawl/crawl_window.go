@@ -0,0 +1,98 @@
+package crawl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CrawlWindow is the daily time window during which the crawl is allowed to run,
+// the crawl is paused outside of it. The window can span midnight, e.g. 22:00-06:00.
+type CrawlWindow struct {
+	Start    time.Duration
+	End      time.Duration
+	Location *time.Location
+}
+
+// ParseCrawlWindow parse a window given as HH:MM-HH:MM, in the given IANA time zone
+// or in the local time zone of the machine if it is empty
+func ParseCrawlWindow(expression string, timezone string) (*CrawlWindow, error) {
+	start, end, found := strings.Cut(expression, "-")
+	if !found {
+		return nil, fmt.Errorf("invalid crawl window %q, must be HH:MM-HH:MM", expression)
+	}
+
+	window := &CrawlWindow{Location: time.Local}
+
+	for _, bound := range []struct {
+		value       string
+		destination *time.Duration
+	}{{start, &window.Start}, {end, &window.End}} {
+		clock, err := time.Parse("15:04", strings.TrimSpace(bound.value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl window %q, must be HH:MM-HH:MM", expression)
+		}
+
+		*bound.destination = time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute
+	}
+
+	if window.Start == window.End {
+		return nil, fmt.Errorf("invalid crawl window %q, the start and the end are the same", expression)
+	}
+
+	if timezone != "" {
+		location, err := time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl window time zone %q: %w", timezone, err)
+		}
+
+		window.Location = location
+	}
+
+	return window, nil
+}
+
+// Contains return true if the given time is in the window
+func (window *CrawlWindow) Contains(t time.Time) bool {
+	t = t.In(window.Location)
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second
+
+	if window.Start < window.End {
+		return clock >= window.Start && clock < window.End
+	}
+
+	return clock >= window.Start || clock < window.End
+}
+
+func (window *CrawlWindow) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d %s",
+		int(window.Start.Hours()), int(window.Start.Minutes())%60,
+		int(window.End.Hours()), int(window.End.Minutes())%60,
+		window.Location)
+}
+
+// handleCrawlWindow pause the crawl outside of the --crawl-window, and resume it when
+// the window opens again. The captures in progress when the window closes are finished.
+func (c *Crawl) handleCrawlWindow() {
+	for !c.Finished.Get() {
+		inWindow := c.CrawlWindow.Contains(time.Now())
+
+		if inWindow && c.OutsideWindow.Get() {
+			logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"window": c.CrawlWindow.String(),
+			})).Info("crawl window opened, resuming the crawl")
+
+			c.OutsideWindow.Set(false)
+		} else if !inWindow && !c.OutsideWindow.Get() {
+			logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"window": c.CrawlWindow.String(),
+			})).Info("outside of the crawl window, pausing the crawl")
+
+			c.OutsideWindow.Set(true)
+			c.Paused.Set(true)
+			c.Frontier.Paused.Set(true)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
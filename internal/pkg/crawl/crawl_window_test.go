@@ -0,0 +1,38 @@
+package crawl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrawlWindow(t *testing.T) {
+	window, err := ParseCrawlWindow("22:00-06:00", "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, "22:00-06:00 UTC", window.String())
+
+	assert.True(t, window.Contains(time.Date(2024, 1, 1, 23, 30, 0, 0, time.UTC)))
+	assert.True(t, window.Contains(time.Date(2024, 1, 1, 5, 59, 59, 0, time.UTC)))
+	assert.False(t, window.Contains(time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)))
+	assert.False(t, window.Contains(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)))
+
+	// The time is converted to the time zone of the window
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.NoError(t, err)
+	assert.False(t, window.Contains(time.Date(2024, 1, 1, 22, 30, 0, 0, paris)))
+	assert.True(t, window.Contains(time.Date(2024, 1, 1, 6, 30, 0, 0, paris)))
+
+	window, err = ParseCrawlWindow("09:00-17:30", "")
+	assert.NoError(t, err)
+	assert.True(t, window.Contains(time.Date(2024, 1, 1, 17, 29, 0, 0, time.Local)))
+	assert.False(t, window.Contains(time.Date(2024, 1, 1, 8, 59, 0, 0, time.Local)))
+
+	for _, expression := range []string{"22:00", "25:00-06:00", "06:00-06:00"} {
+		_, err = ParseCrawlWindow(expression, "")
+		assert.Error(t, err, expression)
+	}
+
+	_, err = ParseCrawlWindow("22:00-06:00", "Nowhere/City")
+	assert.Error(t, err)
+}
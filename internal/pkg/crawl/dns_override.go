@@ -0,0 +1,273 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// LoadHostsFile parse a hosts-style file mapping hostnames to IPs: one IP per
+// line followed by the hostnames it is forced for, # starts a comment
+func LoadHostsFile(hostsFilePath string) (map[string][]net.IP, error) {
+	file, err := os.Open(hostsFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var (
+		overrides = make(map[string][]net.IP)
+		scanner   = bufio.NewScanner(file)
+		line      int
+	)
+
+	for scanner.Scan() {
+		line++
+
+		fields := strings.Fields(strings.SplitN(scanner.Text(), "#", 2)[0])
+		if len(fields) == 0 {
+			continue
+		}
+
+		IP := net.ParseIP(fields[0])
+		if IP == nil || len(fields) < 2 {
+			return nil, fmt.Errorf("invalid line %d in hosts file %s", line, hostsFilePath)
+		}
+
+		for _, host := range fields[1:] {
+			host = strings.TrimSuffix(strings.ToLower(host), ".")
+			overrides[host] = append(overrides[host], IP)
+		}
+	}
+
+	return overrides, scanner.Err()
+}
+
+// installDNSOverrides create the resolver answering the queries for the overridden
+// hostnames itself, the other queries are forwarded to the system's DNS servers. It is
+// used by the dialers of the crawl. The dialer of the WARC-writing HTTP clients isn't
+// exposed and uses the default resolver, so the default resolver is replaced too, and
+// the default HTTP transport, used by the other clients (HQ, webhooks, TimeGate...),
+// is given the system's resolver so that they aren't affected by the overrides.
+func (c *Crawl) installDNSOverrides() {
+	var logged sync.Map
+
+	c.DNSResolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return &dnsOverrideConn{
+				address:   address,
+				overrides: c.DNSOverrides,
+				onOverride: func(host string, IPs []net.IP) {
+					// Every new connection is resolved again, only the first resolution is logged,
+					// without sampling since it's logged once per host
+					if _, loaded := logged.LoadOrStore(host, true); !loaded {
+						logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+							"host": host,
+							"IPs":  fmt.Sprint(IPs),
+						})).Info("DNS resolution overridden")
+					}
+				},
+			}, nil
+		},
+	}
+
+	net.DefaultResolver = c.DNSResolver
+
+	if transport, ok := http.DefaultTransport.(*http.Transport); ok {
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Resolver:  &net.Resolver{},
+		}).DialContext
+	}
+}
+
+// dnsOverrideConn is given to the Go resolver as the connection to the DNS server. It
+// isn't a net.PacketConn so the resolver uses the TCP framing (2 bytes of length before
+// every message): the overridden queries are answered locally, the others are
+// forwarded to the DNS server through a real TCP connection.
+type dnsOverrideConn struct {
+	address    string
+	overrides  map[string][]net.IP
+	onOverride func(host string, IPs []net.IP)
+	request    bytes.Buffer
+	response   bytes.Buffer
+	upstream   net.Conn
+	deadline   time.Time
+}
+
+func (conn *dnsOverrideConn) Write(p []byte) (n int, err error) {
+	if conn.upstream != nil {
+		return conn.upstream.Write(p)
+	}
+
+	conn.request.Write(p)
+
+	if conn.request.Len() < 2 || conn.request.Len() < 2+int(binary.BigEndian.Uint16(conn.request.Bytes())) {
+		return len(p), nil
+	}
+
+	answered, err := conn.answer(conn.request.Bytes()[2:])
+	if err != nil {
+		return 0, err
+	}
+
+	if !answered {
+		dialer := net.Dialer{Deadline: conn.deadline}
+
+		conn.upstream, err = dialer.Dial("tcp", conn.address)
+		if err != nil {
+			return 0, err
+		}
+
+		_, err = conn.upstream.Write(conn.request.Bytes())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	conn.request.Reset()
+
+	return len(p), nil
+}
+
+// answer build the response to the query if its hostname is overridden
+func (conn *dnsOverrideConn) answer(query []byte) (answered bool, err error) {
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(query)
+	if err != nil {
+		return false, err
+	}
+
+	question, err := parser.Question()
+	if err != nil {
+		return false, err
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")
+
+	IPs, overridden := conn.overrides[host]
+	if !overridden {
+		return false, nil
+	}
+
+	conn.onOverride(host, IPs)
+
+	builder := dnsmessage.NewBuilder(make([]byte, 2, 514), dnsmessage.Header{
+		ID:                 header.ID,
+		Response:           true,
+		Authoritative:      true,
+		RecursionDesired:   header.RecursionDesired,
+		RecursionAvailable: true,
+	})
+	builder.EnableCompression()
+
+	err = builder.StartQuestions()
+	if err == nil {
+		err = builder.Question(question)
+	}
+
+	if err == nil {
+		err = builder.StartAnswers()
+	}
+
+	resourceHeader := dnsmessage.ResourceHeader{Name: question.Name, Class: dnsmessage.ClassINET, TTL: 60}
+
+	// The IPs of the other family are left out, the answer is empty if there are none
+	for _, IP := range IPs {
+		if err != nil {
+			break
+		}
+
+		if IPv4 := IP.To4(); IPv4 != nil && question.Type == dnsmessage.TypeA {
+			var resource dnsmessage.AResource
+			copy(resource.A[:], IPv4)
+			err = builder.AResource(resourceHeader, resource)
+		} else if IPv4 == nil && question.Type == dnsmessage.TypeAAAA {
+			var resource dnsmessage.AAAAResource
+			copy(resource.AAAA[:], IP.To16())
+			err = builder.AAAAResource(resourceHeader, resource)
+		}
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	response, err := builder.Finish()
+	if err != nil {
+		return false, err
+	}
+
+	binary.BigEndian.PutUint16(response, uint16(len(response)-2))
+	conn.response.Write(response)
+
+	return true, nil
+}
+
+func (conn *dnsOverrideConn) Read(p []byte) (n int, err error) {
+	if conn.upstream != nil {
+		return conn.upstream.Read(p)
+	}
+
+	if conn.response.Len() == 0 {
+		return 0, io.EOF
+	}
+
+	return conn.response.Read(p)
+}
+
+func (conn *dnsOverrideConn) Close() error {
+	if conn.upstream != nil {
+		return conn.upstream.Close()
+	}
+
+	return nil
+}
+
+func (conn *dnsOverrideConn) LocalAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (conn *dnsOverrideConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+func (conn *dnsOverrideConn) SetDeadline(t time.Time) error {
+	conn.deadline = t
+
+	if conn.upstream != nil {
+		return conn.upstream.SetDeadline(t)
+	}
+
+	return nil
+}
+
+func (conn *dnsOverrideConn) SetReadDeadline(t time.Time) error {
+	if conn.upstream != nil {
+		return conn.upstream.SetReadDeadline(t)
+	}
+
+	return nil
+}
+
+func (conn *dnsOverrideConn) SetWriteDeadline(t time.Time) error {
+	if conn.upstream != nil {
+		return conn.upstream.SetWriteDeadline(t)
+	}
+
+	return nil
+}
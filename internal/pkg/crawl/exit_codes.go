@@ -0,0 +1,56 @@
+package crawl
+
+import (
+	"os"
+	"strconv"
+)
+
+// Exit codes of the process, so that the orchestration systems running Zeno can
+// react to the outcome of the crawl. 2 is left out, it is used by Go for panics.
+const (
+	// ExitSuccess is used when the crawl finished normally
+	ExitSuccess = 0
+
+	// ExitFailure is used for the unexpected fatal errors
+	ExitFailure = 1
+
+	// ExitConfigError is used when the flags or the files given to Zeno are invalid
+	ExitConfigError = 3
+
+	// ExitErrorThreshold is used when the crawl finished, but the share of
+	// failed captures is over --exit-error-threshold
+	ExitErrorThreshold = 4
+
+	// ExitDiskFull is used when the crawl is aborted because the disk stayed
+	// full for longer than --disk-full-abort
+	ExitDiskFull = 5
+
+	// ExitCheckpointCorrupt is used when the queue or the frontier
+	// state of the job can't be loaded to resume it
+	ExitCheckpointCorrupt = 6
+)
+
+// getExitCode return the exit code of a crawl that finished
+func (c *Crawl) getExitCode() int {
+	crawled := c.CrawledSeeds.Value() + c.CrawledAssets.Value()
+
+	if c.ExitErrorThreshold > 0 && crawled > 0 && float64(c.FailedCaptures.Value())/float64(crawled) > c.ExitErrorThreshold {
+		c.Logger.Warning("[EXIT] " + strconv.FormatInt(c.FailedCaptures.Value(), 10) + " failed captures out of " +
+			strconv.FormatInt(crawled, 10) + ", over --exit-error-threshold, exiting with code " + strconv.Itoa(ExitErrorThreshold))
+
+		return ExitErrorThreshold
+	}
+
+	return ExitSuccess
+}
+
+// abortOnDiskFull save the state of the job and exit, it is used when the disk stayed full
+// for too long: the crawl can't be finished properly as nothing can be written anymore
+func (c *Crawl) abortOnDiskFull() {
+	logError.WithFields(c.genLogFields(nil, nil, nil)).Error("disk full for longer than --disk-full-abort, aborting the crawl")
+
+	c.Frontier.Save()
+	c.writeSeedsReport()
+
+	os.Exit(ExitDiskFull)
+}
@@ -4,15 +4,18 @@ import (
 	"os"
 	"os/signal"
 	"path"
+	"strconv"
 	"syscall"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/sirupsen/logrus"
 )
 
 // catchFinish is running in the background and detect when the crawl need to be terminated
-// because it won't crawl anything more. This doesn't apply for Kafka-powered crawls.
+// because it won't crawl anything more. This doesn't apply for the crawls fed by crawl HQ or a queue backend.
 func (crawl *Crawl) catchFinish() {
 	for crawl.CrawledSeeds.Value()+crawl.CrawledAssets.Value() <= 0 {
 		time.Sleep(1 * time.Second)
@@ -20,7 +23,7 @@ func (crawl *Crawl) catchFinish() {
 
 	for {
 		time.Sleep(time.Second * 5)
-		if !crawl.UseHQ && crawl.ActiveWorkers.Value() == 0 && crawl.Frontier.QueueCount.Value() == 0 && !crawl.Finished.Get() && (crawl.CrawledSeeds.Value()+crawl.CrawledAssets.Value() > 0) {
+		if !crawl.UseHQ && crawl.QueueBackend == nil && crawl.ActiveWorkers.Value() == 0 && crawl.Frontier.QueueCount.Value() == 0 && crawl.CircuitBreaker.Held() == 0 && !crawl.Finished.Get() && (crawl.CrawledSeeds.Value()+crawl.CrawledAssets.Value() > 0) {
 			crawl.Frontier.LoggingChan <- &frontier.FrontierLogMessage{
 				Fields:  logrus.Fields{},
 				Message: "no more work to do, finishing",
@@ -32,7 +35,10 @@ func (crawl *Crawl) catchFinish() {
 }
 
 func (crawl *Crawl) finish() {
-	crawl.Finished.Set(true)
+	crawl.setFinished()
+
+	// Finishing can take a while, systemd shouldn't consider the service hung meanwhile
+	utils.SdNotify("STOPPING=1")
 
 	// First we wait for the queue reader to finish its current work,
 	// and stop it, when it's stopped it won't dispatch any additional work
@@ -45,9 +51,21 @@ func (crawl *Crawl) finish() {
 	close(crawl.Frontier.PullChan)
 
 	crawl.Logger.Warning("[WORKERS] Waiting for workers to finish")
-	crawl.WorkerPool.Wait()
+	crawl.waitWorkers()
+
+	if crawl.MediaLane != nil {
+		crawl.closeMediaLane()
+	}
+
+	if crawl.PriorityLane != nil {
+		crawl.closePriorityLane()
+	}
 	crawl.Logger.Warning("[WORKERS] All workers finished")
 
+	// The items deferred by the circuit breaker are queued before the queues are closed,
+	// they are kept in the local queue, or produced to HQ or the queue backend
+	crawl.CircuitBreaker.releaseHeld()
+
 	// When all workers are finished, we can safely close the HQ related channels
 	if crawl.UseHQ {
 		crawl.Logger.Warning("[HQ] Waiting for finished channel to be closed")
@@ -63,6 +81,19 @@ func (crawl *Crawl) finish() {
 		crawl.Logger.Warning("[HQ] All functions returned")
 	}
 
+	// The consumer of the queue backend stops when the backend is closed
+	if crawl.QueueBackend != nil {
+		crawl.Logger.Warning("[QUEUE] Closing queue backend")
+
+		err := crawl.QueueBackend.Close()
+		if err != nil {
+			crawl.Logger.Warning("[QUEUE] Unable to close the queue backend: " + err.Error())
+		}
+
+		crawl.QueueBackendWg.Wait()
+		crawl.Logger.Warning("[QUEUE] Queue backend closed")
+	}
+
 	// Once all workers are done, it means nothing more is actively send to
 	// the PushChan channel, we ask for the queue writer to terminate, and when
 	// it's done we close the channel safely.
@@ -73,13 +104,82 @@ func (crawl *Crawl) finish() {
 	}
 
 	crawl.Logger.Warning("[WARC] Closing writer(s)..")
-	crawl.Client.Close()
+	crawl.closeWARCClients()
+
+	crawl.Logger.Warning("[WARC] Writer(s) closed")
+
+	if !crawl.DryRun {
+		crawl.WARCSpoolWatcher.Stop()
 
-	if crawl.Proxy != "" {
-		crawl.ClientProxied.Close()
+		err := crawl.moveFinishedWARCs()
+		if err != nil {
+			crawl.Logger.Warning("[WARC] Unable to move the WARC files out of the spool directory: " + err.Error())
+		}
 	}
 
-	crawl.Logger.Warning("[WARC] Writer(s) closed")
+	if crawl.WARCManifest != nil {
+		err := crawl.WARCManifest.Update(path.Join(crawl.JobPath, "warcs"))
+		if err != nil {
+			crawl.Logger.Warning("[WARC] Unable to update the WARC manifest: " + err.Error())
+		} else {
+			crawl.Logger.Warning("[WARC] Manifest written to " + path.Join(crawl.JobPath, "warcs-manifest.csv"))
+		}
+	}
+
+	if crawl.WARCIndex {
+		err := indexWARCDirectory(path.Join(crawl.JobPath, "warcs"))
+		if err != nil {
+			crawl.Logger.Warning("[WARC] Unable to index the WARC files: " + err.Error())
+		} else {
+			crawl.Logger.Warning("[WARC] CDX files written to " + path.Join(crawl.JobPath, "warcs"))
+		}
+	}
+
+	if crawl.DryRun {
+		err := crawl.removeDryRunWARCs()
+		if err != nil {
+			crawl.Logger.Warning("[WARC] Unable to remove the dry run WARC files: " + err.Error())
+		}
+	}
+
+	if crawl.LinkGraph != nil {
+		crawl.LinkGraph.Close()
+		crawl.Logger.Warning("[GRAPH] graph.csv closed")
+	}
+
+	if crawl.AttachmentsReport != nil {
+		count, size := crawl.AttachmentsReport.Totals()
+		crawl.AttachmentsReport.Close()
+		crawl.Logger.Warning("[REPORT] attachments.csv closed, " + strconv.FormatInt(count, 10) + " attachments captured (" + humanize.Bytes(uint64(size)) + ")")
+	}
+
+	if crawl.HostTimeBudget != nil {
+		hosts, count := crawl.HostTimeBudget.Totals()
+		crawl.HostTimeBudget.Close()
+		crawl.Logger.Warning("[REPORT] deferred.csv closed, " + strconv.FormatInt(count, 10) + " URLs of " + strconv.Itoa(hosts) + " hosts over their time budget deferred")
+	}
+
+	if nofollowLinks := crawl.NofollowLinks.Values(); nofollowLinks["nofollow"]+nofollowLinks["ugc"]+nofollowLinks["sponsored"] > 0 {
+		crawl.Logger.Warning("[REPORT] Links marked nofollow: " + strconv.FormatInt(nofollowLinks["nofollow"], 10) +
+			", ugc: " + strconv.FormatInt(nofollowLinks["ugc"], 10) +
+			", sponsored: " + strconv.FormatInt(nofollowLinks["sponsored"], 10) +
+			" (--nofollow-policy " + crawl.NofollowPolicy + ")")
+	}
+
+	if downloaded := crawl.ByteCounters.DownloadedValues(); sumByteCounters(downloaded) > 0 {
+		crawl.Logger.Warning("[REPORT] Bytes downloaded: " + humanize.Bytes(uint64(sumByteCounters(downloaded))) + " (" + byteCountersSummary(downloaded) + ")")
+	}
+
+	for _, recordType := range warcRecordTypes {
+		if written := crawl.ByteCounters.WrittenValues()[recordType]; sumByteCounters(written) > 0 {
+			crawl.Logger.Warning("[REPORT] Bytes written to WARC in " + recordType + " records: " + humanize.Bytes(uint64(sumByteCounters(written))) + " (" + byteCountersSummary(written) + ")")
+		}
+	}
+
+	if crawl.CrawlLog != nil {
+		crawl.CrawlLog.Close()
+		crawl.Logger.Warning("[LOGS] crawl.log closed")
+	}
 
 	// Closing the local queue used by the frontier
 	crawl.Frontier.Queue.Close()
@@ -95,9 +195,44 @@ func (crawl *Crawl) finish() {
 	crawl.Logger.Warning("[FRONTIER] Dumping hosts pool and frontier stats to " + path.Join(crawl.Frontier.JobPath, "frontier.gob"))
 	crawl.Frontier.Save()
 
+	if crawl.SeedsReport != nil {
+		crawl.Logger.Warning("[REPORT] Writing seeds report to " + path.Join(crawl.JobPath, "seeds.csv"))
+		crawl.writeSeedsReport()
+	}
+
+	if crawl.HostSecurity != nil {
+		crawl.Logger.Warning("[REPORT] Writing hosts security map to " + path.Join(crawl.JobPath, "host-security.csv"))
+
+		err := crawl.HostSecurity.Write(crawl.JobPath)
+		if err != nil {
+			crawl.Logger.Warning("[REPORT] Unable to write the hosts security map: " + err.Error())
+		}
+	}
+
+	if crawl.UploadState != nil {
+		crawl.Logger.Warning("[UPLOAD] Uploading the remaining WARC files and the reports")
+
+		err := crawl.uploadFinishedWARCs()
+		if err == nil {
+			err = crawl.uploadReports()
+		}
+
+		if err != nil {
+			crawl.Logger.Warning("[UPLOAD] Unable to upload the files: " + err.Error())
+		} else {
+			crawl.Logger.Warning("[UPLOAD] All files uploaded")
+		}
+	}
+
+	if crawl.Benchmark != nil {
+		crawl.writeBenchmarkReport()
+	}
+
+	exitCode := crawl.getExitCode()
+
 	crawl.Logger.Warning("Finished!")
 
-	os.Exit(0)
+	os.Exit(exitCode)
 }
 
 func (crawl *Crawl) setupCloseHandler() {
@@ -0,0 +1,122 @@
+package crawl
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/tomnomnom/linkheader"
+)
+
+// headerAssetRels are the relations of the Link header whose targets are
+// needed to render the page, they are captured with it like its assets
+var headerAssetRels = []string{"preload", "prefetch", "modulepreload", "stylesheet", "icon"}
+
+// headerIgnoredRels are the relations of the Link header whose targets are
+// origins to connect to rather than documents, they aren't captured
+var headerIgnoredRels = []string{"preconnect", "dns-prefetch"}
+
+// extractHeaderLinks extract the URLs announced by the headers of the response: the Link
+// header, the Refresh header and the Location header of a 2xx response (e.g. a 201 Created).
+// The targets of the Link header are split between assets and outlinks by their relation,
+// the hints of the outlinks carry their relation and announced type.
+func (c *Crawl) extractHeaderLinks(base *url.URL, resp *http.Response) (outlinks, assets []*url.URL, hints map[string]*frontier.LinkHints) {
+	hints = make(map[string]*frontier.LinkHints)
+
+	resolve := func(rawLink string) *url.URL {
+		if len(c.skipUnfetchableLinks([]string{rawLink})) == 0 {
+			return nil
+		}
+
+		link, err := url.Parse(strings.TrimSpace(rawLink))
+		if err != nil {
+			return nil
+		}
+
+		return base.ResolveReference(link)
+	}
+
+	for _, link := range linkheader.ParseMultiple(resp.Header.Values("Link")) {
+		URL := resolve(link.URL)
+		if URL == nil {
+			continue
+		}
+
+		rels := strings.Fields(strings.ToLower(link.Rel))
+
+		switch {
+		case hasAnyRel(rels, headerIgnoredRels):
+			continue
+		case hasAnyRel(rels, headerAssetRels):
+			assets = append(assets, URL)
+		default:
+			outlinks = append(outlinks, URL)
+
+			// The hints are keyed like the ones of the HTML links, without the fragment
+			withoutFragment := *URL
+			withoutFragment.Fragment = ""
+
+			key := utils.URLToString(&withoutFragment)
+			if _, exists := hints[key]; !exists {
+				hints[key] = &frontier.LinkHints{
+					ContentType: guessContentType(URL, link.Param("type")),
+					Rel:         strings.Join(rels, " "),
+				}
+			}
+		}
+	}
+
+	if rawLink := parseRefreshHeader(resp.Header.Get("Refresh")); rawLink != "" {
+		if URL := resolve(rawLink); URL != nil {
+			outlinks = append(outlinks, URL)
+		}
+	}
+
+	// The Location header of a redirection is followed by the client,
+	// on a successful response it points to the resource that was created
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 && resp.Header.Get("Location") != "" {
+		if URL := resolve(resp.Header.Get("Location")); URL != nil {
+			outlinks = append(outlinks, URL)
+		}
+	}
+
+	return outlinks, assets, hints
+}
+
+// parseRefreshHeader return the URL of a Refresh header (or of the content of
+// a refresh meta tag), like "5; url=/next", or an empty string if it has none
+func parseRefreshHeader(value string) string {
+	_, target, found := strings.Cut(value, ";")
+	if !found {
+		_, target, found = strings.Cut(value, ",")
+		if !found {
+			return ""
+		}
+	}
+
+	target = strings.TrimSpace(target)
+
+	if len(target) >= 4 && strings.EqualFold(target[:3], "url") {
+		rest := strings.TrimSpace(target[3:])
+		if strings.HasPrefix(rest, "=") {
+			target = strings.TrimSpace(rest[1:])
+		}
+	}
+
+	return strings.Trim(target, `"'`)
+}
+
+// hasAnyRel return true if one of the relations of a link is in the list
+func hasAnyRel(rels []string, list []string) bool {
+	for _, rel := range rels {
+		for _, candidate := range list {
+			if rel == candidate {
+				return true
+			}
+		}
+	}
+
+	return false
+}
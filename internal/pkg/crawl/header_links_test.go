@@ -0,0 +1,66 @@
+package crawl
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHeaderLinks(t *testing.T) {
+	c := &Crawl{SkippedLinks: NewSkippedLinks()}
+
+	base, err := url.Parse("https://example.com/dir/page")
+	assert.NoError(t, err)
+
+	resp := &http.Response{
+		StatusCode: 201,
+		Header:     make(http.Header),
+	}
+	resp.Header.Add("Link", `</style.css>; rel=preload; as=style, <https://cdn.example.com>; rel=preconnect`)
+	resp.Header.Add("Link", `<page2>; rel="next", <mailto:someone@example.com>; rel=author`)
+	resp.Header.Set("Refresh", "5; url='/refreshed'")
+	resp.Header.Set("Location", "/created/1")
+
+	outlinks, assets, hints := c.extractHeaderLinks(base, resp)
+
+	assert.Equal(t, []string{
+		"https://example.com/dir/page2",
+		"https://example.com/refreshed",
+		"https://example.com/created/1",
+	}, urlStrings(outlinks))
+	assert.Equal(t, []string{"https://example.com/style.css"}, urlStrings(assets))
+
+	assert.Len(t, hints, 1)
+	assert.Equal(t, "next", hints["https://example.com/dir/page2"].Rel)
+
+	// The Location header of a redirection is followed by the client
+	resp.StatusCode = 301
+	resp.Header.Del("Link")
+	resp.Header.Del("Refresh")
+
+	outlinks, _, _ = c.extractHeaderLinks(base, resp)
+	assert.Empty(t, outlinks)
+}
+
+func TestParseRefreshHeader(t *testing.T) {
+	for value, expected := range map[string]string{
+		"5; url=/next":                  "/next",
+		"0;URL='https://example.com/'":  "https://example.com/",
+		`3, url="relative"`:             "relative",
+		"10":                            "",
+		"0; https://example.com/no-key": "https://example.com/no-key",
+	} {
+		assert.Equal(t, expected, parseRefreshHeader(value), value)
+	}
+}
+
+func urlStrings(URLs []*url.URL) (output []string) {
+	for _, URL := range URLs {
+		output = append(output, utils.URLToString(URL))
+	}
+
+	return output
+}
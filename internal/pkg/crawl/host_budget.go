@@ -0,0 +1,147 @@
+package crawl
+
+import (
+	"encoding/csv"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// HostTimeBudget limits the wall-clock time spent on each host: the clock of a host
+// starts with the first item of the host taken by a worker, once the budget is spent
+// the remaining items of the host aren't captured but written to deferred.csv
+type HostTimeBudget struct {
+	sync.Mutex
+	Budget  time.Duration
+	started map[string]time.Time
+	spent   map[string]bool
+	file    *os.File
+	writer  *csv.Writer
+	count   int64
+}
+
+// NewHostTimeBudget create (or append to) the deferred.csv file in the job's directory
+func NewHostTimeBudget(jobPath string, budget time.Duration) (*HostTimeBudget, error) {
+	err := os.MkdirAll(jobPath, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path.Join(jobPath, "deferred.csv"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	hostBudget := &HostTimeBudget{
+		Budget:  budget,
+		started: make(map[string]time.Time),
+		spent:   make(map[string]bool),
+		file:    file,
+		writer:  csv.NewWriter(file),
+	}
+
+	// Only write the header if the file is new
+	stat, err := file.Stat()
+	if err == nil && stat.Size() == 0 {
+		hostBudget.writer.Write([]string{"url", "host", "hop", "type", "via"})
+		hostBudget.writer.Flush()
+	}
+
+	return hostBudget, nil
+}
+
+// isSpent start the clock of the host if it isn't started yet, and return true if the
+// budget of the host is spent. firstTime is true the first time the budget is found spent.
+func (hostBudget *HostTimeBudget) isSpent(host string) (spent bool, firstTime bool) {
+	hostBudget.Lock()
+	defer hostBudget.Unlock()
+
+	if hostBudget.spent[host] {
+		return true, false
+	}
+
+	started, exists := hostBudget.started[host]
+	if !exists {
+		hostBudget.started[host] = time.Now()
+		return false, false
+	}
+
+	if time.Since(started) < hostBudget.Budget {
+		return false, false
+	}
+
+	hostBudget.spent[host] = true
+
+	return true, true
+}
+
+func (hostBudget *HostTimeBudget) write(item *frontier.Item) {
+	hostBudget.Lock()
+	defer hostBudget.Unlock()
+
+	var via string
+	if item.ParentItem != nil {
+		via = utils.URLToString(item.ParentItem.URL)
+	}
+
+	hostBudget.count++
+
+	hostBudget.writer.Write([]string{
+		utils.URLToString(item.URL),
+		item.Host,
+		strconv.Itoa(int(item.Hop)),
+		item.Type,
+		via,
+	})
+	hostBudget.writer.Flush()
+}
+
+// Totals return the number of hosts whose budget is spent and the number of deferred items
+func (hostBudget *HostTimeBudget) Totals() (hosts int, count int64) {
+	hostBudget.Lock()
+	defer hostBudget.Unlock()
+
+	return len(hostBudget.spent), hostBudget.count
+}
+
+// Close flush the pending lines and closes the underlying deferred.csv file
+func (hostBudget *HostTimeBudget) Close() error {
+	hostBudget.Lock()
+	defer hostBudget.Unlock()
+
+	hostBudget.writer.Flush()
+
+	return hostBudget.file.Close()
+}
+
+// checkHostTimeBudget return true if the time budget of the host of the item is spent,
+// the item is then written to deferred.csv and marked as done instead of being captured
+func (c *Crawl) checkHostTimeBudget(item *frontier.Item) bool {
+	if c.HostTimeBudget == nil {
+		return false
+	}
+
+	spent, firstTime := c.HostTimeBudget.isSpent(item.Host)
+	if !spent {
+		return false
+	}
+
+	if firstTime {
+		logWarning.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"host":   item.Host,
+			"budget": c.HostTimeBudget.Budget.String(),
+		})).Warn("time budget spent for host, its remaining URLs are deferred")
+	}
+
+	c.HostTimeBudget.write(item)
+
+	// Mark the item as done for HQ or the queue backend
+	c.markItemDone(item)
+
+	return true
+}
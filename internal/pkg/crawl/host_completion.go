@@ -0,0 +1,205 @@
+package crawl
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// hostCompletionInterval is how often the hosts are checked for completion, a host
+// must also have been idle for that long, so that the outlinks of its last captures
+// have had the time to reach the frontier
+const hostCompletionInterval = 30 * time.Second
+
+// HostCompletion emits an event, in the logs and with a webhook, when all the URLs queued
+// for a host have been processed, so that the QA of a site can start before the end of the
+// crawl. Only the local frontier is known: with HQ or a queue backend, a host is completed
+// once the items of the host delivered to this crawler have been processed.
+type HostCompletion struct {
+	sync.Mutex
+	Webhook string
+	hosts   map[string]*HostSummary
+}
+
+// HostSummary is the summary of the captures of a host, as sent to the webhook
+type HostSummary struct {
+	Host        string           `json:"host"`
+	Captures    int64            `json:"captures"`
+	Requests    int64            `json:"requests"`
+	Errors      int64            `json:"errors"`
+	StatusCodes map[string]int64 `json:"statusCodes"`
+	Started     time.Time        `json:"started"`
+	Completed   time.Time        `json:"completed"`
+	Job         string           `json:"job"`
+	JobID       string           `json:"jobId"`
+
+	inFlight     int
+	lastActivity time.Time
+	done         bool
+}
+
+// NewHostCompletion return the hosts completion tracker, the events are POSTed to the webhook if given
+func NewHostCompletion(webhook string) *HostCompletion {
+	return &HostCompletion{
+		Webhook: webhook,
+		hosts:   make(map[string]*HostSummary),
+	}
+}
+
+// started record the start of the capture of an item of the host
+func (completion *HostCompletion) started(host string) {
+	if completion == nil {
+		return
+	}
+
+	completion.Lock()
+	defer completion.Unlock()
+
+	summary, exists := completion.hosts[host]
+	if !exists {
+		summary = &HostSummary{
+			Host:        host,
+			StatusCodes: make(map[string]int64),
+			Started:     time.Now(),
+		}
+		completion.hosts[host] = summary
+	}
+
+	// New URLs of a completed host reopen it, it is completed again once they are processed
+	summary.done = false
+	summary.inFlight++
+	summary.lastActivity = time.Now()
+}
+
+// finished record the end of the capture of an item of the host
+func (completion *HostCompletion) finished(host string) {
+	if completion == nil {
+		return
+	}
+
+	completion.Lock()
+	defer completion.Unlock()
+
+	summary, exists := completion.hosts[host]
+	if !exists {
+		return
+	}
+
+	summary.Captures++
+	summary.inFlight--
+	summary.lastActivity = time.Now()
+}
+
+// record add a request to the summary of its host, the requests to the hosts
+// that only served assets of other hosts' pages aren't tracked
+func (completion *HostCompletion) record(host string, resp *http.Response, err error) {
+	if completion == nil {
+		return
+	}
+
+	completion.Lock()
+	defer completion.Unlock()
+
+	summary, exists := completion.hosts[host]
+	if !exists {
+		return
+	}
+
+	summary.Requests++
+
+	if err != nil || resp == nil {
+		summary.Errors++
+		return
+	}
+
+	summary.StatusCodes[strconv.Itoa(resp.StatusCode/100)+"xx"]++
+}
+
+// completed return the summaries of the hosts that have no item in flight nor queued
+// in the frontier and have been idle for long enough, they are only returned once
+func (completion *HostCompletion) completed(queued func(host string) int) (summaries []*HostSummary) {
+	completion.Lock()
+	defer completion.Unlock()
+
+	for _, summary := range completion.hosts {
+		if summary.done || summary.inFlight > 0 || time.Since(summary.lastActivity) < hostCompletionInterval {
+			continue
+		}
+
+		if queued(summary.Host) > 0 {
+			continue
+		}
+
+		summary.done = true
+		summary.Completed = time.Now()
+
+		statusCodes := make(map[string]int64)
+		for class, count := range summary.StatusCodes {
+			statusCodes[class] = count
+		}
+
+		copied := *summary
+		copied.StatusCodes = statusCodes
+
+		summaries = append(summaries, &copied)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Host < summaries[j].Host
+	})
+
+	return summaries
+}
+
+// watchHostCompletion check the hosts for completion periodically,
+// and log and send to the webhook the summaries of the completed hosts
+func (c *Crawl) watchHostCompletion() {
+	for !c.Finished.Get() {
+		time.Sleep(hostCompletionInterval)
+
+		for _, summary := range c.HostCompletion.completed(c.Frontier.GetHostCount) {
+			summary.Job = c.Job
+			summary.JobID = c.JobID
+
+			logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"host":        summary.Host,
+				"captures":    summary.Captures,
+				"requests":    summary.Requests,
+				"errors":      summary.Errors,
+				"statusCodes": summary.StatusCodes,
+				"duration":    summary.Completed.Sub(summary.Started).Round(time.Second).String(),
+			})).Info("host completed")
+
+			if c.HostCompletion.Webhook != "" {
+				go c.sendHostCompletion(summary)
+			}
+		}
+	}
+}
+
+// sendHostCompletion POST the summary of the completed host as JSON to the --host-completion-webhook URL
+func (c *Crawl) sendHostCompletion(summary *HostSummary) {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	resp, err := client.Post(c.HostCompletion.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logError.WithFields(c.genLogFields(err, c.HostCompletion.Webhook, nil)).Error("unable to send host completion to the webhook")
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logError.WithFields(c.genLogFields(nil, c.HostCompletion.Webhook, map[string]interface{}{
+			"statusCode": resp.StatusCode,
+		})).Error("unable to send host completion to the webhook")
+	}
+}
@@ -0,0 +1,49 @@
+package crawl
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostCompletion(t *testing.T) {
+	var (
+		completion = NewHostCompletion("")
+		queued     = map[string]int{"b.com": 3}
+		getQueued  = func(host string) int { return queued[host] }
+	)
+
+	completion.started("a.com")
+	completion.record("a.com", &http.Response{StatusCode: 200}, nil)
+	completion.record("a.com", nil, errors.New("timeout"))
+	completion.record("cdn.com", &http.Response{StatusCode: 200}, nil)
+
+	completion.started("b.com")
+	completion.finished("b.com")
+
+	// a.com has an item in flight, and the hosts have been active too recently
+	assert.Empty(t, completion.completed(getQueued))
+
+	completion.finished("a.com")
+	for _, summary := range completion.hosts {
+		summary.lastActivity = time.Now().Add(-hostCompletionInterval)
+	}
+
+	// b.com still has URLs queued in the frontier
+	summaries := completion.completed(getQueued)
+	assert.Len(t, summaries, 1)
+	assert.Equal(t, "a.com", summaries[0].Host)
+	assert.Equal(t, int64(1), summaries[0].Captures)
+	assert.Equal(t, int64(2), summaries[0].Requests)
+	assert.Equal(t, int64(1), summaries[0].Errors)
+	assert.Equal(t, int64(1), summaries[0].StatusCodes["2xx"])
+
+	// A completed host is only reported once, until it gets new URLs
+	assert.Empty(t, completion.completed(getQueued))
+
+	queued["b.com"] = 0
+	assert.Len(t, completion.completed(getQueued), 1)
+}
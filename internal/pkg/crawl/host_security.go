@@ -0,0 +1,188 @@
+package crawl
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostSecurity records the Strict-Transport-Security and Alt-Svc headers
+// served by every host, with --host-security. Like a browser, the http:// links
+// to the hosts with an active HSTS policy are upgraded to https:// before being queued.
+type HostSecurity struct {
+	sync.RWMutex
+	hosts map[string]*HostSecurityEntry
+}
+
+// HostSecurityEntry is the security policy of a host, as of the last response it served
+type HostSecurityEntry struct {
+	HSTSMaxAge            int64
+	HSTSIncludeSubDomains bool
+	HSTSPreload           bool
+	HSTSExpires           time.Time
+	AltSvc                string
+	Upgraded              int64
+}
+
+// NewHostSecurity create an empty hosts security map
+func NewHostSecurity() *HostSecurity {
+	return &HostSecurity{hosts: make(map[string]*HostSecurityEntry)}
+}
+
+// record update the policy of the host of the response with its headers, the
+// Strict-Transport-Security header is only honored over https like browsers do
+func (security *HostSecurity) record(resp *http.Response) {
+	if security == nil || resp.Request == nil {
+		return
+	}
+
+	var (
+		host   = strings.ToLower(resp.Request.URL.Hostname())
+		hsts   = resp.Header.Get("Strict-Transport-Security")
+		altSvc = resp.Header.Get("Alt-Svc")
+	)
+
+	if resp.Request.URL.Scheme != "https" {
+		hsts = ""
+	}
+
+	if hsts == "" && altSvc == "" {
+		return
+	}
+
+	security.Lock()
+	defer security.Unlock()
+
+	entry, exists := security.hosts[host]
+	if !exists {
+		entry = new(HostSecurityEntry)
+		security.hosts[host] = entry
+	}
+
+	if hsts != "" {
+		maxAge, includeSubDomains, preload, valid := parseHSTSHeader(hsts)
+		if valid {
+			entry.HSTSMaxAge = maxAge
+			entry.HSTSIncludeSubDomains = includeSubDomains
+			entry.HSTSPreload = preload
+			entry.HSTSExpires = time.Now().Add(time.Duration(maxAge) * time.Second)
+		}
+	}
+
+	// "clear" invalidates the alternative services previously announced
+	if altSvc == "clear" {
+		entry.AltSvc = ""
+	} else if altSvc != "" {
+		entry.AltSvc = altSvc
+	}
+}
+
+// upgrade rewrite an http:// URL to https:// if its host, or one of its parent
+// domains with includeSubDomains, has an active HSTS policy
+func (security *HostSecurity) upgrade(URL *url.URL) {
+	if security == nil || URL.Scheme != "http" {
+		return
+	}
+
+	host := strings.ToLower(URL.Hostname())
+
+	security.Lock()
+	defer security.Unlock()
+
+	for domain, subdomain := host, false; domain != ""; subdomain = true {
+		entry, exists := security.hosts[domain]
+		if exists && time.Now().Before(entry.HSTSExpires) && (!subdomain || entry.HSTSIncludeSubDomains) {
+			URL.Scheme = "https"
+			if URL.Port() == "80" {
+				URL.Host = strings.TrimSuffix(URL.Host, ":80")
+			}
+
+			entry.Upgraded++
+
+			return
+		}
+
+		_, domain, _ = strings.Cut(domain, ".")
+	}
+}
+
+// parseHSTSHeader parse the directives of a Strict-Transport-Security header,
+// a header without a valid max-age directive is ignored
+func parseHSTSHeader(value string) (maxAge int64, includeSubDomains bool, preload bool, valid bool) {
+	for _, directive := range strings.Split(value, ";") {
+		name, argument, _ := strings.Cut(strings.TrimSpace(directive), "=")
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "max-age":
+			age, err := strconv.ParseInt(strings.Trim(strings.TrimSpace(argument), `"`), 10, 64)
+			if err != nil || age < 0 {
+				return 0, false, false, false
+			}
+
+			maxAge, valid = age, true
+		case "includesubdomains":
+			includeSubDomains = true
+		case "preload":
+			preload = true
+		}
+	}
+
+	return maxAge, includeSubDomains, preload, valid
+}
+
+// Write dump the hosts security map as a CSV file in the job's directory
+func (security *HostSecurity) Write(jobPath string) error {
+	security.RLock()
+	defer security.RUnlock()
+
+	var hosts []string
+	for host := range security.hosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	file, err := os.Create(path.Join(jobPath, "host-security.csv"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	err = writer.Write([]string{"host", "hsts_max_age", "hsts_include_subdomains", "hsts_preload", "alt_svc", "upgraded_links"})
+	if err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		entry := security.hosts[host]
+
+		hstsMaxAge := ""
+		if !entry.HSTSExpires.IsZero() {
+			hstsMaxAge = strconv.FormatInt(entry.HSTSMaxAge, 10)
+		}
+
+		err = writer.Write([]string{
+			host,
+			hstsMaxAge,
+			strconv.FormatBool(entry.HSTSIncludeSubDomains),
+			strconv.FormatBool(entry.HSTSPreload),
+			entry.AltSvc,
+			strconv.FormatInt(entry.Upgraded, 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
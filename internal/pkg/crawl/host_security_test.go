@@ -0,0 +1,57 @@
+package crawl
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostSecurityUpgrade(t *testing.T) {
+	security := NewHostSecurity()
+
+	for rawURL, hsts := range map[string]string{
+		"https://example.com/":     "max-age=31536000; includeSubDomains; preload",
+		"https://only.org/":        "max-age=600",
+		"http://insecure.net/":     "max-age=600",
+		"https://expired.example/": "max-age=0",
+	} {
+		URL, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+
+		security.record(&http.Response{
+			Request: &http.Request{URL: URL},
+			Header:  http.Header{"Strict-Transport-Security": []string{hsts}},
+		})
+	}
+
+	for rawURL, expected := range map[string]string{
+		"http://example.com/page":        "https://example.com/page",
+		"http://www.example.com:80/page": "https://www.example.com/page",
+		"http://only.org/":               "https://only.org/",
+		"http://sub.only.org/":           "http://sub.only.org/",
+		"http://insecure.net/":           "http://insecure.net/",
+		"http://expired.example/":        "http://expired.example/",
+	} {
+		URL, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+
+		security.upgrade(URL)
+		assert.Equal(t, expected, URL.String())
+	}
+
+	assert.Equal(t, int64(2), security.hosts["example.com"].Upgraded)
+	assert.True(t, security.hosts["example.com"].HSTSPreload)
+}
+
+func TestParseHSTSHeader(t *testing.T) {
+	maxAge, includeSubDomains, preload, valid := parseHSTSHeader(`max-age="300"; includeSubDomains`)
+	assert.Equal(t, int64(300), maxAge)
+	assert.True(t, includeSubDomains)
+	assert.False(t, preload)
+	assert.True(t, valid)
+
+	_, _, _, valid = parseHSTSHeader("includeSubDomains")
+	assert.False(t, valid)
+}
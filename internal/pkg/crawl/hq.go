@@ -83,7 +83,7 @@ func (c *Crawl) HQProducer() {
 				return
 			default:
 				mutex.Lock()
-				if (len(discoveredArray) >= int(math.Ceil(float64(c.Workers)/2)) || time.Since(HQLastSent) >= time.Second*10) && len(discoveredArray) > 0 {
+				if (len(discoveredArray) >= int(math.Ceil(float64(c.getWorkersCount())/2)) || time.Since(HQLastSent) >= time.Second*10) && len(discoveredArray) > 0 {
 					for {
 						_, err := c.HQClient.Discovered(discoveredArray, "seed", false, false)
 						if err != nil {
@@ -147,23 +147,26 @@ func (c *Crawl) HQProducer() {
 func (c *Crawl) HQConsumer() {
 	for {
 		// This is on purpose evaluated every time,
-		// because the value of workers will maybe change
-		// during the crawl in the future (to be implemented)
-		var HQBatchSize = int(math.Ceil(float64(c.Workers) / 2))
+		// because the number of workers can change during the crawl
+		var HQBatchSize = int(math.Ceil(float64(c.getWorkersCount()) / 2))
 
 		if c.Finished.Get() {
 			c.Logger.Error("crawl finished, stopping HQ consumer")
 			break
 		}
 
+		// Stop pulling URLs from HQ while the crawl is paused,
+		// e.g. when the disk is full
 		if c.Paused.Get() {
 			time.Sleep(time.Second)
+			continue
 		}
 
 		// If HQContinuousPull is set to true, we will pull URLs from HQ
 		// continuously, otherwise we will only pull URLs when needed
 		if !c.HQContinuousPull {
-			if c.ActiveWorkers.Value() >= int64(c.Workers-(c.Workers/10)) {
+			workers := c.getWorkersCount()
+			if c.ActiveWorkers.Value() >= int64(workers-(workers/10)) {
 				time.Sleep(time.Millisecond * 100)
 				continue
 			}
@@ -216,7 +219,7 @@ func (c *Crawl) HQFinisher() {
 		locallyCrawledTotal += int(finishedItem.LocallyCrawled)
 		finishedArray = append(finishedArray, gocrawlhq.URL{ID: finishedItem.ID, Value: utils.URLToString(finishedItem.URL)})
 
-		if len(finishedArray) == int(math.Ceil(float64(c.Workers)/2)) {
+		if len(finishedArray) == int(math.Ceil(float64(c.getWorkersCount())/2)) {
 			for {
 				_, err := c.HQClient.Finished(finishedArray, locallyCrawledTotal)
 				if err != nil {
@@ -0,0 +1,551 @@
+package crawl
+
+import (
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"golang.org/x/net/html"
+)
+
+var (
+	regexStyleAttributeURL = regexp.MustCompile(`(?:\(['"]?)(.*?)(?:['"]?\))`)
+	regexStyleTagURL       = regexp.MustCompile(`(?m)url\((.*?)\)`)
+)
+
+// tokenizedDocument holds what has been extracted from a HTML document by the streaming tokenizer
+type tokenizedDocument struct {
+	base        string
+	rawOutlinks []string
+	rawAssets   []string
+	text        strings.Builder
+
+	// AMP and mobile versions of the page, with --capture-mobile-versions
+	rawMobileVersions []string
+
+	// The hints of the links found in the <a> and <iframe> tags, like extractLinkHints
+	// does for the goquery documents, the anchor is the <a> tag being read
+	rawHints []tokenizedHint
+	heading  *strings.Builder
+	section  string
+	anchor   *tokenizedAnchor
+
+	// If set, the outlinks found in the tags are given to this
+	// function as soon as they are found instead of being collected
+	emitOutlink func(rawOutlink string, typeAttr string, hint *frontier.LinkHints)
+}
+
+// tokenizedHint is the hint of a link as found by the tokenizer, its
+// content type is guessed once the link has been made absolute
+type tokenizedHint struct {
+	rawLink  string
+	typeAttr string
+	hint     *frontier.LinkHints
+}
+
+// tokenizedAnchor is a <a> tag whose text is being read, its
+// link is added once the tag is closed, with its anchor text
+type tokenizedAnchor struct {
+	href     string
+	typeAttr string
+	title    string
+	alt      string
+	text     strings.Builder
+	hint     *frontier.LinkHints
+}
+
+// streamedOutlink is an outlink sent by the tokenizer while the document is parsed
+type streamedOutlink struct {
+	URL  *url.URL
+	hint *frontier.LinkHints
+}
+
+// extractWithTokenizer extract the outlinks and the assets of a HTML document
+// using a streaming tokenizer, it is a lot cheaper than building the whole DOM
+// with goquery and is used for large documents. It mimics extractOutlinks and
+// extractAssets, without the site-specific code that needs a full document.
+// If outlinksChan isn't nil, the outlinks found in the tags are sent to it as
+// they are found, with their hints, only the outlinks found in the text are returned.
+func (c *Crawl) extractWithTokenizer(base *url.URL, item *frontier.Item, body io.Reader, outlinksChan chan<- *streamedOutlink) (outlinks []*url.URL, assets []*url.URL, mobileVersions []*url.URL, hints map[string]*frontier.LinkHints) {
+	doc := new(tokenizedDocument)
+
+	if outlinksChan != nil {
+		doc.emitOutlink = func(rawOutlink string, typeAttr string, hint *frontier.LinkHints) {
+			if len(c.skipUnfetchableLinks([]string{rawOutlink})) == 0 {
+				return
+			}
+
+			// The <base> tag is usually found before any link, in the <head>
+			outlinkBase := base
+			if doc.base != "" && !utils.StringInSlice("base", c.DisabledHTMLTags) {
+				if baseTagValue, err := url.Parse(doc.base); err == nil {
+					outlinkBase = baseTagValue
+				}
+			}
+
+			outlink, err := outlinkBase.Parse(rawOutlink)
+			if err != nil {
+				return
+			}
+
+			outlinksChan <- &streamedOutlink{
+				URL:  outlink,
+				hint: completeLinkHint(outlink, typeAttr, hint),
+			}
+		}
+	}
+
+	c.tokenizeHTML(doc, body)
+
+	// Websites can use a <base> tag to specify a base for relative URLs in every other tags.
+	if doc.base != "" && !utils.StringInSlice("base", c.DisabledHTMLTags) {
+		baseTagValue, err := url.Parse(doc.base)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("error while parsing base tag value")
+		} else {
+			base = baseTagValue
+		}
+	}
+
+	outlinks = utils.StringSliceToURLSlice(c.skipUnfetchableLinks(doc.rawOutlinks))
+	outlinks = append(outlinks, extractLinksFromText(doc.text.String())...)
+	outlinks = utils.MakeAbsolute(base, outlinks)
+	outlinks = utils.DedupeURLs(utils.RemoveFragments(outlinks))
+
+	assets = utils.StringSliceToURLSlice(c.skipUnfetchableLinks(doc.rawAssets))
+	assets = c.excludeHosts(assets)
+	assets = utils.DedupeURLs(utils.MakeAbsolute(base, assets))
+
+	mobileVersions = utils.StringSliceToURLSlice(c.skipUnfetchableLinks(doc.rawMobileVersions))
+	mobileVersions = utils.DedupeURLs(utils.MakeAbsolute(base, mobileVersions))
+
+	// The hints are keyed by the absolute URL of the link, without its fragment
+	hints = make(map[string]*frontier.LinkHints)
+	for _, rawHint := range doc.rawHints {
+		link, err := base.Parse(rawHint.rawLink)
+		if err != nil {
+			continue
+		}
+		link.Fragment = ""
+
+		mergeLinkHint(hints, utils.URLToString(link), completeLinkHint(link, rawHint.typeAttr, rawHint.hint))
+	}
+
+	return outlinks, assets, mobileVersions, hints
+}
+
+func (c *Crawl) tokenizeHTML(doc *tokenizedDocument, body io.Reader) {
+	var (
+		tokenizer = html.NewTokenizer(body)
+		inBody    bool
+		rawTag    string
+		rawAttrs  map[string]string
+	)
+
+	for {
+		tokenType := tokenizer.Next()
+
+		switch tokenType {
+		case html.ErrorToken:
+			// io.EOF or a read error, in both cases we keep what we extracted so far
+			doc.closeAnchor()
+			return
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			tag := string(name)
+
+			attrs := make(map[string]string)
+			for hasAttr {
+				var key, value []byte
+				key, value, hasAttr = tokenizer.TagAttr()
+				if _, exists := attrs[string(key)]; !exists {
+					attrs[string(key)] = string(value)
+				}
+			}
+
+			if tag == "body" {
+				inBody = true
+			}
+
+			if isHeadingTag(tag) && tokenType == html.StartTagToken {
+				doc.heading = new(strings.Builder)
+			}
+
+			c.extractFromTag(doc, tag, attrs)
+
+			// The content of these tags is given as a single raw text token
+			if tokenType == html.StartTagToken && (tag == "script" || tag == "style" || tag == "noscript") {
+				rawTag = tag
+				rawAttrs = attrs
+			}
+		case html.CommentToken:
+			if match := regexSSIInclude.FindStringSubmatch(string(tokenizer.Text())); match != nil {
+				doc.rawAssets = append(doc.rawAssets, match[1])
+			}
+		case html.EndTagToken:
+			rawTag = ""
+
+			name, _ := tokenizer.TagName()
+			if tag := string(name); tag == "a" {
+				doc.closeAnchor()
+			} else if isHeadingTag(tag) && doc.heading != nil {
+				doc.section = truncateUTF8(strings.Join(strings.Fields(doc.heading.String()), " "), maxAnchorTextLength)
+				doc.heading = nil
+			}
+		case html.TextToken:
+			text := string(tokenizer.Text())
+
+			switch rawTag {
+			case "script":
+				if !utils.StringInSlice("script", c.DisabledHTMLTags) {
+					c.extractFromScript(doc, rawAttrs, text)
+				}
+			case "style":
+				if !utils.StringInSlice("style", c.DisabledHTMLTags) {
+					c.extractFromStyle(doc, text)
+				}
+
+				if inBody {
+					doc.text.WriteString(text)
+				}
+			case "noscript":
+				if !utils.StringInSlice("noscript", c.DisabledHTMLTags) {
+					doc.rawAssets = append(doc.rawAssets, c.extractNoscriptAssets(text)...)
+				}
+			default:
+				if inBody {
+					doc.text.WriteString(text)
+				}
+
+				if doc.heading != nil {
+					doc.heading.WriteString(text)
+				}
+
+				if doc.anchor != nil {
+					doc.anchor.text.WriteString(text)
+				}
+			}
+		}
+	}
+}
+
+func (c *Crawl) extractFromTag(doc *tokenizedDocument, tag string, attrs map[string]string) {
+	// Attributes that are looked for on every element
+	if dataItem, exists := attrs["data-item"]; exists {
+		URLsFromJSON, _ := getURLsFromJSON(dataItem)
+		doc.rawAssets = append(doc.rawAssets, URLsFromJSON...)
+	}
+
+	if style, exists := attrs["style"]; exists {
+		for _, match := range regexStyleAttributeURL.FindAllStringSubmatch(style, -1) {
+			doc.rawAssets = append(doc.rawAssets, match[1])
+		}
+	}
+
+	if dataPreview, exists := attrs["data-preview"]; exists && strings.HasPrefix(dataPreview, "http") {
+		doc.rawAssets = append(doc.rawAssets, dataPreview)
+	}
+
+	if itemprop, exists := attrs["itemprop"]; exists {
+		kind := getMicrodataKind(itemprop)
+
+		link, exists := getMicrodataValue(tag, func(name string) (string, bool) {
+			value, exists := attrs[name]
+			return value, exists
+		})
+
+		if exists && kind == "asset" {
+			doc.rawAssets = append(doc.rawAssets, link)
+		} else if exists && kind == "outlink" {
+			doc.addOutlink(link)
+		}
+	}
+
+	switch tag {
+	case "esi:include":
+		doc.addAttributes(attrs, "src", "alt")
+	case "base":
+		if href, exists := attrs["href"]; exists && doc.base == "" {
+			doc.base = href
+		}
+	case "a":
+		// A <a> tag isn't supposed to be nested in another one, it closes the previous one
+		doc.closeAnchor()
+
+		if href, exists := attrs["href"]; exists && !c.skipNofollowLink(attrs["rel"]) {
+			doc.anchor = &tokenizedAnchor{
+				href:     href,
+				typeAttr: attrs["type"],
+				title:    attrs["title"],
+				hint: &frontier.LinkHints{
+					Heading: doc.section,
+					Rel:     strings.Join(strings.Fields(strings.ToLower(attrs["rel"])), " "),
+				},
+			}
+		}
+	case "iframe":
+		if src, exists := attrs["src"]; exists {
+			doc.addLink(src, attrs["type"], &frontier.LinkHints{
+				AnchorText: truncateUTF8(strings.TrimSpace(attrs["title"]), maxAnchorTextLength),
+				Heading:    doc.section,
+			})
+		}
+	case "img":
+		// The alternative text of the image is the anchor text of a link without text
+		if doc.anchor != nil && doc.anchor.alt == "" {
+			doc.anchor.alt = attrs["alt"]
+		}
+	case "ref":
+		if target, exists := attrs["target"]; exists {
+			doc.addOutlink(target)
+		}
+	}
+
+	if utils.StringInSlice(tag, c.DisabledHTMLTags) {
+		return
+	}
+
+	switch tag {
+	case "img":
+		doc.addAttributes(attrs, "src", "data-src", "data-lazy-src")
+		doc.addSrcsets(attrs, "data-srcset", "srcset")
+	case "video", "audio":
+		doc.addAttributes(attrs, "src")
+	case "source":
+		doc.addAttributes(attrs, "src")
+		doc.addSrcsets(attrs, "srcset", "data-srcset")
+	case "link":
+		if c.CaptureMobileVersions && isMobileVersionLink(attrs["rel"], attrs["media"]) {
+			if href, exists := attrs["href"]; exists {
+				doc.rawMobileVersions = append(doc.rawMobileVersions, href)
+			}
+			return
+		}
+
+		if !c.CaptureAlternatePages && attrs["rel"] == "alternate" {
+			return
+		}
+
+		doc.addAttributes(attrs, "href")
+	case "meta":
+		doc.addAttributes(attrs, "href")
+
+		if content, exists := attrs["content"]; exists {
+			if strings.Contains(content, "http") || isSocialPreviewMeta(attrs["property"]) || isSocialPreviewMeta(attrs["name"]) {
+				doc.rawAssets = append(doc.rawAssets, content)
+			}
+		}
+	case "script":
+		doc.addAttributes(attrs, "src")
+	}
+}
+
+// extractNoscriptAssets extract the assets of the HTML fallback of a <noscript> tag,
+// the parsers give its content as raw text because they act as if scripting is enabled
+func (c *Crawl) extractNoscriptAssets(content string) []string {
+	doc := new(tokenizedDocument)
+
+	c.tokenizeHTML(doc, strings.NewReader(content))
+
+	return doc.rawAssets
+}
+
+func (c *Crawl) extractFromScript(doc *tokenizedDocument, attrs map[string]string, script string) {
+	if attrs["type"] == "application/json" {
+		URLsFromJSON, _ := getURLsFromJSON(script)
+		doc.rawAssets = append(doc.rawAssets, URLsFromJSON...)
+	}
+
+	if attrs["type"] == "application/ld+json" {
+		outlinks, assets := getURLsFromJSONLD(script)
+		for _, outlink := range outlinks {
+			doc.addOutlink(outlink)
+		}
+		doc.rawAssets = append(doc.rawAssets, assets...)
+	}
+
+	for _, scriptLink := range utils.DedupeStrings(regexOutlinks.FindAllString(script, -1)) {
+		if strings.HasPrefix(scriptLink, "http") {
+			doc.rawAssets = append(doc.rawAssets, scriptLink)
+		}
+	}
+
+	doc.rawAssets = append(doc.rawAssets, getURLsFromScriptVariable(script)...)
+}
+
+func (c *Crawl) extractFromStyle(doc *tokenizedDocument, style string) {
+	for _, match := range regexStyleTagURL.FindAllStringSubmatch(style, -1) {
+		link := strings.Replace(match[1], "'", "", -1)
+		link = strings.Replace(link, "\"", "", -1)
+
+		// If the URL already has http (or https), we don't need add anything to it.
+		if !strings.Contains(link, "http") {
+			link = strings.Replace(link, "//", "http://", -1)
+		}
+
+		if strings.HasPrefix(link, "#wp-") {
+			continue
+		}
+
+		doc.rawAssets = append(doc.rawAssets, link)
+	}
+}
+
+func (doc *tokenizedDocument) addOutlink(rawOutlink string) {
+	doc.addLink(rawOutlink, "", nil)
+}
+
+// addLink add an outlink with its hint, if any
+func (doc *tokenizedDocument) addLink(rawOutlink string, typeAttr string, hint *frontier.LinkHints) {
+	if doc.emitOutlink != nil {
+		doc.emitOutlink(rawOutlink, typeAttr, hint)
+		return
+	}
+
+	doc.rawOutlinks = append(doc.rawOutlinks, rawOutlink)
+
+	if hint != nil {
+		doc.rawHints = append(doc.rawHints, tokenizedHint{rawLink: rawOutlink, typeAttr: typeAttr, hint: hint})
+	}
+}
+
+// closeAnchor add the link of the <a> tag being read, with its anchor text,
+// or the alternative text of its image or its title if it has no text
+func (doc *tokenizedDocument) closeAnchor() {
+	anchor := doc.anchor
+	if anchor == nil {
+		return
+	}
+	doc.anchor = nil
+
+	text := strings.Join(strings.Fields(anchor.text.String()), " ")
+	if text == "" {
+		text = anchor.alt
+	}
+
+	if text == "" {
+		text = anchor.title
+	}
+
+	anchor.hint.AnchorText = truncateUTF8(strings.TrimSpace(text), maxAnchorTextLength)
+
+	doc.addLink(anchor.href, anchor.typeAttr, anchor.hint)
+}
+
+func isHeadingTag(tag string) bool {
+	return len(tag) == 2 && tag[0] == 'h' && tag[1] >= '1' && tag[1] <= '6'
+}
+
+func (doc *tokenizedDocument) addAttributes(attrs map[string]string, names ...string) {
+	for _, name := range names {
+		if value, exists := attrs[name]; exists {
+			doc.rawAssets = append(doc.rawAssets, value)
+		}
+	}
+}
+
+func (doc *tokenizedDocument) addSrcsets(attrs map[string]string, names ...string) {
+	for _, name := range names {
+		if srcset, exists := attrs[name]; exists {
+			for _, link := range strings.Split(srcset, ",") {
+				doc.rawAssets = append(doc.rawAssets, strings.Split(strings.TrimSpace(link), " ")[0])
+			}
+		}
+	}
+}
+
+// tokenizePageLinks scrape a large HTML document with the streaming tokenizer, the
+// outlinks are queued while the document is still being parsed (and downloaded, if the
+// body is read from the network), the other links are queued by Capture at the end.
+// The outlinks aren't streamed when they may be skipped by a skip-outlinks --body-rule,
+// or when the pagination links of the page have to be told apart from its outlinks.
+func (c *Crawl) tokenizePageLinks(base *url.URL, item *frontier.Item, body io.Reader, rulesBody *bodyRulesBody) *pageLinks {
+	var (
+		outlinksChan chan *streamedOutlink
+		streamed     = make(chan int, 1)
+	)
+
+	if (rulesBody == nil || !c.hasBodyRule("skip-outlinks")) && !c.shouldFollowPagination(item) {
+		outlinksChan = make(chan *streamedOutlink, streamedOutlinksBatchSize)
+		go c.queueStreamedOutlinks(item, outlinksChan, streamed)
+	} else {
+		streamed <- 0
+	}
+
+	outlinks, assets, mobileVersions, hints := c.extractWithTokenizer(base, item, body, outlinksChan)
+
+	if outlinksChan != nil {
+		close(outlinksChan)
+	}
+
+	links := &pageLinks{
+		outlinks:       outlinks,
+		hints:          hints,
+		mobileVersions: mobileVersions,
+		assets:         assets,
+		streamed:       <-streamed,
+	}
+
+	if c.shouldFollowPagination(item) {
+		links.pagination = paginationFromOutlinks(base, outlinks, hints)
+	}
+
+	return links
+}
+
+// streamedOutlinksBatchSize is the number of outlinks queued at once while a document is parsed
+const streamedOutlinksBatchSize = 64
+
+// queueStreamedOutlinks queue the outlinks received on the channel by batches, with their
+// hints, the number of unique outlinks is sent on the streamed channel once it is closed
+func (c *Crawl) queueStreamedOutlinks(item *frontier.Item, outlinksChan <-chan *streamedOutlink, streamed chan<- int) {
+	var (
+		seen  = make(map[string]bool)
+		batch []*url.URL
+		hints = make(map[string]*frontier.LinkHints)
+		count int
+	)
+
+	queueBatch := func() {
+		var waitGroup sync.WaitGroup
+
+		waitGroup.Add(1)
+		c.queueOutlinks(batch, hints, item, &waitGroup)
+
+		batch = nil
+		hints = make(map[string]*frontier.LinkHints)
+	}
+
+	for streamedOutlink := range outlinksChan {
+		outlink := streamedOutlink.URL
+		outlink.Fragment = ""
+		outlink.RawFragment = ""
+
+		key := utils.URLToString(outlink)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if streamedOutlink.hint != nil {
+			hints[key] = streamedOutlink.hint
+		}
+
+		batch = append(batch, outlink)
+		count++
+
+		if len(batch) >= streamedOutlinksBatchSize {
+			queueBatch()
+		}
+	}
+
+	if len(batch) > 0 {
+		queueBatch()
+	}
+
+	streamed <- count
+}
@@ -0,0 +1,90 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/stretchr/testify/assert"
+)
+
+const tokenizerHintsDocument = `<html><body>
+<h2>Latest <em>news</em></h2>
+<a href="/report.pdf#page=2" rel="Next  Bookmark">  The annual
+  report </a>
+<a href="/gallery"><img src="/thumb.jpg" alt="Photo gallery"></a>
+<a href="/about" title="About us"></a>
+<iframe src="https://player.example.com/embed/1" title="Video player"></iframe>
+</body></html>`
+
+func TestExtractWithTokenizerHints(t *testing.T) {
+	c := &Crawl{SkippedLinks: NewSkippedLinks(), NofollowLinks: NewNofollowLinks()}
+
+	base, err := url.Parse("https://example.com/news/")
+	assert.NoError(t, err)
+
+	item := frontier.NewItem(base, nil, "seed", 0, "", false)
+
+	_, _, _, hints := c.extractWithTokenizer(base, item, strings.NewReader(tokenizerHintsDocument), nil)
+
+	assert.Equal(t, &frontier.LinkHints{
+		ContentType: "application/pdf",
+		AnchorText:  "The annual report",
+		Heading:     "Latest news",
+		Rel:         "next bookmark",
+	}, hints["https://example.com/report.pdf"])
+
+	assert.Equal(t, "Photo gallery", hints["https://example.com/gallery"].AnchorText)
+	assert.Equal(t, "About us", hints["https://example.com/about"].AnchorText)
+	assert.Equal(t, "Video player", hints["https://player.example.com/embed/1"].AnchorText)
+	assert.Equal(t, "Latest news", hints["https://player.example.com/embed/1"].Heading)
+}
+
+func TestExtractWithTokenizerStreamedHints(t *testing.T) {
+	c := &Crawl{SkippedLinks: NewSkippedLinks(), NofollowLinks: NewNofollowLinks()}
+
+	base, err := url.Parse("https://example.com/news/")
+	assert.NoError(t, err)
+
+	item := frontier.NewItem(base, nil, "seed", 0, "", false)
+
+	// The outlinks of the tags are streamed with their hints
+	outlinksChan := make(chan *streamedOutlink, 16)
+	_, _, _, hints := c.extractWithTokenizer(base, item, strings.NewReader(tokenizerHintsDocument), outlinksChan)
+	close(outlinksChan)
+
+	assert.Empty(t, hints)
+
+	streamed := make(map[string]*frontier.LinkHints)
+	for outlink := range outlinksChan {
+		streamed[outlink.URL.Path] = outlink.hint
+	}
+
+	assert.Len(t, streamed, 4)
+	assert.Equal(t, "The annual report", streamed["/report.pdf"].AnchorText)
+	assert.Equal(t, "application/pdf", streamed["/report.pdf"].ContentType)
+	assert.Equal(t, "Photo gallery", streamed["/gallery"].AnchorText)
+}
+
+func TestTokenizePageLinksPagination(t *testing.T) {
+	c := &Crawl{SkippedLinks: NewSkippedLinks(), NofollowLinks: NewNofollowLinks(), PaginationDepth: 2}
+
+	base, err := url.Parse("https://example.com/list?page=1")
+	assert.NoError(t, err)
+
+	item := frontier.NewItem(base, nil, "seed", 0, "", false)
+
+	// The outlinks aren't streamed, the pagination links are told apart like with goquery
+	links := c.tokenizePageLinks(base, item, strings.NewReader(`<html><body>
+<a href="/list?page=2">2</a>
+<a href="/archive/older" rel="next">Older</a>
+<a href="/about">About</a>
+</body></html>`), nil)
+
+	assert.Equal(t, 0, links.streamed)
+	assert.Len(t, links.outlinks, 3)
+	assert.Len(t, links.pagination, 2)
+	assert.Equal(t, "https://example.com/list?page=2", links.pagination[0].String())
+	assert.Equal(t, "https://example.com/archive/older", links.pagination[1].String())
+}
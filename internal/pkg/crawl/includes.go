@@ -0,0 +1,44 @@
+package crawl
+
+import (
+	"regexp"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// regexSSIInclude matches the content of a <!--#include virtual="..." --> SSI directive
+var regexSSIInclude = regexp.MustCompile(`^\s*#include\s+(?:virtual|file)\s*=\s*["']([^"']+)["']`)
+
+// extractIncludes extract the targets of the Edge Side Includes (<esi:include src>)
+// and of the SSI directives (<!--#include virtual-->) left un-rendered by the origin
+func extractIncludes(doc *goquery.Document) (rawAssets []string) {
+	var walk func(node *html.Node)
+
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.ElementNode:
+			if node.Data == "esi:include" {
+				for _, attr := range node.Attr {
+					if attr.Key == "src" || attr.Key == "alt" {
+						rawAssets = append(rawAssets, attr.Val)
+					}
+				}
+			}
+		case html.CommentNode:
+			if match := regexSSIInclude.FindStringSubmatch(node.Data); match != nil {
+				rawAssets = append(rawAssets, match[1])
+			}
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+
+	for _, node := range doc.Nodes {
+		walk(node)
+	}
+
+	return rawAssets
+}
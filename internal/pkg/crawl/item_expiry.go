@@ -0,0 +1,36 @@
+package crawl
+
+import (
+	"errors"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// errItemExpired is logged when an item is dropped because its deadline passed
+var errItemExpired = errors.New("item expired before being captured")
+
+// dropExpiredItem return true if the item has a deadline that passed, e.g. a breaking news
+// URL produced with a not_after field in the queue backend. The item is then counted,
+// logged and marked as done instead of being captured long after it stopped being relevant.
+func (c *Crawl) dropExpiredItem(item *frontier.Item) bool {
+	if item.NotAfter.IsZero() || time.Now().Before(item.NotAfter) {
+		return false
+	}
+
+	c.ExpiredItems.Incr(1)
+
+	if c.Prometheus && c.PrometheusMetrics.ExpiredItems != nil {
+		c.PrometheusMetrics.ExpiredItems.Inc()
+	}
+
+	logInfo.WithFields(c.genLogFields(errItemExpired, item.URL, map[string]interface{}{
+		"notAfter": item.NotAfter.Format(time.RFC3339),
+		"expired":  time.Since(item.NotAfter).Round(time.Second).String(),
+	})).Info("item dropped")
+
+	// Mark the item as done for HQ or the queue backend
+	c.markItemDone(item)
+
+	return true
+}
@@ -0,0 +1,94 @@
+package crawl
+
+import (
+	"encoding/csv"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// LinkGraph writes the discovered URLs as a CSV edge list, one line per link
+// found on a captured page: the parent URL, the discovered URL, the type of the
+// link (outlink, pagination, mobile, asset or redirect), the hop of the discovered URL
+// and, for the outlinks of HTML pages, the anchor text and the heading of the link
+type LinkGraph struct {
+	sync.Mutex
+	file   *os.File
+	writer *csv.Writer
+}
+
+// NewLinkGraph create (or append to) the graph.csv file in the job's directory
+func NewLinkGraph(jobPath string) (*LinkGraph, error) {
+	err := os.MkdirAll(jobPath, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path.Join(jobPath, "graph.csv"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	graph := &LinkGraph{file: file, writer: csv.NewWriter(file)}
+
+	// Only write the header if the file is new
+	stat, err := file.Stat()
+	if err == nil && stat.Size() == 0 {
+		graph.writer.Write([]string{"parent", "url", "type", "hop", "anchor_text", "heading", "parent_capture_id"})
+		graph.writer.Flush()
+	}
+
+	return graph, nil
+}
+
+// Close flush the pending edges and closes the underlying graph.csv file
+func (g *LinkGraph) Close() error {
+	g.Lock()
+	defer g.Unlock()
+
+	g.writer.Flush()
+
+	return g.file.Close()
+}
+
+func (g *LinkGraph) write(item *frontier.Item, links []*url.URL, hints map[string]*frontier.LinkHints, linkType string) {
+	g.Lock()
+	defer g.Unlock()
+
+	parent := utils.URLToString(item.URL)
+
+	// Outlinks are one hop further than the page, the other
+	// links are on the same hop as the page
+	hop := int(item.Hop)
+	if linkType == "outlink" {
+		hop++
+	}
+
+	for _, link := range links {
+		var anchorText, heading string
+
+		if hint := hints[utils.URLToString(link)]; hint != nil {
+			anchorText = hint.AnchorText
+			heading = hint.Heading
+		}
+
+		g.writer.Write([]string{parent, utils.URLToString(link), linkType, strconv.Itoa(hop), anchorText, heading, item.CaptureID})
+	}
+
+	g.writer.Flush()
+}
+
+// recordLinks add the links of the given type discovered on the item to the link graph, if any,
+// with their hints if they are known
+func (c *Crawl) recordLinks(item *frontier.Item, links []*url.URL, hints map[string]*frontier.LinkHints, linkType string) {
+	if c.LinkGraph == nil || len(links) == 0 {
+		return
+	}
+
+	c.LinkGraph.write(item, links, hints, linkType)
+}
@@ -0,0 +1,161 @@
+package crawl
+
+import (
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// maxAnchorTextLength is the maximum length in bytes of the anchor text and the heading kept in the hints
+const maxAnchorTextLength = 256
+
+// extractLinkHints extract the hints of the links of the page: the content type
+// announced by the type attribute or guessed from the extension, the anchor text,
+// the heading of the section the link is in and the rel attribute. They are keyed
+// by the absolute URL without its fragment, as returned by extractOutlinks.
+func extractLinkHints(base *url.URL, doc *goquery.Document) map[string]*frontier.LinkHints {
+	var (
+		hints   = make(map[string]*frontier.LinkHints)
+		heading string
+	)
+
+	// The elements are matched in document order, so the last heading
+	// seen is the one of the section the following links are in
+	doc.Find("h1, h2, h3, h4, h5, h6, a[href], iframe[src]").Each(func(index int, item *goquery.Selection) {
+		if item.Is("h1, h2, h3, h4, h5, h6") {
+			heading = truncateUTF8(strings.Join(strings.Fields(item.Text()), " "), maxAnchorTextLength)
+			return
+		}
+
+		rawLink, exists := item.Attr("href")
+		if !exists {
+			rawLink, _ = item.Attr("src")
+		}
+
+		link, err := url.Parse(rawLink)
+		if err != nil {
+			return
+		}
+
+		link = base.ResolveReference(link)
+		link.Fragment = ""
+
+		key := utils.URLToString(link)
+
+		hint, exists := hints[key]
+		if !exists {
+			hint = new(frontier.LinkHints)
+			hints[key] = hint
+		}
+
+		// The same URL can be linked multiple times, the first non-empty values are kept
+		if hint.ContentType == "" {
+			typeAttr, _ := item.Attr("type")
+			hint.ContentType = guessContentType(link, typeAttr)
+		}
+
+		if hint.AnchorText == "" {
+			hint.AnchorText = getAnchorText(item)
+		}
+
+		if hint.Heading == "" {
+			hint.Heading = heading
+		}
+
+		if hint.Rel == "" {
+			rel, _ := item.Attr("rel")
+			hint.Rel = strings.Join(strings.Fields(strings.ToLower(rel)), " ")
+		}
+	})
+
+	return hints
+}
+
+// completeLinkHint return a copy of the hint of a link found by the tokenizer,
+// with the content type guessed from the link once it has been made absolute
+func completeLinkHint(link *url.URL, typeAttr string, hint *frontier.LinkHints) *frontier.LinkHints {
+	if hint == nil {
+		return nil
+	}
+
+	completed := *hint
+	completed.ContentType = guessContentType(link, typeAttr)
+
+	return &completed
+}
+
+// mergeLinkHint add the hint of a link to the hints, the same URL can be
+// linked multiple times, the first non-empty values are kept
+func mergeLinkHint(hints map[string]*frontier.LinkHints, key string, hint *frontier.LinkHints) {
+	existing, exists := hints[key]
+	if !exists {
+		hints[key] = hint
+		return
+	}
+
+	if existing.ContentType == "" {
+		existing.ContentType = hint.ContentType
+	}
+
+	if existing.AnchorText == "" {
+		existing.AnchorText = hint.AnchorText
+	}
+
+	if existing.Heading == "" {
+		existing.Heading = hint.Heading
+	}
+
+	if existing.Rel == "" {
+		existing.Rel = hint.Rel
+	}
+}
+
+// guessContentType return the media type announced by the type attribute of the
+// link, or the one associated with the extension of its path if there is none
+func guessContentType(link *url.URL, typeAttr string) string {
+	if typeAttr == "" {
+		typeAttr = mime.TypeByExtension(path.Ext(link.Path))
+	}
+
+	mediaType, _, err := mime.ParseMediaType(typeAttr)
+	if err != nil {
+		return ""
+	}
+
+	return mediaType
+}
+
+// getAnchorText return the text of the link with its whitespaces collapsed,
+// or the alternative text of its image or its title if it has no text
+func getAnchorText(item *goquery.Selection) (text string) {
+	text = strings.Join(strings.Fields(item.Text()), " ")
+
+	if text == "" {
+		text, _ = item.Find("img[alt]").First().Attr("alt")
+	}
+
+	if text == "" {
+		text, _ = item.Attr("title")
+	}
+
+	return truncateUTF8(strings.TrimSpace(text), maxAnchorTextLength)
+}
+
+// truncateUTF8 truncate the string to at most size bytes without cutting a rune
+func truncateUTF8(s string, size int) string {
+	if len(s) <= size {
+		return s
+	}
+
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+
+	return s[:size]
+}
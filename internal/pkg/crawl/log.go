@@ -52,6 +52,10 @@ func (c *Crawl) genLogFields(err interface{}, URL interface{}, additionalFields
 		fields["job"] = c.Job
 	}
 
+	if c.JobID != "" {
+		fields["jobId"] = c.JobID
+	}
+
 	switch errValue := err.(type) {
 	case error:
 		fields["err"] = errValue.Error()
@@ -62,12 +66,31 @@ func (c *Crawl) genLogFields(err interface{}, URL interface{}, additionalFields
 	}
 
 	switch URLValue := URL.(type) {
+	case *frontier.Item:
+		fields["url"] = utils.URLToString(URLValue.URL)
+
+		if utils.IsIDN(URLValue.URL) {
+			fields["unicodeUrl"] = utils.URLToUnicodeString(URLValue.URL)
+		}
+
+		// The capture ID allows to find the WARC records and the report lines of the capture
+		if URLValue.CaptureID != "" {
+			fields["captureId"] = URLValue.CaptureID
+		}
 	case string:
 		fields["url"] = URLValue
 	case *url.URL:
 		fields["url"] = utils.URLToString(URLValue)
+
+		if utils.IsIDN(URLValue) {
+			fields["unicodeUrl"] = utils.URLToUnicodeString(URLValue)
+		}
 	case url.URL:
 		fields["url"] = utils.URLToString(&URLValue)
+
+		if utils.IsIDN(&URLValue) {
+			fields["unicodeUrl"] = utils.URLToUnicodeString(&URLValue)
+		}
 	default:
 	}
 
@@ -79,13 +102,22 @@ func (c *Crawl) genLogFields(err interface{}, URL interface{}, additionalFields
 }
 
 func (c *Crawl) logCrawlSuccess(executionStart time.Time, statusCode int, item *frontier.Item) {
-	fields := c.genLogFields(nil, item.URL, nil)
+	// The assets are logged once captured, see logAssetSuccess
+	if item.Type == "asset" || !c.shouldLog(logrus.InfoLevel) {
+		return
+	}
+
+	fields := c.genLogFields(nil, item, nil)
 
 	fields["statusCode"] = statusCode
 	fields["hop"] = item.Hop
 	fields["type"] = item.Type
 	fields["executionTime"] = time.Since(executionStart).Milliseconds()
-	fields["url"] = utils.URLToString(item.URL)
+
+	// With --nofollow-policy flag, the URLs discovered through a nofollow link are flagged
+	if c.NofollowPolicy == "flag" && item.Hints != nil && isNofollowRel(item.Hints.Rel) {
+		fields["nofollow"] = item.Hints.Rel
+	}
 
 	logInfo.WithFields(fields).Info("URL archived")
 }
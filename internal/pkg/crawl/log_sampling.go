@@ -0,0 +1,78 @@
+package crawl
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// LogSampler decides which of the high-rate log entries are actually written.
+// For each level, 1 entry out of N is written, the others are counted and
+// periodically reported as a single aggregated log line.
+type LogSampler struct {
+	Interval   time.Duration
+	rates      [logrus.TraceLevel + 1]uint64
+	counts     [logrus.TraceLevel + 1]uint64
+	suppressed [logrus.TraceLevel + 1]uint64
+}
+
+// NewLogSampler create a sampler writing 1 entry out of infoRate, warningRate
+// and errorRate for the corresponding levels, a rate of 0 or 1 disables the sampling
+func NewLogSampler(infoRate, warningRate, errorRate int, interval time.Duration) *LogSampler {
+	sampler := &LogSampler{Interval: interval}
+
+	if sampler.Interval <= 0 {
+		sampler.Interval = time.Minute
+	}
+
+	sampler.rates[logrus.InfoLevel] = uint64(infoRate)
+	sampler.rates[logrus.WarnLevel] = uint64(warningRate)
+	sampler.rates[logrus.ErrorLevel] = uint64(errorRate)
+
+	return sampler
+}
+
+// Sample return true if the entry should be written
+func (s *LogSampler) Sample(level logrus.Level) bool {
+	if s == nil || int(level) >= len(s.rates) || s.rates[level] <= 1 {
+		return true
+	}
+
+	if (atomic.AddUint64(&s.counts[level], 1)-1)%s.rates[level] == 0 {
+		return true
+	}
+
+	atomic.AddUint64(&s.suppressed[level], 1)
+
+	return false
+}
+
+func (c *Crawl) shouldLog(level logrus.Level) bool {
+	return c.LogSampler.Sample(level)
+}
+
+// logSamplingAggregates periodically write how many log entries
+// have been suppressed by the sampling since the last aggregate line
+func (c *Crawl) logSamplingAggregates() {
+	for {
+		time.Sleep(c.LogSampler.Interval)
+
+		var (
+			info    = atomic.SwapUint64(&c.LogSampler.suppressed[logrus.InfoLevel], 0)
+			warning = atomic.SwapUint64(&c.LogSampler.suppressed[logrus.WarnLevel], 0)
+			errs    = atomic.SwapUint64(&c.LogSampler.suppressed[logrus.ErrorLevel], 0)
+		)
+
+		if info+warning+errs == 0 {
+			continue
+		}
+
+		logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+			"suppressedInfo":    info,
+			"suppressedWarning": warning,
+			"suppressedError":   errs,
+			"interval":          c.LogSampler.Interval.String(),
+		})).Info("log entries suppressed by sampling")
+	}
+}
@@ -0,0 +1,139 @@
+package crawl
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// MediaLane isolates the capture of the URLs matching --media-url-pattern, typically
+// heavy media files, from the rest of the crawl: the items are handed over by the
+// workers to the dedicated --media-workers, and the responses of the matching URLs,
+// assets included, share the --media-max-bandwidth limit
+type MediaLane struct {
+	Patterns  []*regexp.Regexp
+	Workers   int
+	Bandwidth *BandwidthLimiter
+	items     chan *frontier.Item
+	waitGroup sync.WaitGroup
+}
+
+// Match return true if the URL matches one of the patterns of the lane
+func (lane *MediaLane) Match(URL *url.URL) bool {
+	rawURL := utils.URLToString(URL)
+
+	for _, pattern := range lane.Patterns {
+		if pattern.MatchString(rawURL) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// startMediaLane start the media workers, they stop once the lane is closed
+func (c *Crawl) startMediaLane() {
+	c.MediaLane.items = make(chan *frontier.Item, c.MediaLane.Workers)
+
+	for i := 0; i < c.MediaLane.Workers; i++ {
+		c.MediaLane.waitGroup.Add(1)
+
+		go func() {
+			defer c.MediaLane.waitGroup.Done()
+
+			// The items have been counted as active when they were handed over
+			for item := range c.MediaLane.items {
+				c.Capture(item)
+				c.ActiveWorkers.Incr(-1)
+			}
+		}()
+	}
+}
+
+// closeMediaLane wait for the media workers to capture the items handed over to them,
+// it must be called once the workers stopped
+func (c *Crawl) closeMediaLane() {
+	close(c.MediaLane.items)
+	c.MediaLane.waitGroup.Wait()
+}
+
+// handOverToMediaLane give the item to the media workers if it matches the lane, it
+// return false if the item must be captured by the worker itself. The worker waits for
+// a media worker to be available, unless it is asked to stop.
+func (c *Crawl) handOverToMediaLane(item *frontier.Item, stop chan struct{}) bool {
+	if c.MediaLane == nil || !c.MediaLane.Match(item.URL) {
+		return false
+	}
+
+	// The item is counted as active until it is captured, so
+	// that the crawl doesn't finish while the lane is busy
+	c.ActiveWorkers.Incr(1)
+
+	select {
+	case c.MediaLane.items <- item:
+		return true
+	case <-stop:
+		c.ActiveWorkers.Incr(-1)
+		return false
+	}
+}
+
+// BandwidthLimiter spread the reads of the bodies sharing it so that they
+// don't exceed the given number of bytes per second in total
+type BandwidthLimiter struct {
+	sync.Mutex
+	bytesPerSecond float64
+	next           time.Time
+}
+
+// NewBandwidthLimiter return a limiter allowing the given number of bytes per second
+func NewBandwidthLimiter(bytesPerSecond int64) *BandwidthLimiter {
+	return &BandwidthLimiter{bytesPerSecond: float64(bytesPerSecond)}
+}
+
+// wait block until n more bytes can be read without exceeding the bandwidth
+func (limiter *BandwidthLimiter) wait(n int) {
+	limiter.Lock()
+
+	now := time.Now()
+	if limiter.next.Before(now) {
+		limiter.next = now
+	}
+
+	delay := limiter.next.Sub(now)
+	limiter.next = limiter.next.Add(time.Duration(float64(n) / limiter.bytesPerSecond * float64(time.Second)))
+
+	limiter.Unlock()
+
+	time.Sleep(delay)
+}
+
+// wrapMediaBandwidthBody limit the bandwidth used to read the body of the responses
+// of the URLs matching the media lane, when --media-max-bandwidth is set
+func (c *Crawl) wrapMediaBandwidthBody(item *frontier.Item, resp *http.Response) {
+	if c.MediaLane == nil || c.MediaLane.Bandwidth == nil || !c.MediaLane.Match(item.URL) {
+		return
+	}
+
+	resp.Body = &limitedBody{ReadCloser: resp.Body, limiter: c.MediaLane.Bandwidth}
+}
+
+type limitedBody struct {
+	io.ReadCloser
+	limiter *BandwidthLimiter
+}
+
+func (b *limitedBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	if n > 0 {
+		b.limiter.wait(n)
+	}
+
+	return n, err
+}
@@ -0,0 +1,38 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// upgradeMixedContent rewrite the http:// assets of an https:// page to https://, like
+// browsers do with mixed content, so that the replay of the page finds the resources
+// it will ask for. It returns the original URLs keyed by the upgraded ones, the
+// assets falling back to their original URL when the upgraded one can't be captured.
+func (c *Crawl) upgradeMixedContent(item *frontier.Item, assets []*url.URL) (originals map[string]*url.URL) {
+	if !c.UpgradeMixedContent || item.URL.Scheme != "https" {
+		return nil
+	}
+
+	originals = make(map[string]*url.URL)
+
+	for _, asset := range assets {
+		if asset.Scheme != "http" {
+			continue
+		}
+
+		original := *asset
+
+		asset.Scheme = "https"
+		if asset.Port() == "80" {
+			asset.Host = strings.TrimSuffix(asset.Host, ":80")
+		}
+
+		originals[utils.URLToString(asset)] = &original
+	}
+
+	return originals
+}
@@ -0,0 +1,39 @@
+package crawl
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpgradeMixedContent(t *testing.T) {
+	c := &Crawl{UpgradeMixedContent: true}
+
+	page, err := url.Parse("https://example.com/")
+	assert.NoError(t, err)
+
+	item := frontier.NewItem(page, nil, "seed", 0, "", false)
+
+	var assets []*url.URL
+	for _, rawURL := range []string{"http://cdn.example.com:80/style.css", "https://example.com/app.js", "http://other.org:8080/img.png"} {
+		asset, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+
+		assets = append(assets, asset)
+	}
+
+	originals := c.upgradeMixedContent(item, assets)
+
+	assert.Equal(t, "https://cdn.example.com/style.css", assets[0].String())
+	assert.Equal(t, "https://example.com/app.js", assets[1].String())
+	assert.Equal(t, "https://other.org:8080/img.png", assets[2].String())
+
+	assert.Len(t, originals, 2)
+	assert.Equal(t, "http://cdn.example.com:80/style.css", originals["https://cdn.example.com/style.css"].String())
+
+	// The assets of an http:// page are left as they are
+	page.Scheme = "http"
+	assert.Nil(t, c.upgradeMixedContent(item, []*url.URL{originals["https://cdn.example.com/style.css"]}))
+}
@@ -0,0 +1,67 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// isMobileVersionLink return true if a <link> tag points to the AMP version of the
+// page (rel="amphtml") or to its mobile version (rel="alternate" with a media query)
+func isMobileVersionLink(rel string, media string) bool {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		if value == "amphtml" || (value == "alternate" && media != "") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// extractMobileVersions extract the URLs of the AMP and mobile versions of the page
+func (c *Crawl) extractMobileVersions(base *url.URL, doc *goquery.Document) []*url.URL {
+	var rawVersions []string
+
+	doc.Find("link[rel]").Each(func(index int, item *goquery.Selection) {
+		relation, _ := item.Attr("rel")
+		media, _ := item.Attr("media")
+
+		if !isMobileVersionLink(relation, media) {
+			return
+		}
+
+		if link, exists := item.Attr("href"); exists {
+			rawVersions = append(rawVersions, link)
+		}
+	})
+
+	versions := utils.StringSliceToURLSlice(c.skipUnfetchableLinks(rawVersions))
+
+	return utils.DedupeURLs(utils.MakeAbsolute(base, versions))
+}
+
+// queueMobileVersions queue the AMP and mobile versions of the item at the same hop,
+// so that they are captured with their own assets alongside the canonical page
+func (c *Crawl) queueMobileVersions(versions []*url.URL, item *frontier.Item, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	var recorded []*url.URL
+
+	for _, version := range versions {
+		c.normalizeURL(version)
+
+		if !c.isOutlinkAllowed(item, version) {
+			continue
+		}
+
+		recorded = append(recorded, version)
+
+		c.queueItem(frontier.NewItem(version, item, "seed", item.Hop, "", false))
+	}
+
+	c.recordLinks(item, recorded, nil, "mobile")
+}
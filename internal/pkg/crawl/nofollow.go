@@ -0,0 +1,70 @@
+package crawl
+
+import (
+	"strings"
+
+	"github.com/paulbellamy/ratecounter"
+)
+
+// nofollowRels are the rel values telling that the site doesn't vouch for the link
+var nofollowRels = []string{"nofollow", "ugc", "sponsored"}
+
+// NofollowLinks counts the outlinks marked with one of the nofollow rel values, per value
+type NofollowLinks map[string]*ratecounter.Counter
+
+// NewNofollowLinks create the counters of the nofollow links for every rel value
+func NewNofollowLinks() NofollowLinks {
+	nofollowLinks := make(NofollowLinks)
+
+	for _, rel := range nofollowRels {
+		nofollowLinks[rel] = new(ratecounter.Counter)
+	}
+
+	return nofollowLinks
+}
+
+// Values return the number of nofollow links for every rel value
+func (nofollowLinks NofollowLinks) Values() map[string]int64 {
+	values := make(map[string]int64)
+
+	for rel, counter := range nofollowLinks {
+		values[rel] = counter.Value()
+	}
+
+	return values
+}
+
+// count increment the counters of the nofollow values of the rel attribute,
+// a link can hold several of them (e.g. "ugc nofollow")
+func (nofollowLinks NofollowLinks) count(rel string) {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		if counter, exists := nofollowLinks[value]; exists {
+			counter.Incr(1)
+		}
+	}
+}
+
+// isNofollowRel return true if the rel attribute of the link holds one of the nofollow values
+func isNofollowRel(rel string) bool {
+	for _, value := range strings.Fields(strings.ToLower(rel)) {
+		for _, nofollowRel := range nofollowRels {
+			if value == nofollowRel {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// skipNofollowLink count the link if it is marked with one of the nofollow
+// values and return true if it must be skipped according to --nofollow-policy
+func (c *Crawl) skipNofollowLink(rel string) bool {
+	if !isNofollowRel(rel) {
+		return false
+	}
+
+	c.NofollowLinks.count(rel)
+
+	return c.NofollowPolicy == "skip"
+}
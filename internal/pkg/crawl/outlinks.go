@@ -11,7 +11,7 @@ import (
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
 )
 
-func extractOutlinks(base *url.URL, doc *goquery.Document) (outlinks []*url.URL, err error) {
+func (c *Crawl) extractOutlinks(base *url.URL, doc *goquery.Document) (outlinks []*url.URL, err error) {
 	var rawOutlinks []string
 
 	// Extract outlinks
@@ -39,8 +39,12 @@ func extractOutlinks(base *url.URL, doc *goquery.Document) (outlinks []*url.URL,
 		fmt.Println(item.Text())
 	})
 
+	// Extract the outlinks from the structured data (JSON-LD and microdata)
+	structuredDataOutlinks, _ := extractStructuredData(doc)
+	rawOutlinks = append(rawOutlinks, structuredDataOutlinks...)
+
 	// Turn strings into url.URL
-	outlinks = utils.StringSliceToURLSlice(rawOutlinks)
+	outlinks = utils.StringSliceToURLSlice(c.skipUnfetchableLinks(rawOutlinks))
 
 	// Extract all text on the page and extract the outlinks from it
 	textOutlinks := extractLinksFromText(doc.Find("body").RemoveFiltered("script").Text())
@@ -55,48 +59,88 @@ func extractOutlinks(base *url.URL, doc *goquery.Document) (outlinks []*url.URL,
 	return utils.DedupeURLs(outlinks), nil
 }
 
-func (c *Crawl) queueOutlinks(outlinks []*url.URL, item *frontier.Item, wg *sync.WaitGroup) {
+// queueOutlinks send the outlinks to the pool of workers, the hints of the links
+// (as returned by extractLinkHints) are attached to the items if given
+func (c *Crawl) queueOutlinks(outlinks []*url.URL, hints map[string]*frontier.LinkHints, item *frontier.Item, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	var excluded bool
+	// The outlinks are recorded in the link graph as they are queued, normalized and filtered
+	var (
+		recorded      []*url.URL
+		recordedHints = make(map[string]*frontier.LinkHints)
+	)
 
 	// Send the outlinks to the pool of workers
 	for _, outlink := range outlinks {
 		outlink := outlink
 
-		// If the host of the outlink is in the host exclusion list, or the host is not in the host inclusion list
-		// if one is specified, we ignore the outlink
-		if utils.StringInSlice(outlink.Host, c.ExcludedHosts) || !c.checkIncludedHosts(outlink.Host) {
+		// The hints are keyed by the URL as it has been extracted
+		hint := hints[utils.URLToString(outlink)]
+
+		if hint != nil && c.skipNofollowLink(hint.Rel) {
 			continue
 		}
 
-		// If the outlink match any excluded string, we ignore it
-		for _, excludedString := range c.ExcludedStrings {
-			if strings.Contains(utils.URLToString(outlink), excludedString) {
-				excluded = true
-				break
-			}
+		c.normalizeURL(outlink)
+
+		// With --resolve-shorteners, the links to a URL shortener are replaced by their destination
+		if c.ResolveShorteners && frontier.IsShortener(outlink) {
+			outlink = c.expandShortener(item, outlink)
 		}
 
-		if excluded {
-			excluded = false
+		if !c.isOutlinkAllowed(item, outlink) {
 			continue
 		}
 
+		var outlinkItem *frontier.Item
+
 		if c.DomainsCrawl && strings.Contains(item.Host, outlink.Host) && item.Hop == 0 {
-			newItem := frontier.NewItem(outlink, item, "seed", 0, "", false)
-			if c.UseHQ {
-				c.HQProducerChannel <- newItem
-			} else {
-				c.Frontier.PushChan <- newItem
-			}
+			outlinkItem = frontier.NewItem(outlink, item, "seed", 0, "", false)
 		} else if c.MaxHops >= item.Hop+1 {
-			newItem := frontier.NewItem(outlink, item, "seed", item.Hop+1, "", false)
-			if c.UseHQ {
-				c.HQProducerChannel <- newItem
-			} else {
-				c.Frontier.PushChan <- newItem
-			}
+			outlinkItem = frontier.NewItem(outlink, item, "seed", item.Hop+1, "", false)
+		} else {
+			continue
+		}
+
+		recorded = append(recorded, outlink)
+		if hint != nil {
+			recordedHints[utils.URLToString(outlink)] = hint
+		}
+
+		// The bursts of identical outlinks found on many pages are collapsed
+		if c.RecentOutlinks != nil && c.RecentOutlinks.seen(outlinkItem.Hash) {
+			c.CollapsedOutlinks.Incr(1)
+			continue
+		}
+
+		outlinkItem.Hints = hint
+
+		c.queueItem(outlinkItem)
+	}
+
+	c.recordLinks(item, recorded, recordedHints, "outlink")
+}
+
+// isOutlinkAllowed return false if the outlink of the item is excluded from the crawl
+func (c *Crawl) isOutlinkAllowed(item *frontier.Item, outlink *url.URL) bool {
+	// The scope of the seed of the item overrides the global scope
+	if scope := c.getItemScope(item); scope != nil {
+		return scope.Match(outlink) && c.isURLWithinLimits(outlink)
+	}
+
+	// If the host of the outlink is in the host exclusion list, or the host is not in the host inclusion list
+	// if one is specified, we ignore the outlink
+	if utils.StringInSlice(outlink.Host, c.getExcludedHosts()) || !c.checkIncludedHosts(outlink.Host) {
+		return false
+	}
+
+	// If the outlink match any excluded string, we ignore it
+	for _, excludedString := range c.getExcludedStrings() {
+		if strings.Contains(utils.URLToString(outlink), excludedString) {
+			return false
 		}
 	}
+
+	// Pathological URLs are dropped
+	return c.isURLWithinLimits(outlink)
 }
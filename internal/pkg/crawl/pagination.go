@@ -0,0 +1,146 @@
+package crawl
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// paginationParams are the query parameters commonly holding the page number of a listing
+var paginationParams = []string{"page", "p", "pg", "paged", "pagenum"}
+
+var regexPageNumber = regexp.MustCompile(`^[0-9]{1,5}$`)
+
+// extractPaginationLinks extract the links to the next and previous pages of a listing:
+// the rel="next" and rel="prev" links, and the links to the same path where only the
+// page number in the query string changes (?page=N)
+func (c *Crawl) extractPaginationLinks(base *url.URL, doc *goquery.Document) (links []*url.URL) {
+	var rawLinks []string
+
+	doc.Find("a[rel], link[rel]").Each(func(index int, item *goquery.Selection) {
+		relation, _ := item.Attr("rel")
+
+		for _, value := range strings.Fields(strings.ToLower(relation)) {
+			if value == "next" || value == "prev" || value == "previous" {
+				if link, exists := item.Attr("href"); exists {
+					rawLinks = append(rawLinks, link)
+				}
+				break
+			}
+		}
+	})
+
+	doc.Find("a[href]").Each(func(index int, item *goquery.Selection) {
+		link, _ := item.Attr("href")
+
+		URL, err := base.Parse(link)
+		if err == nil && isPaginationOf(base, URL) {
+			rawLinks = append(rawLinks, link)
+		}
+	})
+
+	links = utils.StringSliceToURLSlice(c.skipUnfetchableLinks(rawLinks))
+	links = utils.MakeAbsolute(base, links)
+
+	return utils.DedupeURLs(utils.RemoveFragments(links))
+}
+
+// paginationFromOutlinks does what extractPaginationLinks does for the documents scraped
+// with the streaming tokenizer, using the rel values kept in the hints of the outlinks
+func paginationFromOutlinks(base *url.URL, outlinks []*url.URL, hints map[string]*frontier.LinkHints) (links []*url.URL) {
+	for _, outlink := range outlinks {
+		if isPaginationOf(base, outlink) {
+			links = append(links, outlink)
+			continue
+		}
+
+		hint, found := hints[utils.URLToString(outlink)]
+		if !found {
+			continue
+		}
+
+		for _, value := range strings.Fields(hint.Rel) {
+			if value == "next" || value == "prev" || value == "previous" {
+				links = append(links, outlink)
+				break
+			}
+		}
+	}
+
+	return links
+}
+
+// isPaginationOf return true if the URL is the same as the page, except for the page number
+func isPaginationOf(page *url.URL, URL *url.URL) bool {
+	if URL.Host != page.Host || URL.Path != page.Path {
+		return false
+	}
+
+	pageQuery, URLQuery := page.Query(), URL.Query()
+
+	for _, param := range paginationParams {
+		pageNumber := URLQuery.Get(param)
+		if !regexPageNumber.MatchString(pageNumber) || pageNumber == pageQuery.Get(param) {
+			continue
+		}
+
+		// Every other parameter has to be the same
+		pageQuery.Del(param)
+		URLQuery.Del(param)
+
+		return pageQuery.Encode() == URLQuery.Encode()
+	}
+
+	return false
+}
+
+// removePaginationLinks remove the pagination links from the outlinks, they are queued at the same hop instead
+func removePaginationLinks(outlinks []*url.URL, pagination []*url.URL) (output []*url.URL) {
+	paginationSet := make(map[string]bool, len(pagination))
+	for _, link := range pagination {
+		paginationSet[utils.URLToString(link)] = true
+	}
+
+	for _, outlink := range outlinks {
+		if !paginationSet[utils.URLToString(outlink)] {
+			output = append(output, outlink)
+		}
+	}
+
+	return output
+}
+
+// queuePaginationLinks queue the pagination links of the item at the same hop, a
+// listing is followed up to --pagination-depth pages away from its first page
+func (c *Crawl) queuePaginationLinks(links []*url.URL, item *frontier.Item, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	var recorded []*url.URL
+
+	for _, link := range links {
+		c.normalizeURL(link)
+
+		if !c.isOutlinkAllowed(item, link) {
+			continue
+		}
+
+		recorded = append(recorded, link)
+
+		newItem := frontier.NewItem(link, item, "seed", item.Hop, "", false)
+		newItem.Pagination = item.Pagination + 1
+
+		c.queueItem(newItem)
+	}
+
+	c.recordLinks(item, recorded, nil, "pagination")
+}
+
+// shouldFollowPagination return true if the pagination links of the item have to be followed
+func (c *Crawl) shouldFollowPagination(item *frontier.Item) bool {
+	return c.PaginationDepth > 0 && item.Pagination < c.PaginationDepth && item.Type == "seed"
+}
@@ -0,0 +1,84 @@
+package crawl
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// errPriorityLaneFull is returned when the urgent items can't be accepted
+// because the lane is full or closed, the caller can retry later
+var errPriorityLaneFull = errors.New("priority lane full or closed")
+
+// priorityLaneSize is the number of urgent items that can wait for a priority worker
+const priorityLaneSize = 1000
+
+// PriorityLane captures the urgent seeds, submitted through the API or marked with
+// priority=urgent in the queue backend, with the dedicated --priority-workers: they
+// bypass the queue and the workers busy with the bulk crawl, so that they are captured
+// within seconds. Their outlinks are queued normally.
+type PriorityLane struct {
+	sync.Mutex
+	Workers   int
+	items     chan *frontier.Item
+	closed    bool
+	waitGroup sync.WaitGroup
+}
+
+// startPriorityLane start the priority workers, they stop once the lane is closed
+func (c *Crawl) startPriorityLane() {
+	c.PriorityLane.items = make(chan *frontier.Item, priorityLaneSize)
+
+	for i := 0; i < c.PriorityLane.Workers; i++ {
+		c.PriorityLane.waitGroup.Add(1)
+
+		go func() {
+			defer c.PriorityLane.waitGroup.Done()
+
+			// The items have been counted as active when they were submitted
+			for item := range c.PriorityLane.items {
+				c.Capture(item)
+				c.ActiveWorkers.Incr(-1)
+			}
+		}()
+	}
+}
+
+// closePriorityLane stop accepting urgent items, and wait for the priority workers to capture
+// the ones already submitted. It must be called once the queue backend stopped delivering items.
+func (c *Crawl) closePriorityLane() {
+	c.PriorityLane.Lock()
+	c.PriorityLane.closed = true
+	close(c.PriorityLane.items)
+	c.PriorityLane.Unlock()
+
+	c.PriorityLane.waitGroup.Wait()
+}
+
+// submitUrgentItem give the item to the priority workers without waiting,
+// it return errPriorityLaneFull if the lane can't take it now
+func (c *Crawl) submitUrgentItem(item *frontier.Item) error {
+	if c.PriorityLane == nil {
+		return errPriorityLaneFull
+	}
+
+	c.PriorityLane.Lock()
+	defer c.PriorityLane.Unlock()
+
+	if c.PriorityLane.closed {
+		return errPriorityLaneFull
+	}
+
+	// The item is counted as active until it is captured, so
+	// that the crawl doesn't finish while the lane is busy
+	c.ActiveWorkers.Incr(1)
+
+	select {
+	case c.PriorityLane.items <- item:
+		return nil
+	default:
+		c.ActiveWorkers.Incr(-1)
+		return errPriorityLaneFull
+	}
+}
@@ -0,0 +1,112 @@
+package crawl
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// QueueBackend is a remote queue shared by multiple crawlers: the items to crawl
+// are consumed from it, and the discovered items are produced to it instead of
+// being pushed to the local frontier
+type QueueBackend interface {
+	// Consume return the channel the items to crawl are received on, it is
+	// closed when the backend is closed
+	Consume() <-chan *frontier.Item
+	Produce(item *frontier.Item) error
+	Close() error
+}
+
+// QueueBackendAcknowledger is implemented by the queue backends that
+// need to be told when an item they delivered has been crawled
+type QueueBackendAcknowledger interface {
+	Ack(item *frontier.Item) error
+}
+
+// QueueBackendConstructor create a queue backend from the settings of the crawl
+type QueueBackendConstructor func(c *Crawl) (QueueBackend, error)
+
+var queueBackends = make(map[string]QueueBackendConstructor)
+
+// RegisterQueueBackend make a queue backend available under the given name for --queue-backend
+func RegisterQueueBackend(name string, constructor QueueBackendConstructor) {
+	queueBackends[name] = constructor
+}
+
+// QueueBackends return the names of the registered queue backends
+func QueueBackends() (names []string) {
+	for name := range queueBackends {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func (c *Crawl) initQueueBackend() (err error) {
+	constructor, exists := queueBackends[c.QueueBackendName]
+	if !exists {
+		return fmt.Errorf("unknown queue backend: %s", c.QueueBackendName)
+	}
+
+	c.QueueBackend, err = constructor(c)
+
+	return err
+}
+
+// consumeQueueBackend push the items received from the queue backend to the local frontier,
+// the backends deliver no more items than there are workers until they are acknowledged
+func (c *Crawl) consumeQueueBackend() {
+	defer c.QueueBackendWg.Done()
+
+	for item := range c.QueueBackend.Consume() {
+		// The urgent items bypass the local queue, they go to the
+		// local queue anyway if the priority lane is full
+		if item.Urgent && c.submitUrgentItem(item) == nil {
+			continue
+		}
+
+		c.Frontier.PushChan <- item
+	}
+}
+
+// queueItem send a discovered item to crawl HQ or to the queue backend,
+// or push it to the local frontier if none is used
+func (c *Crawl) queueItem(item *frontier.Item) {
+	switch {
+	case c.UseHQ:
+		c.HQProducerChannel <- item
+	case c.QueueBackend != nil:
+		err := c.QueueBackend.Produce(item)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("unable to produce item to the queue backend, pushing it to the local queue")
+			c.Frontier.PushChan <- item
+		}
+	default:
+		c.Frontier.PushChan <- item
+	}
+}
+
+// markItemDone tell crawl HQ or the queue backend that the item they delivered has been processed
+func (c *Crawl) markItemDone(item *frontier.Item) {
+	if item.ID == "" {
+		return
+	}
+
+	switch {
+	case c.UseHQ:
+		c.HQFinishedChannel <- item
+	case c.QueueBackend != nil:
+		acknowledger, ok := c.QueueBackend.(QueueBackendAcknowledger)
+		if !ok {
+			return
+		}
+
+		err := acknowledger.Ack(item)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, item, nil)).Error("unable to acknowledge item to the queue backend")
+		}
+	}
+}
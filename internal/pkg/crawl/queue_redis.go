@@ -0,0 +1,409 @@
+package crawl
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+func init() {
+	RegisterQueueBackend("redis", newRedisStreamsBackend)
+}
+
+// redisStreamsBackend is a queue backend on a Redis stream: the crawlers are the
+// consumers of a consumer group, an entry is acknowledged and deleted from the stream
+// once its URL has been crawled, and the entries left pending by a crawler that died
+// are claimed by the others after --redis-claim-idle. A crawler holds at most as many
+// entries as it has workers, and keeps claiming its own entries so that the ones
+// waiting in its local queue aren't claimed by another crawler.
+type redisStreamsBackend struct {
+	crawl     *Crawl
+	pool      *redis.Pool
+	stream    string
+	group     string
+	consumer  string
+	claimIdle time.Duration
+	items     chan *frontier.Item
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// pending are the IDs of the entries delivered to this crawler and not yet
+	// acknowledged, released is signaled when one of them is acknowledged
+	pendingMutex sync.Mutex
+	pending      map[string]struct{}
+	released     chan struct{}
+}
+
+func newRedisStreamsBackend(c *Crawl) (QueueBackend, error) {
+	backend := &redisStreamsBackend{
+		crawl: c,
+		pool: &redis.Pool{
+			MaxIdle:     4,
+			IdleTimeout: time.Minute,
+			Dial: func() (redis.Conn, error) {
+				return redis.DialURL(c.RedisURL)
+			},
+		},
+		stream:    c.RedisStream,
+		group:     c.RedisGroup,
+		consumer:  utils.GetHostname() + "-" + c.JobID,
+		claimIdle: time.Duration(c.RedisClaimIdle) * time.Second,
+		items:     make(chan *frontier.Item),
+		done:      make(chan struct{}),
+		pending:   make(map[string]struct{}),
+		released:  make(chan struct{}, 1),
+	}
+
+	conn := backend.pool.Get()
+	defer conn.Close()
+
+	// The group starts at the beginning of the stream so that the entries
+	// added before the first crawler started are consumed too
+	_, err := conn.Do("XGROUP", "CREATE", backend.stream, backend.group, "0", "MKSTREAM")
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, err
+	}
+
+	go backend.consume()
+
+	return backend, nil
+}
+
+func (backend *redisStreamsBackend) Consume() <-chan *frontier.Item {
+	return backend.items
+}
+
+func (backend *redisStreamsBackend) Produce(item *frontier.Item) error {
+	conn := backend.pool.Get()
+	defer conn.Close()
+
+	args := redis.Args{backend.stream, "*",
+		"url", utils.URLToString(item.URL),
+		"hop", item.Hop,
+		"type", item.Type,
+	}
+
+	if item.ParentItem != nil {
+		args = args.Add("via", utils.URLToString(item.ParentItem.URL))
+	}
+
+	if item.Scope != "" {
+		args = args.Add("scope", item.Scope)
+	}
+
+	if item.Collection != "" {
+		args = args.Add("collection", item.Collection)
+	}
+
+	if !item.NotAfter.IsZero() {
+		args = args.Add("not_after", item.NotAfter.UTC().Format(time.RFC3339))
+	}
+
+	if item.Urgent {
+		args = args.Add("priority", "urgent")
+	}
+
+	if item.TimeGateChecked {
+		args = args.Add("timegate", "checked")
+	}
+
+	// The hints let the prioritizers reading the stream rank the URLs without fetching them
+	if item.Hints != nil {
+		args = args.Add("content_type", item.Hints.ContentType, "anchor_text", item.Hints.AnchorText,
+			"heading", item.Hints.Heading, "rel", item.Hints.Rel)
+	}
+
+	_, err := conn.Do("XADD", args...)
+
+	return err
+}
+
+// Ack acknowledge the entry of a crawled item, it won't be delivered again. The entry is
+// then deleted from the stream, otherwise the stream would keep every URL ever queued.
+func (backend *redisStreamsBackend) Ack(item *frontier.Item) error {
+	// The slot of the entry is released even if it can't be acknowledged,
+	// the entry is then claimed again by a crawler after --redis-claim-idle
+	defer backend.untrack(item.ID)
+
+	conn := backend.pool.Get()
+	defer conn.Close()
+
+	_, err := conn.Do("XACK", backend.stream, backend.group, item.ID)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Do("XDEL", backend.stream, item.ID)
+
+	return err
+}
+
+// track add an entry to the pending entries of this crawler, it return
+// false if the entry has already been delivered and is still pending
+func (backend *redisStreamsBackend) track(ID string) bool {
+	backend.pendingMutex.Lock()
+	defer backend.pendingMutex.Unlock()
+
+	if _, exists := backend.pending[ID]; exists {
+		return false
+	}
+
+	backend.pending[ID] = struct{}{}
+
+	return true
+}
+
+func (backend *redisStreamsBackend) untrack(ID string) {
+	backend.pendingMutex.Lock()
+	_, exists := backend.pending[ID]
+	delete(backend.pending, ID)
+	backend.pendingMutex.Unlock()
+
+	if !exists {
+		return
+	}
+
+	select {
+	case backend.released <- struct{}{}:
+	default:
+	}
+}
+
+// pendingIDs return the IDs of the entries delivered to this crawler and not yet acknowledged
+func (backend *redisStreamsBackend) pendingIDs() (IDs []string) {
+	backend.pendingMutex.Lock()
+	defer backend.pendingMutex.Unlock()
+
+	for ID := range backend.pending {
+		IDs = append(IDs, ID)
+	}
+
+	return IDs
+}
+
+func (backend *redisStreamsBackend) Close() error {
+	backend.closeOnce.Do(func() {
+		close(backend.done)
+	})
+
+	return nil
+}
+
+func (backend *redisStreamsBackend) consume() {
+	defer close(backend.items)
+	defer backend.pool.Close()
+
+	var lastClaim, lastRefresh time.Time
+
+	for {
+		select {
+		case <-backend.done:
+			return
+		default:
+		}
+
+		// The entries waiting in the local queue are claimed again by this crawler
+		// before they reach --redis-claim-idle, so that they aren't crawled twice
+		if time.Since(lastRefresh) >= backend.claimIdle/2 {
+			err := backend.refresh()
+			if err != nil {
+				logError.WithFields(backend.crawl.genLogFields(err, nil, map[string]interface{}{
+					"stream": backend.stream,
+				})).Error("unable to refresh the pending entries of the Redis stream")
+			}
+			lastRefresh = time.Now()
+		}
+
+		// No more entries are read than there are workers to crawl them,
+		// the slots are released when the items are acknowledged
+		free := backend.crawl.getWorkersCount() - len(backend.pendingIDs())
+		if free <= 0 {
+			select {
+			case <-backend.released:
+			case <-time.After(time.Second):
+			case <-backend.done:
+				return
+			}
+
+			continue
+		}
+
+		var (
+			entries []interface{}
+			err     error
+		)
+
+		// Claim the entries left pending for too long by another consumer
+		if time.Since(lastClaim) >= backend.claimIdle/2 {
+			entries, err = backend.claim(free)
+			lastClaim = time.Now()
+		}
+
+		if err == nil && len(entries) == 0 {
+			entries, err = backend.read(free)
+		}
+
+		if err != nil {
+			logError.WithFields(backend.crawl.genLogFields(err, nil, map[string]interface{}{
+				"stream": backend.stream,
+			})).Error("unable to read from the Redis stream, retrying in 1s")
+			time.Sleep(time.Second)
+			continue
+		}
+
+		for _, entry := range entries {
+			item, err := parseRedisStreamEntry(entry)
+			if err != nil {
+				logWarning.WithFields(backend.crawl.genLogFields(err, nil, map[string]interface{}{
+					"stream": backend.stream,
+				})).Warn("invalid entry in the Redis stream, discarding")
+				continue
+			}
+
+			// XAUTOCLAIM also returns the entries of this crawler that became idle
+			if !backend.track(item.ID) {
+				continue
+			}
+
+			select {
+			case backend.items <- item:
+			case <-backend.done:
+				return
+			}
+		}
+	}
+}
+
+// read up to count new entries of the stream for this consumer, blocking for up to a second
+func (backend *redisStreamsBackend) read(count int) ([]interface{}, error) {
+	conn := backend.pool.Get()
+	defer conn.Close()
+
+	streams, err := redis.Values(conn.Do("XREADGROUP", "GROUP", backend.group, backend.consumer,
+		"COUNT", count, "BLOCK", 1000, "STREAMS", backend.stream, ">"))
+	if err == redis.ErrNil {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	// Only one stream is read: [[stream, [entries...]]]
+	stream, err := redis.Values(streams[0], nil)
+	if err != nil || len(stream) != 2 {
+		return nil, errors.New("unexpected XREADGROUP reply")
+	}
+
+	return redis.Values(stream[1], nil)
+}
+
+// claim up to count entries that have been pending for longer than --redis-claim-idle
+func (backend *redisStreamsBackend) claim(count int) ([]interface{}, error) {
+	conn := backend.pool.Get()
+	defer conn.Close()
+
+	// XAUTOCLAIM reply: [next start ID, [entries...], ...]
+	reply, err := redis.Values(conn.Do("XAUTOCLAIM", backend.stream, backend.group, backend.consumer,
+		backend.claimIdle.Milliseconds(), "0-0", "COUNT", count))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply) < 2 {
+		return nil, errors.New("unexpected XAUTOCLAIM reply")
+	}
+
+	return redis.Values(reply[1], nil)
+}
+
+// refresh reset the idle time of the entries pending for this consumer, by
+// claiming them again without incrementing their delivery counter
+func (backend *redisStreamsBackend) refresh() error {
+	IDs := backend.pendingIDs()
+	if len(IDs) == 0 {
+		return nil
+	}
+
+	conn := backend.pool.Get()
+	defer conn.Close()
+
+	args := redis.Args{backend.stream, backend.group, backend.consumer, 0}.AddFlat(IDs).Add("JUSTID")
+
+	_, err := conn.Do("XCLAIM", args...)
+
+	return err
+}
+
+// parseRedisStreamEntry turn an entry of the stream, [ID, [field, value...]], into an item
+func parseRedisStreamEntry(entry interface{}) (*frontier.Item, error) {
+	values, err := redis.Values(entry, nil)
+	if err != nil || len(values) != 2 {
+		return nil, errors.New("unexpected stream entry")
+	}
+
+	ID, err := redis.String(values[0], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries deleted while pending are claimed without their fields
+	fields, err := redis.StringMap(values[1], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	URL, err := url.Parse(fields["url"])
+	if err != nil || fields["url"] == "" {
+		return nil, errors.New("invalid URL in stream entry " + ID)
+	}
+
+	hop, _ := strconv.Atoi(fields["hop"])
+
+	itemType := fields["type"]
+	if itemType == "" {
+		itemType = "seed"
+	}
+
+	// The parent is only known by its URL, it's used for the Referer header
+	var parent *frontier.Item
+	if via, err := url.Parse(fields["via"]); err == nil && fields["via"] != "" {
+		parent = frontier.NewItem(via, nil, "seed", uint8(hop), "", false)
+	}
+
+	item := frontier.NewItem(URL, parent, itemType, uint8(hop), ID, false)
+	item.Scope = fields["scope"]
+	item.Collection = fields["collection"]
+
+	// The urgent items are captured by the priority workers, see --priority-workers
+	item.Urgent = fields["priority"] == "urgent"
+
+	// The items demoted with --timegate-action demote aren't checked again
+	item.TimeGateChecked = fields["timegate"] == "checked"
+
+	// Producers can give a deadline after which the item isn't worth capturing anymore
+	if fields["not_after"] != "" {
+		notAfter, err := time.Parse(time.RFC3339, fields["not_after"])
+		if err != nil {
+			return nil, errors.New("invalid not_after in stream entry " + ID)
+		}
+
+		item.NotAfter = notAfter
+	}
+
+	if fields["content_type"] != "" || fields["anchor_text"] != "" || fields["heading"] != "" || fields["rel"] != "" {
+		item.Hints = &frontier.LinkHints{
+			ContentType: fields["content_type"],
+			AnchorText:  fields["anchor_text"],
+			Heading:     fields["heading"],
+			Rel:         fields["rel"],
+		}
+	}
+
+	return item, nil
+}
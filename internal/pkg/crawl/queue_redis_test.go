@@ -0,0 +1,30 @@
+package crawl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisStreamsBackendPendingEntries(t *testing.T) {
+	backend := &redisStreamsBackend{
+		pending:  make(map[string]struct{}),
+		released: make(chan struct{}, 1),
+	}
+
+	assert.True(t, backend.track("1-0"))
+	assert.True(t, backend.track("2-0"))
+
+	// An entry of this crawler claimed again by XAUTOCLAIM isn't delivered twice
+	assert.False(t, backend.track("1-0"))
+	assert.Len(t, backend.pendingIDs(), 2)
+
+	backend.untrack("1-0")
+	assert.Len(t, backend.pendingIDs(), 1)
+	assert.Len(t, backend.released, 1)
+
+	// Acknowledging an entry twice releases its slot once
+	<-backend.released
+	backend.untrack("1-0")
+	assert.Len(t, backend.released, 0)
+}
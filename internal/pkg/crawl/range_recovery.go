@@ -0,0 +1,120 @@
+package crawl
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// wrapRangeRecoveryBody replaces the body of a large response with a reader that resumes
+// the download with a Range request when the connection is interrupted, instead of
+// failing the capture. The continuation is captured as a 206 response in the WARC.
+func (c *Crawl) wrapRangeRecoveryBody(item *frontier.Item, req *http.Request, resp *http.Response) {
+	if c.RangeRecoveryMinSize <= 0 || resp.StatusCode != 200 || resp.Uncompressed {
+		return
+	}
+
+	if resp.ContentLength < int64(c.RangeRecoveryMinSize*MB) || !strings.Contains(resp.Header.Get("Accept-Ranges"), "bytes") {
+		return
+	}
+
+	// The continuation has to be of the same representation, If-Range
+	// needs a strong ETag or a Last-Modified date to guarantee it
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+
+	if validator == "" {
+		return
+	}
+
+	resp.Body = &rangeRecoveryBody{
+		ReadCloser: resp.Body,
+		crawl:      c,
+		item:       item,
+		req:        req,
+		validator:  validator,
+		size:       resp.ContentLength,
+	}
+}
+
+type rangeRecoveryBody struct {
+	io.ReadCloser
+	crawl     *Crawl
+	item      *frontier.Item
+	req       *http.Request
+	validator string
+	received  int64
+	size      int64
+	attempts  int
+}
+
+func (b *rangeRecoveryBody) Read(p []byte) (n int, err error) {
+	n, err = b.ReadCloser.Read(p)
+	b.received += int64(n)
+
+	if err == nil || b.received >= b.size || b.attempts >= b.crawl.MaxRetry {
+		return n, err
+	}
+
+	if b.crawl.shouldLog(logrus.WarnLevel) {
+		logWarning.WithFields(b.crawl.genLogFields(err, b.req.URL, map[string]interface{}{
+			"received": b.received,
+			"size":     b.size,
+			"attempt":  b.attempts + 1,
+		})).Warn("download interrupted, resuming with a Range request")
+	}
+
+	resumeErr := b.resume()
+	if resumeErr != nil {
+		if b.crawl.shouldLog(logrus.ErrorLevel) {
+			logError.WithFields(b.crawl.genLogFields(resumeErr, b.req.URL, nil)).Error("unable to resume interrupted download")
+		}
+
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+
+		return n, err
+	}
+
+	return n, nil
+}
+
+// resume request the rest of the payload and continue reading from it
+func (b *rangeRecoveryBody) resume() error {
+	b.attempts++
+
+	req, err := http.NewRequest("GET", utils.URLToString(b.req.URL), nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header = b.req.Header.Clone()
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-", b.received))
+	req.Header.Set("If-Range", b.validator)
+
+	resp, err := b.crawl.getCaptureClient(b.item, req.URL.Host).Do(req)
+	if err != nil {
+		return err
+	}
+
+	// The server may answer with the whole payload if the representation changed
+	if resp.StatusCode != 206 || !strings.HasPrefix(resp.Header.Get("Content-Range"), fmt.Sprintf("bytes %d-", b.received)) {
+		discardBody(resp.Body)
+		resp.Body.Close()
+
+		return fmt.Errorf("unexpected response to Range request: %s %s", resp.Status, resp.Header.Get("Content-Range"))
+	}
+
+	b.ReadCloser.Close()
+	b.ReadCloser = resp.Body
+
+	return nil
+}
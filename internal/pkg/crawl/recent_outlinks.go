@@ -0,0 +1,67 @@
+package crawl
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// RecentOutlinks is a fixed-size LRU of the outlinks queued recently. Template-heavy
+// sites link the same URLs from every page, the bursts of identical outlinks are
+// collapsed here before reaching the seencheck, HQ or the queue backend.
+type RecentOutlinks struct {
+	sync.Mutex
+	Size    int
+	Window  time.Duration
+	entries map[uint64]*list.Element
+	order   *list.List
+}
+
+type recentOutlink struct {
+	hash     uint64
+	queuedAt time.Time
+}
+
+// NewRecentOutlinks return a LRU remembering up to size outlinks for the given window
+func NewRecentOutlinks(size int, window time.Duration) *RecentOutlinks {
+	return &RecentOutlinks{
+		Size:    size,
+		Window:  window,
+		entries: make(map[uint64]*list.Element, size),
+		order:   list.New(),
+	}
+}
+
+// seen return true if the outlink with the given hash has been queued within the
+// window, otherwise it is remembered and the least recently seen one is evicted
+func (recent *RecentOutlinks) seen(hash uint64) bool {
+	recent.Lock()
+	defer recent.Unlock()
+
+	now := time.Now()
+
+	if element, exists := recent.entries[hash]; exists {
+		outlink := element.Value.(*recentOutlink)
+
+		if now.Sub(outlink.queuedAt) < recent.Window {
+			recent.order.MoveToFront(element)
+			return true
+		}
+
+		// The outlink is queued again once the window is over
+		outlink.queuedAt = now
+		recent.order.MoveToFront(element)
+
+		return false
+	}
+
+	recent.entries[hash] = recent.order.PushFront(&recentOutlink{hash: hash, queuedAt: now})
+
+	if recent.order.Len() > recent.Size {
+		oldest := recent.order.Back()
+		recent.order.Remove(oldest)
+		delete(recent.entries, oldest.Value.(*recentOutlink).hash)
+	}
+
+	return false
+}
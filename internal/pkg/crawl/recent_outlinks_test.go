@@ -0,0 +1,27 @@
+package crawl
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecentOutlinks(t *testing.T) {
+	recent := NewRecentOutlinks(2, time.Hour)
+
+	assert.False(t, recent.seen(1))
+	assert.True(t, recent.seen(1))
+	assert.False(t, recent.seen(2))
+
+	// 1 is the most recently seen, 2 is evicted
+	assert.True(t, recent.seen(1))
+	assert.False(t, recent.seen(3))
+	assert.False(t, recent.seen(2))
+
+	// Once the window is over, the outlink is queued again
+	recent = NewRecentOutlinks(2, time.Millisecond)
+	assert.False(t, recent.seen(1))
+	time.Sleep(2 * time.Millisecond)
+	assert.False(t, recent.seen(1))
+}
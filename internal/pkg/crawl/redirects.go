@@ -0,0 +1,100 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"golang.org/x/net/publicsuffix"
+)
+
+// isRedirectionInScope return true if the target of a redirection stays in the scope of the
+// crawl: on the same host (or the same registered domain with --redirect-scope domain),
+// and not excluded by --exclude-host, --include-host or --exclude-string
+func (c *Crawl) isRedirectionInScope(source, target *url.URL) bool {
+	if utils.StringInSlice(target.Host, c.getExcludedHosts()) || !c.checkIncludedHosts(target.Host) {
+		return false
+	}
+
+	for _, excludedString := range c.getExcludedStrings() {
+		if strings.Contains(utils.URLToString(target), excludedString) {
+			return false
+		}
+	}
+
+	if c.RedirectScope == "host" {
+		return source.Hostname() == target.Hostname()
+	}
+
+	return registeredDomain(source) == registeredDomain(target)
+}
+
+func registeredDomain(URL *url.URL) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(URL.Hostname())
+	if err != nil {
+		// IP addresses and public suffixes have no registered domain
+		return URL.Hostname()
+	}
+
+	return domain
+}
+
+// isCrossHostRedirection return true if the redirection leads to another host
+func isCrossHostRedirection(source, target *url.URL) bool {
+	return !strings.EqualFold(source.Hostname(), target.Hostname())
+}
+
+// isRedirectionAllowed return false if the redirection of the item to the target would go over
+// --max-redirect (the same-host redirections) or --max-cross-host-redirect, or goes back
+// to a URL of the chain of redirections the item is part of, like A→B→A
+func (c *Crawl) isRedirectionAllowed(item *frontier.Item, source, target *url.URL) bool {
+	if isRedirectionLoop(item, target) {
+		logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"target": utils.URLToString(target),
+		})).Info("redirection loop detected, not following it")
+
+		return false
+	}
+
+	if isCrossHostRedirection(source, target) {
+		return item.CrossHostRedir < c.MaxCrossHostRedirect
+	}
+
+	return item.Redirect-item.CrossHostRedir < c.MaxRedirect
+}
+
+// isRedirectionLoop return true if the target is one of the URLs of the chain of
+// redirections the item is part of, from the item up to the originally queued URL
+func isRedirectionLoop(item *frontier.Item, target *url.URL) bool {
+	targetString := utils.URLToString(target)
+
+	for chained := item; chained != nil; chained = chained.ParentItem {
+		if utils.URLToString(chained.URL) == targetString {
+			return true
+		}
+
+		if chained.Redirect == 0 {
+			break
+		}
+	}
+
+	return false
+}
+
+// handleOutOfScopeRedirection is called instead of following a redirection that leaves
+// the scope of the crawl, the redirection response itself is already in the WARC
+func (c *Crawl) handleOutOfScopeRedirection(item *frontier.Item, target *url.URL) {
+	switch c.RedirectPolicy {
+	case "record":
+		c.recordLinks(item, []*url.URL{target}, nil, "redirect")
+
+		logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"target": utils.URLToString(target),
+		})).Info("out of scope redirection recorded but not followed")
+	case "drop":
+		logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"target": utils.URLToString(target),
+		})).Debug("out of scope redirection dropped")
+	}
+}
@@ -0,0 +1,47 @@
+package crawl
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectionBudgets(t *testing.T) {
+	c := &Crawl{MaxRedirect: 1, MaxCrossHostRedirect: 2}
+
+	parse := func(rawURL string) *url.URL {
+		URL, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+
+		return URL
+	}
+
+	// a.com/1 → a.com/2 (same host) → b.com/1 (cross host)
+	first := frontier.NewItem(parse("http://a.com/1"), nil, "seed", 0, "", false)
+
+	second := frontier.NewItem(parse("http://a.com/2"), first, "seed", 0, "", false)
+	second.Redirect = 1
+
+	third := frontier.NewItem(parse("http://b.com/1"), second, "seed", 0, "", false)
+	third.Redirect = 2
+	third.CrossHostRedir = 1
+
+	// The same-host budget is spent, but not the cross-host one
+	assert.False(t, c.isRedirectionAllowed(second, second.URL, parse("http://a.com/3")))
+	assert.True(t, c.isRedirectionAllowed(second, second.URL, parse("http://c.com/1")))
+	assert.True(t, c.isRedirectionAllowed(third, third.URL, parse("http://c.com/1")))
+
+	third.CrossHostRedir = 2
+	assert.False(t, c.isRedirectionAllowed(third, third.URL, parse("http://c.com/1")))
+
+	// b.com/1 → a.com/1 goes back to the start of the chain
+	assert.True(t, isRedirectionLoop(third, parse("http://a.com/1")))
+	assert.True(t, isRedirectionLoop(third, parse("http://a.com/2")))
+	assert.False(t, isRedirectionLoop(third, parse("http://a.com/4")))
+
+	// The chain stops at the originally queued URL, its parents aren't part of it
+	fourth := frontier.NewItem(parse("http://d.com/"), third, "seed", 1, "", false)
+	assert.False(t, isRedirectionLoop(fourth, parse("http://a.com/1")))
+}
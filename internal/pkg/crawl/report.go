@@ -0,0 +1,204 @@
+package crawl
+
+import (
+	"encoding/csv"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// SeedOutcome holds the capture outcome of one of the original seeds of the crawl,
+// it is updated by the captures and read by the report writer through its methods
+type SeedOutcome struct {
+	mutex          sync.Mutex
+	URL            string
+	Status         string
+	StatusCode     int
+	RedirectTarget string
+	Redirects      int
+	Assets         uint64
+	Outlinks       uint64
+	Error          string
+	CaptureID      string
+	Tags           []string
+}
+
+// SeedsReport keeps track of the outcome of every original seed
+type SeedsReport struct {
+	outcomes sync.Map
+}
+
+// isOriginalSeed return true if the item is one of the seeds given to the crawler,
+// and not something discovered during the crawl
+func isOriginalSeed(item *frontier.Item) bool {
+	return item.ParentItem == nil && item.Type == "seed"
+}
+
+// getSeedOutcome return the outcome of the seed, creating it if it doesn't exist yet.
+// It returns nil if the seeds report is disabled or if the item isn't an original seed.
+func (c *Crawl) getSeedOutcome(item *frontier.Item) *SeedOutcome {
+	if c.SeedsReport == nil || !isOriginalSeed(item) {
+		return nil
+	}
+
+	URL := utils.URLToString(item.URL)
+
+	outcome, _ := c.SeedsReport.outcomes.LoadOrStore(URL, &SeedOutcome{
+		URL:       URL,
+		Status:    "pending",
+		CaptureID: item.CaptureID,
+	})
+
+	return outcome.(*SeedOutcome)
+}
+
+func (outcome *SeedOutcome) addOutlinks(count int) {
+	if outcome == nil {
+		return
+	}
+
+	atomic.AddUint64(&outcome.Outlinks, uint64(count))
+}
+
+func (outcome *SeedOutcome) addTags(tags []string) {
+	if outcome == nil {
+		return
+	}
+
+	outcome.mutex.Lock()
+	defer outcome.mutex.Unlock()
+
+	outcome.Tags = append(outcome.Tags, tags...)
+}
+
+func (outcome *SeedOutcome) setError(err error) {
+	if outcome == nil {
+		return
+	}
+
+	outcome.mutex.Lock()
+	defer outcome.mutex.Unlock()
+
+	outcome.Status = "failed"
+	outcome.Error = err.Error()
+}
+
+// setCaptured record the status code of the seed's response, and its final URL if it was redirected
+func (outcome *SeedOutcome) setCaptured(statusCode int, finalURL string) {
+	if outcome == nil {
+		return
+	}
+
+	outcome.mutex.Lock()
+	defer outcome.mutex.Unlock()
+
+	outcome.Status = "captured"
+	outcome.StatusCode = statusCode
+
+	if finalURL != "" && finalURL != outcome.URL {
+		outcome.RedirectTarget = finalURL
+	}
+}
+
+// setAlias record that the seed is the same resource as another seed, given if known
+func (outcome *SeedOutcome) setAlias(seed string) {
+	if outcome == nil {
+		return
+	}
+
+	outcome.mutex.Lock()
+	defer outcome.mutex.Unlock()
+
+	outcome.Status = "alias"
+	if seed != "" {
+		outcome.RedirectTarget = seed
+	}
+}
+
+// setRedirection record the target of the seed's chain of redirections so far
+func (outcome *SeedOutcome) setRedirection(target string, redirects int) {
+	if outcome == nil {
+		return
+	}
+
+	outcome.mutex.Lock()
+	defer outcome.mutex.Unlock()
+
+	outcome.RedirectTarget = target
+	outcome.Redirects = redirects
+}
+
+// row return the line of the seed in seeds.csv
+func (outcome *SeedOutcome) row() []string {
+	outcome.mutex.Lock()
+	defer outcome.mutex.Unlock()
+
+	return []string{
+		outcome.URL,
+		outcome.Status,
+		strconv.Itoa(outcome.StatusCode),
+		outcome.RedirectTarget,
+		strconv.Itoa(outcome.Redirects),
+		strconv.FormatUint(atomic.LoadUint64(&outcome.Assets), 10),
+		strconv.FormatUint(atomic.LoadUint64(&outcome.Outlinks), 10),
+		outcome.Error,
+		outcome.CaptureID,
+		strings.Join(outcome.Tags, ";"),
+	}
+}
+
+func (c *Crawl) writeSeedsReport() {
+	if c.SeedsReport == nil {
+		return
+	}
+
+	err := c.SeedsReport.Write(c.JobPath)
+	if err != nil {
+		logError.WithFields(c.genLogFields(err, nil, nil)).Error("unable to write seeds report")
+	}
+}
+
+// Write dump the seeds report as a CSV file in the job's directory
+func (report *SeedsReport) Write(jobPath string) error {
+	var outcomes []*SeedOutcome
+
+	report.outcomes.Range(func(key, value any) bool {
+		outcomes = append(outcomes, value.(*SeedOutcome))
+		return true
+	})
+
+	sort.Slice(outcomes, func(i, j int) bool {
+		return outcomes[i].URL < outcomes[j].URL
+	})
+
+	file, err := os.Create(path.Join(jobPath, "seeds.csv"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	err = writer.Write([]string{"url", "status", "status_code", "redirect_target", "redirects", "assets", "outlinks", "error", "capture_id", "tags"})
+	if err != nil {
+		return err
+	}
+
+	for _, outcome := range outcomes {
+		err = writer.Write(outcome.row())
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -0,0 +1,58 @@
+package crawl
+
+import (
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/paulbellamy/ratecounter"
+)
+
+// unfetchableSchemes are the URL schemes that can't be captured, links
+// using them are skipped by the extractors before being parsed
+var unfetchableSchemes = []string{"data", "blob", "javascript", "mailto", "tel"}
+
+// SkippedLinks counts the links skipped by the extractors, per scheme
+type SkippedLinks map[string]*ratecounter.Counter
+
+// NewSkippedLinks create the counters of skipped links for every unfetchable scheme
+func NewSkippedLinks() SkippedLinks {
+	skippedLinks := make(SkippedLinks)
+
+	for _, scheme := range unfetchableSchemes {
+		skippedLinks[scheme] = new(ratecounter.Counter)
+	}
+
+	return skippedLinks
+}
+
+// Values return the number of links skipped for every scheme
+func (skippedLinks SkippedLinks) Values() map[string]int64 {
+	values := make(map[string]int64)
+
+	for scheme, counter := range skippedLinks {
+		values[scheme] = counter.Value()
+	}
+
+	return values
+}
+
+// skipUnfetchableLinks remove the links using a scheme that can't be captured,
+// like data: or mailto:, and count them if the counters have been created by Start
+func (c *Crawl) skipUnfetchableLinks(rawLinks []string) (output []string) {
+	for _, rawLink := range rawLinks {
+		link := strings.TrimSpace(rawLink)
+
+		scheme, _, found := strings.Cut(link, ":")
+		if found && utils.StringInSlice(strings.ToLower(scheme), unfetchableSchemes) {
+			if counter, exists := c.SkippedLinks[strings.ToLower(scheme)]; exists {
+				counter.Incr(1)
+			}
+
+			continue
+		}
+
+		output = append(output, rawLink)
+	}
+
+	return output
+}
@@ -0,0 +1,59 @@
+package crawl
+
+import (
+	"errors"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+var errSeedAlias = errors.New("seed is an alias of an already captured seed")
+
+// getOriginalSeed return the original seed at the start of the redirection chain
+// of the item, or nil if the chain doesn't start from one of the original seeds
+func getOriginalSeed(item *frontier.Item) *frontier.Item {
+	for item.Redirect > 0 && item.ParentItem != nil {
+		item = item.ParentItem
+	}
+
+	if !isOriginalSeed(item) {
+		return nil
+	}
+
+	return item
+}
+
+// recordSeedAlias record the redirection target of an original seed as its alias in the
+// seeds report. With --seencheck-seed-aliases, it return errSeedAlias if the target
+// has already been captured, either as a seed or as the target of another seed.
+func (c *Crawl) recordSeedAlias(redirection *frontier.Item) error {
+	seed := getOriginalSeed(redirection)
+	if seed == nil {
+		return nil
+	}
+
+	c.getSeedOutcome(seed).setRedirection(utils.URLToString(redirection.URL), redirection.Redirect)
+
+	if !c.SeencheckSeedAliases {
+		return nil
+	}
+
+	_, seen := c.seedForms.LoadOrStore(utils.URLToString(redirection.URL), utils.URLToString(seed.URL))
+	if seen {
+		return errSeedAlias
+	}
+
+	return nil
+}
+
+// getSeedAlias return the seed that has already been captured under the form of the
+// given original seed, either as a seed or as the redirection target of another seed
+func (c *Crawl) getSeedAlias(item *frontier.Item) (seed string, isAlias bool) {
+	if !c.SeencheckSeedAliases || !isOriginalSeed(item) {
+		return "", false
+	}
+
+	value, seen := c.seedForms.LoadOrStore(utils.URLToString(item.URL), utils.URLToString(item.URL))
+
+	return value.(string), seen
+}
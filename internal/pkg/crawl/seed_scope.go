@@ -0,0 +1,34 @@
+package crawl
+
+import (
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+// getItemScope return the scope the item inherited from its seed, or nil if it has
+// none. The scope expressions are parsed once and shared by all the descendants.
+func (c *Crawl) getItemScope(item *frontier.Item) *frontier.Scope {
+	if item.Scope == "" {
+		return nil
+	}
+
+	if scope, exists := c.seedScopes.Load(item.Scope); exists {
+		return scope.(*frontier.Scope)
+	}
+
+	// The expressions of the seed list are validated when it is loaded,
+	// but the items received from a queue backend aren't
+	scope, err := frontier.ParseScope(item.Scope)
+	if err != nil {
+		logWarning.WithFields(c.genLogFields(err, item, map[string]interface{}{
+			"scope": item.Scope,
+		})).Warn("invalid seed scope, the global scope is used")
+
+		item.Scope = ""
+
+		return nil
+	}
+
+	actual, _ := c.seedScopes.LoadOrStore(item.Scope, scope)
+
+	return actual.(*frontier.Scope)
+}
@@ -0,0 +1,41 @@
+package crawl
+
+import (
+	"net/url"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// expandShortener return the destination of an outlink pointing to a known URL shortener,
+// so that it is seenchecked and scoped as the destination rather than as the shortener.
+// The chain of redirections is logged and the destination is recorded in the link graph.
+// If the shortener can't be resolved, the outlink is returned as it is.
+func (c *Crawl) expandShortener(item *frontier.Item, outlink *url.URL) *url.URL {
+	chain := frontier.ResolveShortener(outlink, c.UserAgent)
+	if len(chain) == 0 {
+		logInfo.WithFields(c.genLogFields(nil, outlink, map[string]interface{}{
+			"parentUrl": utils.URLToString(item.URL),
+		})).Debug("unable to resolve URL shortener")
+
+		return outlink
+	}
+
+	destination := chain[len(chain)-1]
+	c.normalizeURL(destination)
+
+	var hops []string
+	for _, hop := range chain {
+		hops = append(hops, utils.URLToString(hop))
+	}
+
+	logInfo.WithFields(c.genLogFields(nil, outlink, map[string]interface{}{
+		"parentUrl":   utils.URLToString(item.URL),
+		"destination": utils.URLToString(destination),
+		"chain":       hops,
+	})).Info("URL shortener expanded")
+
+	c.recordLinks(item, []*url.URL{destination}, nil, "shortener")
+
+	return destination
+}
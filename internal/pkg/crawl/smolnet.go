@@ -0,0 +1,312 @@
+package crawl
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// maxSmolnetResponseSize is the maximum size of a Gemini or Gopher response,
+// those protocols have no content length so we need a limit
+const maxSmolnetResponseSize = 100 * MB
+
+// gopherItemTypes maps the Gopher item types to the MIME type of the content
+var gopherItemTypes = map[byte]string{
+	'0': "text/plain",
+	'1': "text/x-gopher-menu",
+	'4': "application/mac-binhex40",
+	'5': "application/octet-stream",
+	'6': "text/x-uuencode",
+	'7': "text/x-gopher-menu",
+	'9': "application/octet-stream",
+	'g': "image/gif",
+	'h': "text/html",
+	'I': "image/unknown",
+	's': "audio/unknown",
+	'p': "image/png",
+	'd': "application/pdf",
+}
+
+// isSmolnetURL return true if the URL is a gemini:// or gopher:// URL
+func isSmolnetURL(URL *url.URL) bool {
+	return URL.Scheme == "gemini" || URL.Scheme == "gopher"
+}
+
+// captureSmolnet capture a gemini:// or gopher:// URL, the response is
+// written as a WARC resource record and the links are queued as outlinks
+func (c *Crawl) captureSmolnet(item *frontier.Item) {
+	var (
+		executionStart = time.Now()
+		body           []byte
+		contentType    string
+		statusCode     int
+		IP             string
+		outlinks       []*url.URL
+		err            error
+	)
+
+	// The hostnames of --hosts-file are resolved to their forced IPs
+	dialer := &net.Dialer{
+		Timeout:  time.Duration(c.HTTPTimeout) * time.Second,
+		Resolver: c.DNSResolver,
+	}
+
+	switch item.URL.Scheme {
+	case "gemini":
+		statusCode, contentType, body, IP, err = fetchGemini(item.URL, dialer)
+	case "gopher":
+		contentType, body, IP, err = fetchGopher(item.URL, dialer)
+	}
+
+	c.URIsPerSecond.Incr(1)
+
+	if item.Type == "seed" {
+		c.CrawledSeeds.Incr(1)
+	} else if item.Type == "asset" {
+		c.CrawledAssets.Incr(1)
+	}
+
+	if err != nil {
+		c.getSeedOutcome(item).setError(err)
+		logError.WithFields(c.genLogFields(err, item, nil)).Error("error while capturing smolnet URL")
+		return
+	}
+
+	// Only the successful Gemini responses have a content, the
+	// other statuses are redirections, errors or input requests
+	if item.URL.Scheme == "gemini" && (statusCode < 20 || statusCode >= 30) {
+		logWarning.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+			"statusCode": statusCode,
+			"meta":       contentType,
+		})).Warn("Gemini capsule didn't return any content")
+
+		// Follow the redirections as new items
+		if statusCode >= 30 && statusCode < 40 {
+			redirection, err := item.URL.Parse(contentType)
+			if err == nil {
+				outlinks = append(outlinks, redirection)
+			}
+		}
+	} else {
+		record := warc.NewRecord(c.WARCTempDir, c.WARCFullOnDisk)
+		record.Header.Set("WARC-Type", "resource")
+		record.Header.Set("WARC-Target-URI", utils.URLToString(item.URL))
+		record.Header.Set("Content-Type", contentType)
+
+		if IP != "" {
+			record.Header.Set("WARC-IP-Address", IP)
+		}
+
+		record.Content.Write(body)
+
+		c.writeWARCRecords(item, "", executionStart, record)
+
+		c.logCrawlSuccess(executionStart, statusCode, item)
+
+		switch {
+		case strings.HasPrefix(contentType, "text/gemini"):
+			outlinks = extractGeminiLinks(item.URL, body)
+		case contentType == "text/x-gopher-menu":
+			outlinks = extractGopherMenuLinks(body)
+		}
+	}
+
+	seedOutcome := c.getSeedOutcome(item)
+	seedOutcome.setCaptured(statusCode, "")
+	seedOutcome.addOutlinks(len(outlinks))
+
+	if len(outlinks) > 0 {
+		var waitGroup sync.WaitGroup
+
+		waitGroup.Add(1)
+		c.queueOutlinks(outlinks, nil, item, &waitGroup)
+	}
+}
+
+// fetchGemini send a Gemini request and return the status, the meta (MIME type for
+// successful responses) and the body. Gemini capsules mostly use self-signed
+// certificates (trust on first use), so the certificates aren't verified.
+func fetchGemini(URL *url.URL, dialer *net.Dialer) (statusCode int, meta string, body []byte, IP string, err error) {
+	host := URL.Host
+	if URL.Port() == "" {
+		host = net.JoinHostPort(URL.Hostname(), "1965")
+	}
+
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         URL.Hostname(),
+	})
+	if err != nil {
+		return 0, "", nil, "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(dialer.Timeout))
+
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		IP = addr.IP.String()
+	}
+
+	_, err = conn.Write([]byte(utils.URLToString(URL) + "\r\n"))
+	if err != nil {
+		return 0, "", nil, IP, err
+	}
+
+	reader := bufio.NewReader(io.LimitReader(conn, maxSmolnetResponseSize))
+
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, "", nil, IP, err
+	}
+
+	status, meta, _ := strings.Cut(strings.TrimRight(header, "\r\n"), " ")
+
+	statusCode, err = strconv.Atoi(status)
+	if err != nil || len(status) != 2 {
+		return 0, "", nil, IP, fmt.Errorf("invalid Gemini response header: %q", header)
+	}
+
+	body, err = io.ReadAll(reader)
+	if err != nil {
+		return statusCode, meta, nil, IP, err
+	}
+
+	return statusCode, meta, body, IP, nil
+}
+
+// fetchGopher send a Gopher request and return the MIME type, guessed
+// from the item type of the URL, and the body
+func fetchGopher(URL *url.URL, dialer *net.Dialer) (contentType string, body []byte, IP string, err error) {
+	host := URL.Host
+	if URL.Port() == "" {
+		host = net.JoinHostPort(URL.Hostname(), "70")
+	}
+
+	// The path of a Gopher URL is the item type followed by the selector,
+	// an empty path is the root menu
+	var (
+		itemType byte = '1'
+		selector string
+	)
+
+	if path := strings.TrimPrefix(URL.Path, "/"); path != "" {
+		itemType = path[0]
+		selector = path[1:]
+	}
+
+	if URL.RawQuery != "" {
+		selector += "\t" + URL.RawQuery
+	}
+
+	contentType, known := gopherItemTypes[itemType]
+	if !known {
+		contentType = "application/octet-stream"
+	}
+
+	conn, err := dialer.Dial("tcp", host)
+	if err != nil {
+		return "", nil, "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(dialer.Timeout))
+
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		IP = addr.IP.String()
+	}
+
+	_, err = conn.Write([]byte(selector + "\r\n"))
+	if err != nil {
+		return "", nil, IP, err
+	}
+
+	body, err = io.ReadAll(io.LimitReader(conn, maxSmolnetResponseSize))
+	if err != nil {
+		return "", nil, IP, err
+	}
+
+	if len(body) == 0 {
+		return "", nil, IP, errors.New("empty Gopher response")
+	}
+
+	return contentType, body, IP, nil
+}
+
+// extractGeminiLinks extract the links of a text/gemini document,
+// they are the lines starting with =>
+func extractGeminiLinks(base *url.URL, body []byte) (links []*url.URL) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "=>") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line, "=>"))
+		if len(fields) == 0 {
+			continue
+		}
+
+		link, err := base.Parse(fields[0])
+		if err != nil {
+			continue
+		}
+
+		links = append(links, link)
+	}
+
+	return links
+}
+
+// extractGopherMenuLinks extract the links of a Gopher menu, each line being
+// the item type and the display string, then the selector, host and port
+// separated by tabs. The h items with a URL: selector are links to the web.
+func extractGopherMenuLinks(body []byte) (links []*url.URL) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) < 4 || len(fields[0]) == 0 {
+			continue
+		}
+
+		itemType := fields[0][0]
+
+		// Informational lines, errors and telnet sessions aren't links
+		if itemType == 'i' || itemType == '3' || itemType == '8' || itemType == 'T' {
+			continue
+		}
+
+		selector, host, port := fields[1], fields[2], strings.TrimSpace(fields[3])
+
+		var rawLink string
+		if strings.HasPrefix(selector, "URL:") {
+			rawLink = strings.TrimPrefix(selector, "URL:")
+		} else {
+			rawLink = "gopher://" + net.JoinHostPort(host, port) + "/" + string(itemType) + selector
+		}
+
+		link, err := url.Parse(rawLink)
+		if err != nil {
+			continue
+		}
+
+		links = append(links, link)
+	}
+
+	return links
+}
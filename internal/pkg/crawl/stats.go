@@ -35,14 +35,19 @@ func (c *Crawl) printLiveStats() {
 
 		stats.AddRow("", "")
 		stats.AddRow("  - Job:", c.Job)
+		stats.AddRow("  - Job ID:", c.JobID)
 		stats.AddRow("  - State:", c.getCrawlState())
-		stats.AddRow("  - Active workers:", strconv.Itoa(int(c.ActiveWorkers.Value()))+"/"+strconv.Itoa(c.Workers))
+		stats.AddRow("  - Active workers:", strconv.Itoa(int(c.ActiveWorkers.Value()))+"/"+strconv.Itoa(c.getWorkersCount()))
 		stats.AddRow("  - URI/s:", c.URIsPerSecond.Rate())
+		queueAge := c.Frontier.QueueAge.Percentiles(0.5, 0.9, 0.99)
+
 		stats.AddRow("  - Queued:", c.Frontier.QueueCount.Value())
+		stats.AddRow("  - Queue age (p50/p90/p99):", queueAge[0].Round(time.Millisecond).String()+" / "+queueAge[1].Round(time.Millisecond).String()+" / "+queueAge[2].Round(time.Millisecond).String())
 		stats.AddRow("  - Crawled total:", crawledSeeds+crawledAssets)
 		stats.AddRow("  - Crawled seeds:", crawledSeeds)
 		stats.AddRow("  - Crawled assets:", crawledAssets)
-		stats.AddRow("  - WARC writing queue:", c.Client.WaitGroup.Size())
+		stats.AddRow("  - WARC writing queue:", c.getWARCWritingQueueSize())
+		stats.AddRow("  - WARC writing blocked:", time.Duration(c.WARCWritingBlockedTime.Value()).Round(time.Millisecond).String())
 		stats.AddRow("  - Data:", humanize.Bytes(uint64(warc.DataTotal.Value())))
 		stats.AddRow("", "")
 		stats.AddRow("  - Elapsed time:", time.Since(c.StartTime).String())
@@ -61,6 +66,14 @@ func (c *Crawl) getCrawlState() (state string) {
 		return "finishing"
 	}
 
+	if c.DiskFull.Get() {
+		return "paused (disk full)"
+	}
+
+	if c.OutsideWindow.Get() {
+		return "paused (outside of the crawl window)"
+	}
+
 	if c.Paused.Get() {
 		return "paused"
 	}
@@ -0,0 +1,120 @@
+package crawl
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// extractStructuredData extract the URLs of the schema.org properties found in the
+// JSON-LD blocks and the microdata attributes of a document, a lot of media URLs
+// only appear there: contentUrl and image are assets, url and sameAs are outlinks
+func extractStructuredData(doc *goquery.Document) (rawOutlinks []string, rawAssets []string) {
+	doc.Find(`script[type="application/ld+json"]`).Each(func(index int, item *goquery.Selection) {
+		outlinks, assets := getURLsFromJSONLD(item.Text())
+		rawOutlinks = append(rawOutlinks, outlinks...)
+		rawAssets = append(rawAssets, assets...)
+	})
+
+	doc.Find("[itemprop]").Each(func(index int, item *goquery.Selection) {
+		property, _ := item.Attr("itemprop")
+
+		kind := getMicrodataKind(property)
+		if kind == "" {
+			return
+		}
+
+		link, exists := getMicrodataValue(goquery.NodeName(item), item.Attr)
+		if !exists {
+			return
+		}
+
+		if kind == "asset" {
+			rawAssets = append(rawAssets, link)
+		} else {
+			rawOutlinks = append(rawOutlinks, link)
+		}
+	})
+
+	return rawOutlinks, rawAssets
+}
+
+// getURLsFromJSONLD extract the URLs of the schema.org properties of a JSON-LD block
+func getURLsFromJSONLD(JSONLD string) (outlinks []string, assets []string) {
+	var data interface{}
+
+	err := json.Unmarshal([]byte(JSONLD), &data)
+	if err != nil {
+		return nil, nil
+	}
+
+	walkJSONLD(data, "", &outlinks, &assets)
+
+	return outlinks, assets
+}
+
+// walkJSONLD collect the values of the schema.org properties, the url of an
+// object given as an image (an ImageObject) is the image itself so it's an asset
+func walkJSONLD(data interface{}, kind string, outlinks *[]string, assets *[]string) {
+	switch value := data.(type) {
+	case string:
+		switch kind {
+		case "asset":
+			*assets = append(*assets, value)
+		case "outlink":
+			*outlinks = append(*outlinks, value)
+		}
+	case []interface{}:
+		for _, element := range value {
+			walkJSONLD(element, kind, outlinks, assets)
+		}
+	case map[string]interface{}:
+		for property, element := range value {
+			switch property {
+			case "contentUrl", "image":
+				walkJSONLD(element, "asset", outlinks, assets)
+			case "url", "sameAs":
+				if kind == "asset" {
+					walkJSONLD(element, "asset", outlinks, assets)
+				} else {
+					walkJSONLD(element, "outlink", outlinks, assets)
+				}
+			default:
+				walkJSONLD(element, "", outlinks, assets)
+			}
+		}
+	}
+}
+
+// getMicrodataKind return whether the value of a microdata property is
+// an asset or an outlink, an itemprop attribute can hold multiple properties
+func getMicrodataKind(itemprop string) (kind string) {
+	for _, property := range strings.Fields(itemprop) {
+		switch property {
+		case "contentUrl", "image":
+			return "asset"
+		case "url", "sameAs":
+			kind = "outlink"
+		}
+	}
+
+	return kind
+}
+
+// getMicrodataValue return the URL value of a microdata property, which
+// depends on the element holding it as defined by the HTML specification
+func getMicrodataValue(tag string, attr func(name string) (string, bool)) (string, bool) {
+	switch tag {
+	case "a", "area", "link":
+		return attr("href")
+	case "audio", "embed", "iframe", "img", "source", "track", "video":
+		return attr("src")
+	case "object":
+		return attr("data")
+	case "meta":
+		return attr("content")
+	}
+
+	return "", false
+}
@@ -0,0 +1,54 @@
+package crawl
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// notifySystemdReady tell systemd that the crawl started when Zeno runs as a
+// Type=notify service, and start the watchdog notifications if WatchdogSec= is set
+func (c *Crawl) notifySystemdReady() {
+	sent, err := utils.SdNotify("READY=1\nSTATUS=crawling")
+	if err != nil {
+		logWarning.WithFields(c.genLogFields(err, nil, nil)).Warn("unable to notify systemd")
+		return
+	}
+
+	if !sent {
+		return
+	}
+
+	if interval := utils.SdWatchdogInterval(); interval > 0 {
+		go c.systemdWatchdog(interval)
+	}
+}
+
+// systemdWatchdog notify systemd that the crawl is alive twice per watchdog interval, as long
+// as the main loop (the crawl speed limiter) is beating. If it hangs, the notifications stop
+// and systemd restarts the service. The status of the crawl is sent along with the notifications.
+// It keeps running while the crawl is finishing, so that a long finish isn't taken for a hang.
+func (c *Crawl) systemdWatchdog(interval time.Duration) {
+	for {
+		time.Sleep(interval / 2)
+
+		lastBeat := time.Unix(0, atomic.LoadInt64(&c.heartbeat))
+		if time.Since(lastBeat) > interval/2 {
+			logError.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"lastBeat": lastBeat.String(),
+			})).Error("main loop not beating, not notifying the systemd watchdog")
+
+			continue
+		}
+
+		utils.SdNotify(fmt.Sprintf("WATCHDOG=1\nSTATUS=%s, %d URLs crawled, %d queued",
+			c.getCrawlState(), c.CrawledSeeds.Value()+c.CrawledAssets.Value(), c.Frontier.QueueCount.Value()))
+	}
+}
+
+// beat record that the main loop is alive, for the systemd watchdog
+func (c *Crawl) beat() {
+	atomic.StoreInt64(&c.heartbeat, time.Now().UnixNano())
+}
@@ -0,0 +1,104 @@
+package crawl
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// targetRateInterval is how often the dispatch rate is adjusted to the measured rate
+const targetRateInterval = 5 * time.Second
+
+// minDispatchRate is the lowest number of items dispatched per second with --target-rate
+const minDispatchRate = 0.01
+
+// RatePacer spaces the dispatch of the items to the workers so that the crawl
+// captures about Target URIs per second. The captured URIs include the assets of the
+// pages, so the dispatch rate is periodically adjusted based on the measured rate.
+type RatePacer struct {
+	sync.Mutex
+	target       float64
+	dispatchRate float64
+	next         time.Time
+}
+
+// NewRatePacer return a pacer for the given number of URIs per second, 0 to disable it
+func NewRatePacer(target float64) *RatePacer {
+	return &RatePacer{target: target, dispatchRate: target}
+}
+
+// Target return the number of URIs per second the crawl is paced to, 0 if disabled
+func (pacer *RatePacer) Target() float64 {
+	pacer.Lock()
+	defer pacer.Unlock()
+
+	return pacer.target
+}
+
+// SetTarget change the number of URIs per second the crawl is paced to, 0 to disable it
+func (pacer *RatePacer) SetTarget(target float64) {
+	pacer.Lock()
+	defer pacer.Unlock()
+
+	pacer.target = target
+	pacer.dispatchRate = target
+	pacer.next = time.Time{}
+}
+
+// wait block until the next item can be dispatched
+func (pacer *RatePacer) wait() {
+	pacer.Lock()
+
+	if pacer.target <= 0 {
+		pacer.Unlock()
+		return
+	}
+
+	now := time.Now()
+	if pacer.next.Before(now) {
+		pacer.next = now
+	}
+
+	delay := pacer.next.Sub(now)
+	pacer.next = pacer.next.Add(time.Duration(float64(time.Second) / pacer.dispatchRate))
+
+	pacer.Unlock()
+
+	time.Sleep(delay)
+}
+
+// adjust the dispatch rate to the measured rate of URIs per second: pages with many
+// assets lower it, the dispatch rate never exceeds the target as every item is at least
+// one URI. The correction is bounded to avoid oscillations with a noisy measure.
+func (pacer *RatePacer) adjust(measured float64) {
+	pacer.Lock()
+	defer pacer.Unlock()
+
+	if pacer.target <= 0 || measured <= 0 {
+		return
+	}
+
+	ratio := math.Max(0.5, math.Min(2, pacer.target/measured))
+
+	pacer.dispatchRate = math.Max(minDispatchRate, math.Min(pacer.target, pacer.dispatchRate*ratio))
+}
+
+// paceTargetRate periodically adjust the dispatch rate of the pacer to the rate of
+// URIs captured, the intervals during which the crawl is paused are ignored
+func (c *Crawl) paceTargetRate() {
+	lastCrawled := c.CrawledSeeds.Value() + c.CrawledAssets.Value()
+
+	for !c.Finished.Get() {
+		time.Sleep(targetRateInterval)
+
+		crawled := c.CrawledSeeds.Value() + c.CrawledAssets.Value()
+		measured := float64(crawled-lastCrawled) / targetRateInterval.Seconds()
+		lastCrawled = crawled
+
+		if c.Paused.Get() {
+			continue
+		}
+
+		c.TargetRate.adjust(measured)
+	}
+}
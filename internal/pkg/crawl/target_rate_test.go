@@ -0,0 +1,28 @@
+package crawl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRatePacerAdjust(t *testing.T) {
+	pacer := NewRatePacer(10)
+
+	// Every page comes with 9 assets, the dispatch rate converges to 1 page per second
+	for i := 0; i < 10; i++ {
+		pacer.adjust(pacer.dispatchRate * 10)
+	}
+	assert.InDelta(t, 1, pacer.dispatchRate, 0.01)
+
+	// The dispatch rate never exceeds the target
+	pacer.adjust(1)
+	pacer.adjust(1)
+	pacer.adjust(1)
+	pacer.adjust(1)
+	assert.Equal(t, float64(10), pacer.dispatchRate)
+
+	pacer.SetTarget(0)
+	pacer.wait()
+	assert.Equal(t, float64(0), pacer.Target())
+}
@@ -0,0 +1,168 @@
+package crawl
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// TimeGate queries a Memento TimeGate (RFC 7089) before capturing the pages, so that
+// the pages captured recently enough by another archive are skipped or demoted
+// to the back of the queue, instead of duplicating the work across institutions
+type TimeGate struct {
+	URL    string
+	MaxAge time.Duration
+	Action string
+	client *http.Client
+}
+
+// NewTimeGate return the TimeGate checker, the URL of the page is appended to the TimeGate URL
+func NewTimeGate(timeGateURL string, maxAge time.Duration, action string) *TimeGate {
+	return &TimeGate{
+		URL:    timeGateURL,
+		MaxAge: maxAge,
+		Action: action,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// latestMemento return the datetime of the most recent memento of the URL
+// known to the TimeGate, or a zero time if it has none
+func (timeGate *TimeGate) latestMemento(URL *url.URL, userAgent string) (latest time.Time, err error) {
+	req, err := http.NewRequest("HEAD", timeGate.URL+utils.URLToString(URL), nil)
+	if err != nil {
+		return latest, err
+	}
+
+	// Asking for the memento closest to now gives the most recent one
+	req.Header.Set("Accept-Datetime", time.Now().UTC().Format(http.TimeFormat))
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := timeGate.client.Do(req)
+	if err != nil {
+		return latest, err
+	}
+	resp.Body.Close()
+
+	// The mementos are announced in the Link header, with their datetime
+	for _, link := range splitLinkHeader(resp.Header.Values("Link")) {
+		rel, datetimeParam := linkParams(link)
+		if !utils.StringInSlice("memento", strings.Fields(strings.ToLower(rel))) {
+			continue
+		}
+
+		datetime, err := http.ParseTime(datetimeParam)
+		if err == nil && datetime.After(latest) {
+			latest = datetime
+		}
+	}
+
+	// A memento answering directly gives its own datetime
+	if datetime, err := http.ParseTime(resp.Header.Get("Memento-Datetime")); err == nil && datetime.After(latest) {
+		latest = datetime
+	}
+
+	return latest, nil
+}
+
+// splitLinkHeader split the Link headers into one value per link. The datetime of the
+// mementos contains commas, that the usual Link header parsers take as separators.
+func splitLinkHeader(headers []string) (links []string) {
+	for _, header := range headers {
+		var (
+			inQuotes   bool
+			inBrackets bool
+			start      int
+		)
+
+		for i, char := range header {
+			switch {
+			case char == '"' && !inBrackets:
+				inQuotes = !inQuotes
+			case char == '<' && !inQuotes:
+				inBrackets = true
+			case char == '>' && !inQuotes:
+				inBrackets = false
+			case char == ',' && !inQuotes && !inBrackets:
+				links = append(links, header[start:i])
+				start = i + 1
+			}
+		}
+
+		links = append(links, header[start:])
+	}
+
+	return links
+}
+
+// linkParams return the rel and datetime parameters of a link of the Link header
+func linkParams(link string) (rel string, datetime string) {
+	// The parameters follow the URL, given between angle brackets
+	if end := strings.Index(link, ">"); end != -1 {
+		link = link[end+1:]
+	}
+
+	for _, param := range strings.Split(link, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "rel":
+			rel = value
+		case "datetime":
+			datetime = value
+		}
+	}
+
+	return rel, datetime
+}
+
+// checkTimeGate return true if the item has been captured by another archive
+// less than --timegate-max-age ago, the item is then skipped or demoted with
+// --timegate-action. The items the TimeGate can't be queried for are captured.
+func (c *Crawl) checkTimeGate(item *frontier.Item) bool {
+	if c.TimeGate == nil || item.TimeGateChecked {
+		return false
+	}
+
+	latest, err := c.TimeGate.latestMemento(item.URL, c.UserAgent)
+	if err != nil {
+		logWarning.WithFields(c.genLogFields(err, item, nil)).Warn("unable to query the TimeGate, capturing the item")
+		return false
+	}
+
+	if latest.IsZero() || time.Since(latest) > c.TimeGate.MaxAge {
+		return false
+	}
+
+	logInfo.WithFields(c.genLogFields(nil, item, map[string]interface{}{
+		"memento": latest.Format(time.RFC3339),
+		"action":  c.TimeGate.Action,
+	})).Info("recent memento found by the TimeGate")
+
+	// A demoted item is sent to the back of the queue, bypassing the seencheck,
+	// and is captured when it comes up again without querying the TimeGate
+	if c.TimeGate.Action == "demote" {
+		demoted := frontier.NewItem(item.URL, item.ParentItem, item.Type, item.Hop, "", true)
+		demoted.Scope = item.Scope
+		demoted.Collection = item.Collection
+		demoted.Hints = item.Hints
+		demoted.TimeGateChecked = true
+
+		c.queueItem(demoted)
+	}
+
+	// Mark the item as done for HQ or the queue backend
+	c.markItemDone(item)
+
+	return true
+}
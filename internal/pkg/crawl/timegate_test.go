@@ -0,0 +1,33 @@
+package crawl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeGateLatestMemento(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/timegate/https://example.com/page", r.URL.Path)
+		assert.NotEmpty(t, r.Header.Get("Accept-Datetime"))
+
+		w.Header().Add("Link", `<https://example.com/page>; rel="original", <https://archive.example/20200101000000/https://example.com/page>; rel="first memento"; datetime="Wed, 01 Jan 2020 00:00:00 GMT"`)
+		w.Header().Add("Link", `<https://archive.example/20240301000000/https://example.com/page>; rel="last memento"; datetime="Fri, 01 Mar 2024 00:00:00 GMT"`)
+		w.Header().Set("Location", "https://archive.example/20240301000000/https://example.com/page")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	timeGate := NewTimeGate(server.URL+"/timegate/", time.Hour, "skip")
+
+	URL, err := url.Parse("https://example.com/page")
+	assert.NoError(t, err)
+
+	latest, err := timeGate.latestMemento(URL, "Zeno")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), latest.UTC())
+}
@@ -0,0 +1,134 @@
+package crawl
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/upload"
+)
+
+// reportFiles are the reports that can be produced in the job's directory,
+// they are uploaded at the end of the crawl along with the CDX files
+var reportFiles = []string{"seeds.csv", "seeds-validation.csv", "host-security.csv", "graph.csv", "attachments.csv", "deferred.csv", "verification.csv", "warcs-manifest.csv", "logs/crawl.log"}
+
+// UploadState keeps track of the files already uploaded, it is persisted
+// in the job's directory so that a resumed crawl doesn't upload them again
+type UploadState struct {
+	sync.Mutex
+	uploader upload.Uploader
+	path     string
+	uploaded map[string]bool
+}
+
+// NewUploadState load the list of the files already uploaded by the job
+func NewUploadState(jobPath string, uploader upload.Uploader) (*UploadState, error) {
+	state := &UploadState{
+		uploader: uploader,
+		path:     path.Join(jobPath, "uploaded.txt"),
+		uploaded: make(map[string]bool),
+	}
+
+	file, err := os.Open(state.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		state.uploaded[scanner.Text()] = true
+	}
+
+	return state, scanner.Err()
+}
+
+// upload the files that haven't been uploaded yet
+func (state *UploadState) upload(filePaths []string) error {
+	state.Lock()
+	defer state.Unlock()
+
+	for _, filePath := range filePaths {
+		if state.uploaded[filePath] {
+			continue
+		}
+
+		err := state.uploader.Upload(filePath)
+		if err != nil {
+			return err
+		}
+
+		state.uploaded[filePath] = true
+
+		file, err := os.OpenFile(state.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+
+		_, err = file.WriteString(filePath + "\n")
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uploadFinishedWARCs upload the WARC files that have been finished since the last call,
+// the files still being written have a .open suffix and are ignored
+func (c *Crawl) uploadFinishedWARCs() error {
+	WARCPaths, err := filepath.Glob(path.Join(c.JobPath, "warcs", "*.warc.gz"))
+	if err != nil {
+		return err
+	}
+
+	return c.UploadState.upload(WARCPaths)
+}
+
+// uploadReports upload the CDX files and the reports produced in the job's directory,
+// the reports are uploaded again every time as they are rewritten when a crawl is resumed
+func (c *Crawl) uploadReports() error {
+	var filePaths []string
+
+	for _, report := range reportFiles {
+		reportPath := path.Join(c.JobPath, report)
+		if _, err := os.Stat(reportPath); err != nil {
+			continue
+		}
+
+		err := c.UploadState.uploader.Upload(reportPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pattern := range []string{"*.cdx", "*.cdx.gz", path.Join("warcs", "*.cdx"), path.Join("warcs", "*.cdx.gz")} {
+		matches, err := filepath.Glob(path.Join(c.JobPath, pattern))
+		if err != nil {
+			return err
+		}
+
+		filePaths = append(filePaths, matches...)
+	}
+
+	return c.UploadState.upload(filePaths)
+}
+
+// uploadWARCs periodically upload the finished WARC files
+func (c *Crawl) uploadWARCs() {
+	for {
+		err := c.uploadFinishedWARCs()
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, nil)).Error("unable to upload the finished WARC files")
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
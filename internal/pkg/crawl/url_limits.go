@@ -0,0 +1,25 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// isURLWithinLimits return false if the URL exceeds --max-url-length or
+// --max-query-params, those URLs are usually generated by broken templates
+// and lead to crawler traps, they are counted as rejected
+func (c *Crawl) isURLWithinLimits(URL *url.URL) bool {
+	if c.MaxURLLength > 0 && len(utils.URLToString(URL)) > c.MaxURLLength {
+		c.RejectedURLs.Incr(1)
+		return false
+	}
+
+	if c.MaxQueryParams > 0 && URL.RawQuery != "" && len(strings.Split(URL.RawQuery, "&")) > c.MaxQueryParams {
+		c.RejectedURLs.Incr(1)
+		return false
+	}
+
+	return true
+}
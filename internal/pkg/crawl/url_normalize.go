@@ -0,0 +1,56 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultTrackingParams are the query parameters stripped by --strip-tracking-params
+// when no --tracking-param is specified, a trailing * matches any suffix
+var DefaultTrackingParams = []string{"utm_*", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "_ga", "yclid", "igshid"}
+
+// normalizeURL strip the fragment of the URL, as it is never sent to the server,
+// and the tracking parameters if --strip-tracking-params is enabled. With
+// --host-security, the http:// URLs of the HSTS hosts are upgraded to https://.
+func (c *Crawl) normalizeURL(URL *url.URL) {
+	URL.Fragment = ""
+	URL.RawFragment = ""
+
+	c.HostSecurity.upgrade(URL)
+
+	if !c.StripTrackingParams || URL.RawQuery == "" {
+		return
+	}
+
+	query := URL.Query()
+	stripped := false
+
+	for param := range query {
+		if c.isTrackingParam(param) {
+			query.Del(param)
+			stripped = true
+		}
+	}
+
+	if stripped {
+		URL.RawQuery = query.Encode()
+	}
+}
+
+func (c *Crawl) isTrackingParam(param string) bool {
+	param = strings.ToLower(param)
+
+	for _, trackingParam := range c.TrackingParams {
+		trackingParam = strings.ToLower(trackingParam)
+
+		if strings.HasSuffix(trackingParam, "*") {
+			if strings.HasPrefix(param, strings.TrimSuffix(trackingParam, "*")) {
+				return true
+			}
+		} else if param == trackingParam {
+			return true
+		}
+	}
+
+	return false
+}
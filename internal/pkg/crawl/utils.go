@@ -1,21 +1,28 @@
 package crawl
 
 import (
+	"math"
 	"net/url"
-	"regexp"
+	"os"
+	"path"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
-	"github.com/sirupsen/logrus"
 	"github.com/zeebo/xxh3"
+	"mvdan.cc/xurls/v2"
 )
 
-var regexOutlinks *regexp.Regexp
+// regexOutlinks matches the URLs found in scripts and text, it is used by
+// the crawl as well as by VerifyCaptures, which doesn't go through Start
+var regexOutlinks = xurls.Relaxed()
 
 func (c *Crawl) writeFrontierToDisk() {
 	for !c.Finished.Get() {
 		c.Frontier.Save()
+		c.writeSeedsReport()
 		time.Sleep(time.Minute * 1)
 	}
 }
@@ -24,10 +31,14 @@ func (c *Crawl) crawlSpeedLimiter() {
 	maxConcurrentAssets := c.MaxConcurrentAssets
 
 	for {
-		if c.Client.WaitGroup.Size() > c.Workers*8 {
+		c.beat()
+
+		// The disk space monitor and the crawl window are responsible for pausing the crawl when
+		// the disk is full or outside of the window, we don't want to resume the crawl then
+		if c.DiskFull.Get() || c.OutsideWindow.Get() || c.getWARCWritingQueueSize() > c.WARCQueueSize {
 			c.Paused.Set(true)
 			c.Frontier.Paused.Set(true)
-		} else if c.Client.WaitGroup.Size() > c.Workers*4 {
+		} else if c.getWARCWritingQueueSize() > c.WARCQueueSize/2 {
 			c.MaxConcurrentAssets = 1
 			c.Paused.Set(false)
 			c.Frontier.Paused.Set(false)
@@ -42,21 +53,56 @@ func (c *Crawl) crawlSpeedLimiter() {
 }
 
 func (c *Crawl) checkIncludedHosts(host string) bool {
+	includedHosts := c.getIncludedHosts()
+
 	// If no hosts are included, all hosts are included
-	if len(c.IncludedHosts) == 0 {
+	if len(includedHosts) == 0 {
 		return true
 	}
 
-	return utils.StringInSlice(host, c.IncludedHosts)
+	return utils.StringInSlice(host, includedHosts)
 }
 
+// handleCrawlPause monitor the free space on the volumes where the WARC files and the
+// WARC temporary files are written, and pause the crawl when it goes below --min-space-required.
+// The crawl is resumed when the free space goes 10% above the threshold, to avoid flapping.
 func (c *Crawl) handleCrawlPause() {
+	var (
+		threshold       = float64(c.MinSpaceRequired) * float64(GB)
+		resumeThreshold = threshold * 1.1
+		diskFullSince   time.Time
+	)
+
 	for {
-		if float64(utils.GetFreeDiskSpace(c.JobPath).Avail)/float64(GB) <= 20 {
-			logrus.Errorln("Not enough disk space. Please free some space and restart the crawler.")
+		var minAvailable = math.MaxFloat64
+
+		for _, directory := range []string{path.Join(c.JobPath, "warcs"), c.WARCTempDir, c.JobPath} {
+			if _, err := os.Stat(directory); err != nil {
+				continue
+			}
+
+			minAvailable = math.Min(minAvailable, float64(utils.GetFreeDiskSpace(directory).Avail))
+		}
+
+		if !c.DiskFull.Get() && minAvailable <= threshold {
+			logError.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"availableSpace": humanize.Bytes(uint64(minAvailable)),
+				"requiredSpace":  humanize.Bytes(uint64(threshold)),
+			})).Error("not enough disk space, pausing the crawl until some space is freed")
+
+			c.DiskFull.Set(true)
 			c.Paused.Set(true)
 			c.Frontier.Paused.Set(true)
-		} else {
+
+			diskFullSince = time.Now()
+		} else if c.DiskFull.Get() && c.DiskFullAbort > 0 && minAvailable <= resumeThreshold && time.Since(diskFullSince) > c.DiskFullAbort {
+			c.abortOnDiskFull()
+		} else if c.DiskFull.Get() && minAvailable > resumeThreshold {
+			logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"availableSpace": humanize.Bytes(uint64(minAvailable)),
+			})).Info("disk space freed, resuming the crawl")
+
+			c.DiskFull.Set(false)
 			c.Paused.Set(false)
 			c.Frontier.Paused.Set(false)
 		}
@@ -78,7 +124,7 @@ func (c *Crawl) seencheckURL(URL string, URLType string) bool {
 
 func (c *Crawl) excludeHosts(URLs []*url.URL) (output []*url.URL) {
 	for _, URL := range URLs {
-		if utils.StringInSlice(URL.Host, c.ExcludedHosts) || !c.checkIncludedHosts(URL.Host) {
+		if utils.StringInSlice(URL.Host, c.getExcludedHosts()) || !c.checkIncludedHosts(URL.Host) {
 			continue
 		} else {
 			output = append(output, URL)
@@ -110,8 +156,12 @@ func extractLinksFromText(source string) (links []*url.URL) {
 	return links
 }
 
-func (c *Crawl) shouldPause(host string) bool {
-	return c.Frontier.GetActiveHostCount(host) >= c.MaxConcurrentRequestsPerDomain
+func (c *Crawl) shouldPause(politenessKey string) bool {
+	if strings.HasPrefix(politenessKey, cdnPolitenessPrefix) {
+		return c.Frontier.GetActiveHostCount(politenessKey) >= c.MaxConcurrentRequestsPerCDN
+	}
+
+	return c.Frontier.GetActiveHostCount(politenessKey) >= c.getMaxConcurrentRequestsPerDomain()
 }
 
 func isStatusCodeRedirect(statusCode int) bool {
@@ -0,0 +1,255 @@
+package crawl
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// PageVerification is the replay completeness of a captured page: the number of
+// assets referenced by the page and how many of them are missing from the WARCs
+type PageVerification struct {
+	URL     string
+	Assets  int
+	Missing int
+}
+
+// Completeness return the ratio of the assets of the page that have been captured
+func (page *PageVerification) Completeness() float64 {
+	if page.Assets == 0 {
+		return 1
+	}
+
+	return float64(page.Assets-page.Missing) / float64(page.Assets)
+}
+
+// VerifyCaptures replay a sample of the HTML pages captured in the job's WARC files
+// and check that the assets they reference have been captured too. If a seed list
+// is given, the captured seeds are verified instead of a random sample, giving the
+// replay completeness of every seed. The report is written to verification.csv.
+func (c *Crawl) VerifyCaptures(sampleSize int) (pages []*PageVerification, err error) {
+	logInfo, logWarning, logError = utils.SetupLogging(c.JobPath, false, c.ElasticSearchURL, c.SyslogAddress, c.Journald)
+
+	WARCPaths, err := filepath.Glob(path.Join(c.JobPath, "warcs", "*.warc.gz"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(WARCPaths) == 0 {
+		return nil, errors.New("no finished WARC file in the job's directory")
+	}
+
+	// First pass: index the captured URLs and list the HTML pages
+	var (
+		captured  = make(map[string]bool)
+		HTMLPages []string
+	)
+
+	for _, WARCPath := range WARCPaths {
+		err = readWARCRecords(WARCPath, func(record *warc.Record) {
+			URI := record.Header.Get("WARC-Target-URI")
+
+			switch record.Header.Get("WARC-Type") {
+			case "revisit", "resource":
+				captured[URI] = true
+			case "response":
+				captured[URI] = true
+
+				resp, err := http.ReadResponse(bufio.NewReader(record.Content), nil)
+				if err != nil {
+					return
+				}
+				resp.Body.Close()
+
+				if resp.StatusCode == 200 && strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+					HTMLPages = append(HTMLPages, URI)
+				}
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Pick the pages to verify
+	toVerify := make(map[string]bool)
+
+	if len(c.SeedList) > 0 {
+		seeds := make(map[string]bool)
+		for _, seed := range c.SeedList {
+			seeds[utils.URLToString(seed.URL)] = true
+		}
+
+		for _, page := range HTMLPages {
+			if seeds[page] {
+				toVerify[page] = true
+			}
+		}
+	} else {
+		rand.Shuffle(len(HTMLPages), func(i, j int) {
+			HTMLPages[i], HTMLPages[j] = HTMLPages[j], HTMLPages[i]
+		})
+
+		for i := 0; i < len(HTMLPages) && i < sampleSize; i++ {
+			toVerify[HTMLPages[i]] = true
+		}
+	}
+
+	// Second pass: extract the assets of the pages to verify and check them against the index
+	for _, WARCPath := range WARCPaths {
+		err = readWARCRecords(WARCPath, func(record *warc.Record) {
+			URI := record.Header.Get("WARC-Target-URI")
+
+			if record.Header.Get("WARC-Type") != "response" || !toVerify[URI] {
+				return
+			}
+
+			// The page may have been captured multiple times
+			delete(toVerify, URI)
+
+			page, err := c.verifyPage(URI, record, captured)
+			if err != nil {
+				logWarning.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+					"url": URI,
+				})).Warn("unable to verify page")
+				return
+			}
+
+			pages = append(pages, page)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].URL < pages[j].URL
+	})
+
+	return pages, writeVerificationReport(c.JobPath, pages)
+}
+
+func (c *Crawl) verifyPage(URI string, record *warc.Record, captured map[string]bool) (*PageVerification, error) {
+	URL, err := url.Parse(URI)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.ReadResponse(bufio.NewReader(record.Content), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The records hold the response as it was sent by the server
+	var body io.Reader = resp.Body
+	switch resp.Header.Get("Content-Encoding") {
+	case "":
+	case "gzip":
+		gzipReader, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		defer gzipReader.Close()
+
+		body = gzipReader
+	default:
+		return nil, fmt.Errorf("unsupported content encoding: %s", resp.Header.Get("Content-Encoding"))
+	}
+
+	item := frontier.NewItem(URL, nil, "seed", 0, "", false)
+
+	_, assets, _, _ := c.extractWithTokenizer(URL, item, body, nil)
+
+	for _, asset := range assets {
+		c.normalizeURL(asset)
+	}
+
+	assets = dedupeAssets(item, assets)
+
+	page := &PageVerification{URL: URI, Assets: len(assets)}
+
+	for _, asset := range assets {
+		if !captured[utils.URLToString(asset)] {
+			page.Missing++
+		}
+	}
+
+	return page, nil
+}
+
+// readWARCRecords call the given function on every record of a WARC file
+func readWARCRecords(WARCPath string, fn func(record *warc.Record)) error {
+	file, err := os.Open(WARCPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, err := warc.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		record, err := reader.ReadRecord()
+		if record != nil && record.Header != nil {
+			fn(record)
+			record.Content.Close()
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func writeVerificationReport(jobPath string, pages []*PageVerification) error {
+	file, err := os.Create(path.Join(jobPath, "verification.csv"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	err = writer.Write([]string{"url", "assets", "missing", "completeness"})
+	if err != nil {
+		return err
+	}
+
+	for _, page := range pages {
+		err = writer.Write([]string{
+			page.URL,
+			strconv.Itoa(page.Assets),
+			strconv.Itoa(page.Missing),
+			strconv.FormatFloat(page.Completeness(), 'f', 2, 64),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
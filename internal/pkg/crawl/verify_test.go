@@ -0,0 +1,46 @@
+package crawl
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyCapturesPageWithScript(t *testing.T) {
+	var (
+		jobPath = t.TempDir()
+		WARC    []byte
+	)
+
+	page := `<html><head><script>fetch("https://cdn.example.com/app.js")</script></head>` +
+		`<body><img src="/logo.png"><a href="mailto:someone@example.com">Contact</a></body></html>`
+
+	for _, record := range []string{
+		"WARC/1.1\r\nWARC-Type: response\r\nWARC-Target-URI: https://example.com/\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Type: text/html; charset=utf-8\r\n\r\n" + page + "\r\n\r\n",
+		"WARC/1.1\r\nWARC-Type: response\r\nWARC-Target-URI: https://cdn.example.com/app.js\r\n\r\n" +
+			"HTTP/1.1 200 OK\r\nContent-Type: text/javascript\r\n\r\nconsole.log(1)\r\n\r\n",
+	} {
+		WARC = append(WARC, gzipMember(t, record)...)
+	}
+
+	assert.NoError(t, os.MkdirAll(path.Join(jobPath, "warcs"), 0755))
+	assert.NoError(t, os.WriteFile(path.Join(jobPath, "warcs", "test.warc.gz"), WARC, 0644))
+
+	// The crawl isn't started, the verification works on its own
+	c := &Crawl{JobPath: jobPath}
+
+	pages, err := c.VerifyCaptures(10)
+	assert.NoError(t, err)
+	assert.Len(t, pages, 1)
+
+	// The script's asset is captured, the image isn't
+	assert.Equal(t, "https://example.com/", pages[0].URL)
+	assert.Equal(t, 2, pages[0].Assets)
+	assert.Equal(t, 1, pages[0].Missing)
+
+	_, err = os.Stat(path.Join(jobPath, "verification.csv"))
+	assert.NoError(t, err)
+}
@@ -2,19 +2,30 @@ package crawl
 
 import (
 	"fmt"
+	"os"
 	"path"
+	"time"
 
 	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
+	"github.com/zeebo/xxh3"
 )
 
 func (c *Crawl) initWARCRotatorSettings() *warc.RotatorSettings {
 	var rotatorSettings = warc.NewRotatorSettings()
 
-	rotatorSettings.OutputDirectory = path.Join(c.JobPath, "warcs")
+	rotatorSettings.OutputDirectory = c.WARCSpoolDir
+	if c.DryRun {
+		// The rotator always creates a WARC file with a warcinfo record,
+		// it is written aside and removed when the crawl finishes
+		rotatorSettings.OutputDirectory = path.Join(c.JobPath, "dry-run")
+	}
 	rotatorSettings.Compression = "GZIP"
 	rotatorSettings.Prefix = c.WARCPrefix
 	rotatorSettings.WarcinfoContent.Set("software", fmt.Sprintf("Zeno %s", utils.GetVersion().Version))
+	rotatorSettings.WarcinfoContent.Set("isPartOf", c.Job)
+	rotatorSettings.WarcinfoContent.Set("job-id", c.JobID)
 	rotatorSettings.WARCWriterPoolSize = c.WARCPoolSize
 
 	if len(c.WARCOperator) > 0 {
@@ -23,3 +34,185 @@ func (c *Crawl) initWARCRotatorSettings() *warc.RotatorSettings {
 
 	return rotatorSettings
 }
+
+func (c *Crawl) newWARCWritingHTTPClient(HTTPClientSettings warc.HTTPClientSettings) (client *warc.CustomHTTPClient, err error) {
+	client, err = warc.NewWARCWritingHTTPClient(HTTPClientSettings)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for err := range client.ErrChan {
+			logError.WithFields(c.genLogFields(err, nil, nil)).Errorf("WARC HTTP client error")
+		}
+	}()
+
+	client.Timeout = time.Duration(c.HTTPTimeout) * time.Second
+
+	if c.DryRun {
+		c.discardWARCRecords(client)
+	} else {
+		c.boundWARCWritingQueue(client)
+	}
+
+	return client, nil
+}
+
+// discardWARCRecords drop the records produced by the client instead of writing
+// them, it is used with --dry-run to fetch the pages without archiving them
+func (c *Crawl) discardWARCRecords(client *warc.CustomHTTPClient) {
+	var (
+		writers = client.WARCWriter
+		queue   = make(chan *warc.RecordBatch, c.WARCQueueSize)
+	)
+
+	client.WARCWriter = queue
+
+	go func() {
+		for batch := range queue {
+			for _, record := range batch.Records {
+				record.Content.Close()
+			}
+
+			if batch.Done != nil {
+				batch.Done <- true
+			}
+		}
+
+		close(writers)
+	}()
+}
+
+// boundWARCWritingQueue interpose a bounded queue between the captures and the WARC writers
+// of the client. When the writers can't keep up, the queue fills up, the number of records
+// waiting to be written grows and the crawl speed limiter slows down or pauses the crawl.
+// The time spent waiting for the writers is recorded to help diagnose slow disks.
+func (c *Crawl) boundWARCWritingQueue(client *warc.CustomHTTPClient) {
+	var (
+		writers = client.WARCWriter
+		queue   = make(chan *warc.RecordBatch, c.WARCQueueSize)
+	)
+
+	client.WARCWriter = queue
+
+	write := func(batch *warc.RecordBatch) {
+		c.countWrittenBytes(batch)
+
+		blockingStart := time.Now()
+
+		writers <- batch
+
+		blockingTime := time.Since(blockingStart)
+		c.WARCWritingBlockedTime.Incr(int64(blockingTime))
+
+		if c.Prometheus && c.PrometheusMetrics.WARCWritingBlockedTime != nil {
+			c.PrometheusMetrics.WARCWritingBlockedTime.Add(blockingTime.Seconds())
+		}
+	}
+
+	go func() {
+		links := newConcurrentRecords()
+
+		for batch := range queue {
+			for _, ready := range links.process(batch) {
+				write(ready)
+			}
+		}
+
+		for _, ready := range links.flush() {
+			write(ready)
+		}
+
+		// The queue is closed when the client is closed, we propagate
+		// that to the writers so they can finish their WARC files
+		close(writers)
+	}()
+}
+
+// setCaptureID tags a record written by Zeno (and not by the WARC client itself) with
+// the ID of the capture it belongs to, so it can be found from the logs and the reports
+func setCaptureID(record *warc.Record, item *frontier.Item) {
+	if item.CaptureID != "" {
+		record.Header.Set("Zeno-Capture-ID", item.CaptureID)
+	}
+}
+
+// getCaptureClient return the client capturing a request of the item to the host: with
+// --proxy, the requests to the hosts that aren't bypassed go through a proxied client,
+// the one of the item's collection if it has one, the shared proxied client otherwise
+func (c *Crawl) getCaptureClient(item *frontier.Item, host string) *warc.CustomHTTPClient {
+	if c.ClientProxied == nil || utils.StringContainsSliceElements(host, c.BypassProxy) {
+		return c.getWARCClient(item)
+	}
+
+	if client, ok := c.CollectionProxiedClients[item.Collection]; ok {
+		return client
+	}
+
+	return c.ClientProxied
+}
+
+// getWARCClient return the client that should be used to capture the item. The
+// items of a collection given with --warc-collection use the client of the collection,
+// otherwise if --warc-writers-routing is set, the records are routed to one of the
+// WARC writers depending on the host of the item or the worker capturing it
+func (c *Crawl) getWARCClient(item *frontier.Item) *warc.CustomHTTPClient {
+	if client, ok := c.CollectionClients[item.Collection]; ok {
+		return client
+	}
+
+	switch c.WARCWritersRouting {
+	case "host":
+		return c.Clients[xxh3.HashString(item.Host)%uint64(len(c.Clients))]
+	case "worker":
+		return c.Clients[workerID(item)%len(c.Clients)]
+	default:
+		return c.Client
+	}
+}
+
+// getWARCWritingQueueSize return the number of records waiting to be written, across all clients:
+// the connections still being recorded by the clients, and the batches of records waiting in the
+// bounded queues, that the clients don't count anymore once they handed them over
+func (c *Crawl) getWARCWritingQueueSize() (size int) {
+	for _, client := range c.getWARCClients() {
+		size += client.WaitGroup.Size() + len(client.WARCWriter)
+	}
+
+	return size
+}
+
+// getWARCClients return all the WARC writing clients, including the proxied one
+func (c *Crawl) getWARCClients() (clients []*warc.CustomHTTPClient) {
+	if len(c.Clients) == 0 {
+		clients = append(clients, c.Client)
+	} else {
+		clients = append(clients, c.Clients...)
+	}
+
+	for _, collection := range c.WARCCollections {
+		clients = append(clients, c.CollectionClients[collection])
+
+		if client, ok := c.CollectionProxiedClients[collection]; ok {
+			clients = append(clients, client)
+		}
+	}
+
+	if c.ClientProxied != nil {
+		clients = append(clients, c.ClientProxied)
+	}
+
+	return clients
+}
+
+func (c *Crawl) closeWARCClients() {
+	for _, client := range c.getWARCClients() {
+		client.Close()
+	}
+}
+
+// removeDryRunWARCs remove the WARC files created by the rotators during a dry run,
+// they only contain a warcinfo record
+func (c *Crawl) removeDryRunWARCs() error {
+	return os.RemoveAll(path.Join(c.JobPath, "dry-run"))
+}
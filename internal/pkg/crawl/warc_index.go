@@ -0,0 +1,222 @@
+package crawl
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// cdxHeader is the first line of the CDX files written next to the finished WARC
+// files: SURT, timestamp, original URL, MIME type, status, digest, redirect, meta
+// tags, compressed length, offset and WARC file name
+const cdxHeader = " CDX N b a m s k r M S V g"
+
+var indexWARCsLock sync.Mutex
+
+// indexWARCs periodically write the CDX index of the finished WARC files
+func (c *Crawl) indexWARCs() {
+	for {
+		err := indexWARCDirectory(path.Join(c.JobPath, "warcs"))
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, nil)).Error("unable to index the WARC files")
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
+
+// indexWARCDirectory write a CDX file next to every finished WARC file of the directory
+// that isn't indexed yet, the files still being written have a .open suffix and are ignored
+func indexWARCDirectory(WARCDirectory string) error {
+	indexWARCsLock.Lock()
+	defer indexWARCsLock.Unlock()
+
+	WARCPaths, err := filepath.Glob(path.Join(WARCDirectory, "*.warc.gz"))
+	if err != nil {
+		return err
+	}
+
+	for _, WARCPath := range WARCPaths {
+		CDXPath := strings.TrimSuffix(WARCPath, ".warc.gz") + ".cdx"
+
+		_, err = os.Stat(CDXPath)
+		if err == nil {
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		lines, err := indexWARC(WARCPath)
+		if err != nil {
+			return err
+		}
+
+		// The index is written under a temporary name so that an interrupted
+		// write is never mistaken for a complete index
+		err = os.WriteFile(CDXPath+".open", []byte(cdxHeader+"\n"+strings.Join(lines, "")), 0644)
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(CDXPath+".open", CDXPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexWARC return the sorted CDX lines of the response and revisit records of a
+// gzipped WARC file. Every record is compressed as its own gzip member, the offset
+// and the length of a record are the position and the size of its member.
+func indexWARC(WARCPath string) (lines []string, err error) {
+	file, err := os.Open(WARCPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// gzip doesn't read ahead when the reader is an io.ByteReader, the
+	// count is then exactly the end of the last member read
+	counter := &countingReader{reader: bufio.NewReader(file)}
+
+	gzipReader, err := gzip.NewReader(counter)
+	if err != nil {
+		return nil, err
+	}
+	defer gzipReader.Close()
+
+	var offset int64
+
+	for {
+		gzipReader.Multistream(false)
+
+		fields, err := indexWARCRecord(gzipReader)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = io.Copy(io.Discard, gzipReader)
+		if err != nil {
+			return nil, err
+		}
+
+		if fields != nil {
+			fields = append(fields, strconv.FormatInt(counter.count-offset, 10), strconv.FormatInt(offset, 10), path.Base(WARCPath))
+			lines = append(lines, strings.Join(fields, " ")+"\n")
+		}
+
+		offset = counter.count
+
+		err = gzipReader.Reset(counter)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Strings(lines)
+
+	return lines, nil
+}
+
+// indexWARCRecord return the CDX fields of a record up to the redirect and meta tags,
+// or nil if the record isn't a response or a revisit
+func indexWARCRecord(record io.Reader) (fields []string, err error) {
+	reader := textproto.NewReader(bufio.NewReader(record))
+
+	_, err = reader.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	WARCType := header.Get("WARC-Type")
+	if WARCType != "response" && WARCType != "revisit" {
+		return nil, nil
+	}
+
+	URI := header.Get("WARC-Target-URI")
+
+	URL, err := url.Parse(URI)
+	if err != nil {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.RFC3339Nano, header.Get("WARC-Date"))
+	if err != nil {
+		return nil, nil
+	}
+
+	var (
+		MIMEType = "-"
+		status   = "-"
+		redirect = "-"
+		digest   = "-"
+	)
+
+	if resp, err := http.ReadResponse(reader.R, nil); err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+
+		if contentType := strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0]); contentType != "" {
+			MIMEType = strings.ToLower(contentType)
+		}
+
+		if location := resp.Header.Get("Location"); location != "" {
+			redirect = cdxEscape(location)
+		}
+	}
+
+	if WARCType == "revisit" {
+		MIMEType = "warc/revisit"
+	}
+
+	if payloadDigest := header.Get("WARC-Payload-Digest"); payloadDigest != "" {
+		digest = strings.TrimPrefix(payloadDigest, "sha1:")
+	}
+
+	return []string{
+		cdxEscape(surtToURLKey(utils.URLToSURT(URL))),
+		date.UTC().Format("20060102150405"),
+		cdxEscape(URI),
+		cdxEscape(MIMEType),
+		status,
+		digest,
+		redirect,
+		"-",
+	}, nil
+}
+
+// surtToURLKey turn a SURT into the form used as key by the CDX files:
+// (org,archive,www,)/details becomes org,archive,www)/details
+func surtToURLKey(SURT string) string {
+	key := strings.TrimPrefix(SURT, "(")
+	key = strings.Replace(key, ",:", ":", 1)
+
+	return strings.Replace(key, ",)", ")", 1)
+}
+
+// cdxEscape escape the spaces that would break the CDX line
+func cdxEscape(field string) string {
+	return strings.ReplaceAll(field, " ", "%20")
+}
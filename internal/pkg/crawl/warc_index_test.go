@@ -0,0 +1,57 @@
+package crawl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gzipMember(t *testing.T, record string) []byte {
+	var buffer bytes.Buffer
+
+	writer := gzip.NewWriter(&buffer)
+	_, err := writer.Write([]byte(record))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	return buffer.Bytes()
+}
+
+func TestIndexWARC(t *testing.T) {
+	var (
+		directory = t.TempDir()
+		WARC      []byte
+		offsets   []int
+	)
+
+	for _, record := range []string{
+		"WARC/1.1\r\nWARC-Type: warcinfo\r\n\r\nsoftware: Zeno\r\n\r\n\r\n",
+		"WARC/1.1\r\nWARC-Type: response\r\nWARC-Target-URI: https://www.example.com/a b\r\nWARC-Date: 2024-01-02T03:04:05Z\r\nWARC-Payload-Digest: sha1:ABC\r\n\r\nHTTP/1.1 301 Moved Permanently\r\nLocation: /b\r\nContent-Type: text/html; charset=utf-8\r\n\r\n\r\n\r\n",
+		"WARC/1.1\r\nWARC-Type: request\r\nWARC-Target-URI: https://www.example.com/a b\r\n\r\nGET /a%20b HTTP/1.1\r\n\r\n\r\n\r\n",
+		"WARC/1.1\r\nWARC-Type: revisit\r\nWARC-Target-URI: https://example.com/\r\nWARC-Date: 2024-01-02T03:04:05.123Z\r\n\r\nHTTP/1.1 200 OK\r\n\r\n\r\n\r\n",
+	} {
+		offsets = append(offsets, len(WARC))
+		WARC = append(WARC, gzipMember(t, record)...)
+	}
+	offsets = append(offsets, len(WARC))
+
+	assert.NoError(t, os.WriteFile(path.Join(directory, "test.warc.gz"), WARC, 0644))
+	assert.NoError(t, os.WriteFile(path.Join(directory, "test.warc.gz.open"), WARC, 0644))
+	assert.NoError(t, indexWARCDirectory(directory))
+
+	CDX, err := os.ReadFile(path.Join(directory, "test.cdx"))
+	assert.NoError(t, err)
+	assert.Equal(t, cdxHeader+"\n"+
+		"com,example)/ 20240102030405 https://example.com/ warc/revisit 200 - - - "+strconv.Itoa(offsets[4]-offsets[3])+" "+strconv.Itoa(offsets[3])+" test.warc.gz\n"+
+		"com,example,www)/a%20b 20240102030405 https://www.example.com/a%20b text/html 301 ABC /b - "+strconv.Itoa(offsets[2]-offsets[1])+" "+strconv.Itoa(offsets[1])+" test.warc.gz\n",
+		string(CDX))
+
+	// The files still being written aren't indexed
+	_, err = os.Stat(path.Join(directory, "test.warc.gz.cdx"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,185 @@
+package crawl
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WARCManifest keeps a manifest of the finished WARC files of the job, with the
+// SHA-256, the size and the number of records of each file. It simplifies the
+// integrity checks once the files are transferred to long-term storage.
+type WARCManifest struct {
+	sync.Mutex
+	path  string
+	files map[string]bool
+}
+
+// NewWARCManifest open the manifest of the job, the files already
+// listed in an existing manifest aren't processed again
+func NewWARCManifest(jobPath string) (*WARCManifest, error) {
+	manifest := &WARCManifest{
+		path:  path.Join(jobPath, "warcs-manifest.csv"),
+		files: make(map[string]bool),
+	}
+
+	file, err := os.Open(manifest.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	lines, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		manifest.files[line[0]] = true
+	}
+
+	return manifest, nil
+}
+
+// Update add the finished WARC files of the directory that aren't in the manifest yet,
+// the files still being written have a .open suffix and are ignored
+func (manifest *WARCManifest) Update(WARCDirectory string) error {
+	manifest.Lock()
+	defer manifest.Unlock()
+
+	WARCPaths, err := filepath.Glob(path.Join(WARCDirectory, "*.warc.gz"))
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(manifest.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	for _, WARCPath := range WARCPaths {
+		name := path.Base(WARCPath)
+		if manifest.files[name] {
+			continue
+		}
+
+		size, checksum, records, err := checksumWARC(WARCPath)
+		if err != nil {
+			return err
+		}
+
+		err = writer.Write([]string{name, strconv.FormatInt(size, 10), checksum, strconv.Itoa(records)})
+		if err != nil {
+			return err
+		}
+
+		manifest.files[name] = true
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// checksumWARC compute the size and the SHA-256 of a gzipped WARC file, and count
+// its records in the same pass: every record is compressed as its own gzip member
+func checksumWARC(WARCPath string) (size int64, checksum string, records int, err error) {
+	file, err := os.Open(WARCPath)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	defer file.Close()
+
+	var (
+		hash    = sha256.New()
+		counter = &countingReader{reader: file}
+		reader  = bufio.NewReader(io.TeeReader(counter, hash))
+	)
+
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return 0, "", 0, err
+	}
+	defer gzipReader.Close()
+
+	for {
+		gzipReader.Multistream(false)
+
+		_, err = io.Copy(io.Discard, gzipReader)
+		if err != nil {
+			return 0, "", 0, err
+		}
+
+		records++
+
+		err = gzipReader.Reset(reader)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, "", 0, err
+		}
+	}
+
+	// Hash whatever could follow the last gzip member
+	_, err = io.Copy(io.Discard, reader)
+	if err != nil {
+		return 0, "", 0, err
+	}
+
+	return counter.count, hex.EncodeToString(hash.Sum(nil)), records, nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// ReadByte make the reader an io.ByteReader, gzip then doesn't read ahead of
+// the member it decompresses, the underlying reader should be buffered
+func (r *countingReader) ReadByte() (b byte, err error) {
+	if byteReader, ok := r.reader.(io.ByteReader); ok {
+		b, err = byteReader.ReadByte()
+	} else {
+		var p [1]byte
+		_, err = io.ReadFull(r.reader, p[:])
+		b = p[0]
+	}
+
+	if err == nil {
+		r.count++
+	}
+	return b, err
+}
+
+// updateWARCManifest periodically add the finished WARC files to the manifest
+func (c *Crawl) updateWARCManifest() {
+	for {
+		err := c.WARCManifest.Update(path.Join(c.JobPath, "warcs"))
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, nil)).Error("unable to update the WARC manifest")
+		}
+
+		time.Sleep(time.Minute)
+	}
+}
@@ -0,0 +1,200 @@
+package crawl
+
+import (
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/CorentinB/warc"
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+)
+
+const (
+	// concurrentTargetHeader carries the target URI of the response a record written by
+	// Zeno is concurrent to, until the writing queue replaces it with WARC-Concurrent-To
+	concurrentTargetHeader = "Zeno-Concurrent-Target"
+
+	// concurrentRecordsTimeout is how long a record waits for the response it is concurrent
+	// to, the responses filtered by the WARC client (e.g. the 429s) are never written
+	concurrentRecordsTimeout = time.Minute
+
+	// concurrentResponsesSize is the number of recent responses remembered, for the
+	// records that reach the writing queue after the response they are concurrent to
+	concurrentResponsesSize = 1024
+)
+
+// writeWARCRecords send records written by Zeno (and not by the WARC client itself) to the
+// WARC writers of the item's client. The send is tracked by the client's wait group, so that
+// the client isn't closed meanwhile. If concurrentTo is given, the records are linked to the
+// response captured from that URL with WARC-Concurrent-To, they are written by the client
+// that captured the response (e.g. the proxied one) since only its queue knows the record.
+func (c *Crawl) writeWARCRecords(item *frontier.Item, concurrentTo string, captureTime time.Time, records ...*warc.Record) {
+	client := c.getWARCClient(item)
+
+	if concurrentTo != "" {
+		if URL, err := url.Parse(concurrentTo); err == nil {
+			client = c.getCaptureClient(item, URL.Host)
+		}
+	}
+
+	client.WaitGroup.Add(1)
+	defer client.WaitGroup.Done()
+
+	for _, record := range records {
+		setCaptureID(record, item)
+
+		if concurrentTo != "" {
+			record.Header.Set(concurrentTargetHeader, concurrentTo)
+		}
+	}
+
+	client.WARCWriter <- &warc.RecordBatch{
+		Records:     records,
+		CaptureTime: captureTime.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+// concurrentRecords links the records written by Zeno to the response records written by
+// the WARC client, whose ID is only known once their batch reaches the writing queue. It is
+// only used by the goroutine of the writing queue.
+type concurrentRecords struct {
+	responses map[string]string
+	order     []string
+	pending   map[string][]pendingRecords
+}
+
+type pendingRecords struct {
+	batch *warc.RecordBatch
+	since time.Time
+}
+
+func newConcurrentRecords() *concurrentRecords {
+	return &concurrentRecords{
+		responses: make(map[string]string),
+		pending:   make(map[string][]pendingRecords),
+	}
+}
+
+// process return the batches ready to be written once the batch reached the queue: the
+// batches of records waiting for their response are held until it comes or times out
+func (links *concurrentRecords) process(batch *warc.RecordBatch) (ready []*warc.RecordBatch) {
+	now := time.Now()
+
+	if target := concurrentTarget(batch); target != "" {
+		target = concurrentKey(target)
+
+		if ID, known := links.responses[target]; known {
+			setConcurrentTo(batch, ID)
+			ready = append(ready, batch)
+		} else {
+			links.pending[target] = append(links.pending[target], pendingRecords{batch: batch, since: now})
+		}
+
+		return append(ready, links.expired(now)...)
+	}
+
+	ready = append(ready, batch)
+
+	for _, record := range batch.Records {
+		if recordType := record.Header.Get("WARC-Type"); recordType != "response" && recordType != "revisit" {
+			continue
+		}
+
+		var (
+			target = concurrentKey(record.Header.Get("WARC-Target-URI"))
+			ID     = record.Header.Get("WARC-Record-ID")
+		)
+
+		links.remember(target, ID)
+
+		for _, pending := range links.pending[target] {
+			setConcurrentTo(pending.batch, ID)
+			ready = append(ready, pending.batch)
+		}
+
+		delete(links.pending, target)
+	}
+
+	return append(ready, links.expired(now)...)
+}
+
+// flush return all the batches still waiting for their response, when the queue is closed
+func (links *concurrentRecords) flush() (ready []*warc.RecordBatch) {
+	for target, pending := range links.pending {
+		for _, records := range pending {
+			ready = append(ready, records.batch)
+		}
+
+		delete(links.pending, target)
+	}
+
+	return ready
+}
+
+func (links *concurrentRecords) expired(now time.Time) (ready []*warc.RecordBatch) {
+	for target, pending := range links.pending {
+		if now.Sub(pending[0].since) < concurrentRecordsTimeout {
+			continue
+		}
+
+		for _, records := range pending {
+			ready = append(ready, records.batch)
+		}
+
+		delete(links.pending, target)
+	}
+
+	return ready
+}
+
+func (links *concurrentRecords) remember(target, ID string) {
+	if _, known := links.responses[target]; !known {
+		links.order = append(links.order, target)
+	}
+
+	links.responses[target] = ID
+
+	if len(links.order) > concurrentResponsesSize {
+		delete(links.responses, links.order[0])
+		links.order = links.order[1:]
+	}
+}
+
+// concurrentTarget return the target URI of the response the records of the batch are
+// concurrent to, if any, and removes it from the headers of the records
+func concurrentTarget(batch *warc.RecordBatch) (target string) {
+	for _, record := range batch.Records {
+		if value := record.Header.Get(concurrentTargetHeader); value != "" {
+			target = value
+			record.Header.Del(concurrentTargetHeader)
+		}
+	}
+
+	return target
+}
+
+// concurrentKey normalize the target URIs, the WARC client builds them from the request
+// line and the Host header while Zeno builds them from the URL of the request
+func concurrentKey(target string) string {
+	URL, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	URL.Fragment = ""
+	URL.RawFragment = ""
+	URL.RawQuery = URL.Query().Encode()
+	URL.Host = strings.ToLower(URL.Host)
+
+	if (URL.Scheme == "http" && URL.Port() == "80") || (URL.Scheme == "https" && URL.Port() == "443") {
+		URL.Host = URL.Hostname()
+	}
+
+	return URL.String()
+}
+
+func setConcurrentTo(batch *warc.RecordBatch, ID string) {
+	for _, record := range batch.Records {
+		record.Header.Set("WARC-Concurrent-To", ID)
+	}
+}
@@ -0,0 +1,54 @@
+package crawl
+
+import (
+	"testing"
+
+	"github.com/CorentinB/warc"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestBatch(headers map[string]string) *warc.RecordBatch {
+	record := &warc.Record{Header: warc.NewHeader()}
+	for key, value := range headers {
+		record.Header.Set(key, value)
+	}
+
+	return &warc.RecordBatch{Records: []*warc.Record{record}}
+}
+
+func TestConcurrentRecords(t *testing.T) {
+	links := newConcurrentRecords()
+
+	// The metadata reaching the queue before its response waits for it
+	metadata := newTestBatch(map[string]string{
+		"WARC-Type":            "metadata",
+		concurrentTargetHeader: "https://Example.com:443/page?b=2&a=1",
+	})
+	assert.Empty(t, links.process(metadata))
+
+	response := newTestBatch(map[string]string{
+		"WARC-Type":       "response",
+		"WARC-Target-URI": "https://example.com/page?a=1&b=2",
+		"WARC-Record-ID":  "<urn:uuid:1>",
+	})
+	assert.Equal(t, []*warc.RecordBatch{response, metadata}, links.process(response))
+	assert.Equal(t, "<urn:uuid:1>", metadata.Records[0].Header.Get("WARC-Concurrent-To"))
+	assert.Equal(t, "", metadata.Records[0].Header.Get(concurrentTargetHeader))
+
+	// The metadata reaching the queue after its response is written right away
+	late := newTestBatch(map[string]string{
+		"WARC-Type":            "metadata",
+		concurrentTargetHeader: "https://example.com/page?a=1&b=2",
+	})
+	assert.Equal(t, []*warc.RecordBatch{late}, links.process(late))
+	assert.Equal(t, "<urn:uuid:1>", late.Records[0].Header.Get("WARC-Concurrent-To"))
+
+	// The metadata whose response never comes is written when the queue is closed
+	orphan := newTestBatch(map[string]string{
+		"WARC-Type":            "metadata",
+		concurrentTargetHeader: "https://example.com/429",
+	})
+	assert.Empty(t, links.process(orphan))
+	assert.Equal(t, []*warc.RecordBatch{orphan}, links.flush())
+	assert.Equal(t, "", orphan.Records[0].Header.Get("WARC-Concurrent-To"))
+}
@@ -0,0 +1,227 @@
+package crawl
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// moveWARCsLock serialize the moves of the finished WARC files, the spool watcher
+// and the end of the crawl would otherwise copy the same file at the same time
+var moveWARCsLock sync.Mutex
+
+// WARCSpoolWatcher stops the goroutine moving the finished WARC files out of the
+// spool directory, before the last move done at the end of the crawl
+type WARCSpoolWatcher struct {
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newWARCSpoolWatcher() *WARCSpoolWatcher {
+	return &WARCSpoolWatcher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Stop stop the watcher and wait for its current move to finish
+func (watcher *WARCSpoolWatcher) Stop() {
+	if watcher == nil {
+		return
+	}
+
+	watcher.stopOnce.Do(func() { close(watcher.stop) })
+	<-watcher.done
+}
+
+// recoverOpenWARCs repair the WARC files left open by a crash in the spool directory:
+// the file is truncated after its last complete record, then renamed to remove the
+// .open suffix. The files without any complete record are removed.
+func (c *Crawl) recoverOpenWARCs() {
+	openWARCPaths, err := filepath.Glob(path.Join(c.WARCSpoolDir, "*.warc.gz.open"))
+	if err != nil {
+		logError.WithFields(c.genLogFields(err, nil, nil)).Error("unable to list the open WARC files")
+		return
+	}
+
+	for _, openWARCPath := range openWARCPaths {
+		size, validSize, err := repairWARC(openWARCPath)
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+				"file": openWARCPath,
+			})).Error("unable to repair open WARC file")
+			continue
+		}
+
+		if validSize == 0 {
+			err = os.Remove(openWARCPath)
+		} else {
+			err = os.Rename(openWARCPath, strings.TrimSuffix(openWARCPath, ".open"))
+		}
+
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, map[string]interface{}{
+				"file": openWARCPath,
+			})).Error("unable to recover open WARC file")
+			continue
+		}
+
+		logWarning.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+			"file":           openWARCPath,
+			"size":           size,
+			"truncatedBytes": size - validSize,
+		})).Warn("open WARC file left by a previous run recovered")
+	}
+}
+
+// repairWARC truncate a gzipped WARC file after its last complete gzip member,
+// every record being compressed as its own member
+func repairWARC(WARCPath string) (size int64, validSize int64, err error) {
+	file, err := os.OpenFile(WARCPath, os.O_RDWR, 0644)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var (
+		counter = &countingReader{reader: file}
+		reader  = bufio.NewReader(counter)
+	)
+
+	// The decompressor reads the data byte by byte from the bufio.Reader, so
+	// the position in the file is what has been read minus what is buffered
+	gzipReader, err := gzip.NewReader(reader)
+	if err == nil {
+		for {
+			gzipReader.Multistream(false)
+
+			_, err = io.Copy(io.Discard, gzipReader)
+			if err != nil {
+				break
+			}
+
+			validSize = counter.count - int64(reader.Buffered())
+
+			err = gzipReader.Reset(reader)
+			if err != nil {
+				break
+			}
+		}
+	}
+
+	if validSize < stat.Size() {
+		err = file.Truncate(validSize)
+		if err != nil {
+			return stat.Size(), validSize, err
+		}
+	}
+
+	return stat.Size(), validSize, nil
+}
+
+// moveFinishedWARCs move the finished WARC files from the spool directory to the
+// job's warcs directory, they are copied with a .open suffix and then renamed so
+// that a file without the suffix is always complete
+func (c *Crawl) moveFinishedWARCs() error {
+	moveWARCsLock.Lock()
+	defer moveWARCsLock.Unlock()
+
+	WARCDirectory := path.Join(c.JobPath, "warcs")
+
+	if path.Clean(c.WARCSpoolDir) == path.Clean(WARCDirectory) {
+		return nil
+	}
+
+	WARCPaths, err := filepath.Glob(path.Join(c.WARCSpoolDir, "*.warc.gz"))
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(WARCDirectory, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	for _, WARCPath := range WARCPaths {
+		destination := path.Join(WARCDirectory, path.Base(WARCPath))
+
+		// Renaming is enough when both directories are on the same filesystem
+		if os.Rename(WARCPath, destination) == nil {
+			continue
+		}
+
+		err = copyFile(WARCPath, destination+".open")
+		if err != nil {
+			return err
+		}
+
+		err = os.Rename(destination+".open", destination)
+		if err != nil {
+			return err
+		}
+
+		err = os.Remove(WARCPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func copyFile(source, destination string) error {
+	sourceFile, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer sourceFile.Close()
+
+	destinationFile, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(destinationFile, sourceFile)
+	if err != nil {
+		destinationFile.Close()
+		return err
+	}
+
+	err = destinationFile.Sync()
+	if err != nil {
+		destinationFile.Close()
+		return err
+	}
+
+	return destinationFile.Close()
+}
+
+// watchWARCSpool periodically move the finished WARC files out of the spool directory
+func (c *Crawl) watchWARCSpool() {
+	defer close(c.WARCSpoolWatcher.done)
+
+	for {
+		err := c.moveFinishedWARCs()
+		if err != nil {
+			logError.WithFields(c.genLogFields(err, nil, nil)).Error("unable to move the finished WARC files out of the spool directory")
+		}
+
+		select {
+		case <-c.WARCSpoolWatcher.stop:
+			return
+		case <-time.After(10 * time.Second):
+		}
+	}
+}
@@ -3,6 +3,7 @@ package crawl
 import (
 	"time"
 
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
 )
 
@@ -20,12 +21,18 @@ const (
 // Worker is the key component of a crawl, it's a background processed dispatched
 // when the crawl starts, it listens on a channel to get new URLs to archive,
 // and eventually push newly discovered URLs back in the frontier.
-func (c *Crawl) Worker() {
+// The worker stops when the stop channel is closed, e.g. when the pool is scaled down.
+func (c *Crawl) Worker(ID int, stop chan struct{}) {
 	defer c.WorkerPool.Done()
 
+	var fairness hostFairness
+
 	// Start archiving the URLs!
-	for item := range c.Frontier.PullChan {
-		item := item
+	for {
+		item := c.pullItem(stop, &fairness)
+		if item == nil {
+			return
+		}
 
 		// Check if the crawl is paused
 		for c.Paused.Get() {
@@ -33,12 +40,55 @@ func (c *Crawl) Worker() {
 		}
 
 		// If the host of the item is in the host exclusion list, we skip it
-		if utils.StringInSlice(item.Host, c.ExcludedHosts) || !c.checkIncludedHosts(item.Host) {
-			if c.UseHQ {
-				// If we are using the HQ, we want to mark the item as done
-				c.HQFinishedChannel <- item
+		// The items with a seed scope have been checked against it when they were queued
+		if c.getItemScope(item) == nil && (utils.StringInSlice(item.Host, c.getExcludedHosts()) || !c.checkIncludedHosts(item.Host)) {
+			// Mark the item as done for HQ or the queue backend
+			c.markItemDone(item)
+
+			continue
+		}
+
+		// The items past their deadline aren't worth capturing anymore
+		if c.dropExpiredItem(item) {
+			continue
+		}
+
+		// If the host failed too many times in a row, the item is dropped or deferred
+		if c.CircuitBreaker != nil && c.CircuitBreaker.isOpen(item.Host) {
+			c.handleOpenCircuit(item)
+			continue
+		}
+
+		// Once the time budget of the host is spent, its remaining items are deferred
+		if c.checkHostTimeBudget(item) {
+			continue
+		}
+
+		// With --timegate, the items recently captured by another archive are skipped or demoted
+		if c.checkTimeGate(item) {
+			continue
+		}
+
+		item.WorkerID = ID
+
+		// Record how long the item waited in the queue
+		if !item.EnqueuedAt.IsZero() {
+			queueAge := time.Since(item.EnqueuedAt)
+
+			c.Frontier.QueueAge.Add(queueAge)
+
+			if c.Prometheus && c.PrometheusMetrics.QueueAge != nil {
+				c.PrometheusMetrics.QueueAge.Observe(queueAge.Seconds())
 			}
+		}
 
+		// With --target-rate, the dispatch of the items is paced
+		if c.TargetRate != nil {
+			c.TargetRate.wait()
+		}
+
+		// The items matching --media-url-pattern are captured by the media workers
+		if c.handOverToMediaLane(item, stop) {
 			continue
 		}
 
@@ -47,3 +97,70 @@ func (c *Crawl) Worker() {
 		c.ActiveWorkers.Incr(-1)
 	}
 }
+
+// hostFairness is the state used by a worker to enforce --max-consecutive-per-host
+type hostFairness struct {
+	host        string
+	consecutive int
+	held        *frontier.Item
+}
+
+// pullItem return the next item the worker should capture, or nil if the worker must stop.
+// With --max-consecutive-per-host, once the worker captured that many items of a host in a row,
+// the next item of that host is set aside until an item of another host is captured. If no
+// other item is waiting, the hosts can't be interleaved and the item set aside is captured.
+func (c *Crawl) pullItem(stop chan struct{}, fairness *hostFairness) (item *frontier.Item) {
+	for {
+		if fairness.held != nil && fairness.held.Host != fairness.host {
+			item, fairness.held = fairness.held, nil
+			break
+		}
+
+		if fairness.held != nil {
+			select {
+			case <-stop:
+				item, fairness.held = fairness.held, nil
+			case pulledItem, ok := <-c.Frontier.PullChan:
+				if !ok {
+					item, fairness.held = fairness.held, nil
+				} else if pulledItem.Host != fairness.host {
+					item = pulledItem
+				} else {
+					// Two items of the same host in a row, there is nothing to interleave them with
+					item, fairness.held = fairness.held, pulledItem
+				}
+			default:
+				item, fairness.held = fairness.held, nil
+			}
+
+			break
+		}
+
+		select {
+		case <-stop:
+			return nil
+		case pulledItem, ok := <-c.Frontier.PullChan:
+			if !ok {
+				return nil
+			}
+
+			item = pulledItem
+		}
+
+		if c.MaxConsecutivePerHost > 0 && item.Host == fairness.host && fairness.consecutive >= c.MaxConsecutivePerHost {
+			fairness.held = item
+			continue
+		}
+
+		break
+	}
+
+	if item.Host == fairness.host {
+		fairness.consecutive++
+	} else {
+		fairness.host = item.Host
+		fairness.consecutive = 1
+	}
+
+	return item
+}
@@ -0,0 +1,192 @@
+package crawl
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/CorentinB/warc"
+)
+
+// workerPoolScalingInterval is how often the size of the worker pool is re-evaluated
+const workerPoolScalingInterval = 10 * time.Second
+
+// WorkerPoolState holds the workers currently running, each one of them
+// having a channel that is closed to ask it to stop. The workers being
+// started are counted by starting, until they joined the worker pool.
+type WorkerPoolState struct {
+	sync.Mutex
+	workers  map[int]chan struct{}
+	starting sync.WaitGroup
+}
+
+// getWorkersCount return the number of workers currently running
+func (c *Crawl) getWorkersCount() int {
+	c.WorkerPoolState.Lock()
+	defer c.WorkerPoolState.Unlock()
+
+	return len(c.WorkerPoolState.workers)
+}
+
+// setWorkersCount start or stop workers to reach the given count, bounded
+// by --min-workers and --max-workers. When stopping workers, the ones with
+// the highest IDs are asked to stop once they finished their current capture.
+func (c *Crawl) setWorkersCount(count int) {
+	c.WorkerPoolState.Lock()
+	defer c.WorkerPoolState.Unlock()
+
+	// No worker is started once the crawl is finishing, the workers are then being waited for
+	if c.Finished.Get() {
+		return
+	}
+
+	if count < c.MinWorkers {
+		count = c.MinWorkers
+	}
+
+	if count > c.MaxWorkers {
+		count = c.MaxWorkers
+	}
+
+	if c.WorkerPoolState.workers == nil {
+		c.WorkerPoolState.workers = make(map[int]chan struct{})
+	}
+
+	for ID := 1; ID <= count; ID++ {
+		if _, running := c.WorkerPoolState.workers[ID]; running {
+			continue
+		}
+
+		stop := make(chan struct{})
+		c.WorkerPoolState.workers[ID] = stop
+		c.WorkerPoolState.starting.Add(1)
+
+		// The pool blocks until the stopped workers finished their current capture,
+		// the worker is started aside not to hold the lock of the pool state meanwhile
+		go func(ID int, stop chan struct{}) {
+			c.WorkerPool.Add()
+			c.WorkerPoolState.starting.Done()
+			c.Worker(ID, stop)
+		}(ID, stop)
+	}
+
+	for ID, stop := range c.WorkerPoolState.workers {
+		if ID > count {
+			close(stop)
+			delete(c.WorkerPoolState.workers, ID)
+		}
+	}
+}
+
+// setWorkersBounds change the --min-workers and --max-workers values, the
+// maximum can't exceed the size of the pool allocated when the crawl started
+func (c *Crawl) setWorkersBounds(min, max int) error {
+	if min < 1 || max < min {
+		return errors.New("invalid bounds, the minimum must be at least 1 and lower or equal to the maximum")
+	}
+
+	if max > c.WorkerPool.Size {
+		return errors.New("the maximum can't exceed the --max-workers value given at start")
+	}
+
+	c.WorkerPoolState.Lock()
+	if c.Finished.Get() {
+		c.WorkerPoolState.Unlock()
+		return errors.New("the crawl is finishing")
+	}
+
+	c.MinWorkers = min
+	c.MaxWorkers = max
+	c.WorkerPoolState.Unlock()
+
+	// Apply the new bounds to the current pool
+	c.setWorkersCount(c.getWorkersCount())
+
+	return nil
+}
+
+// setFinished mark the crawl as finishing, no worker is started afterwards
+func (c *Crawl) setFinished() {
+	c.WorkerPoolState.Lock()
+	defer c.WorkerPoolState.Unlock()
+
+	c.Finished.Set(true)
+}
+
+// waitWorkers wait for the workers being started to join the worker pool, then for
+// all the workers to finish, so that the pool is never added to while it's waited for
+func (c *Crawl) waitWorkers() {
+	c.WorkerPoolState.starting.Wait()
+	c.WorkerPool.Wait()
+}
+
+// scaleWorkers periodically adjust the number of workers between --min-workers
+// and --max-workers, based on the queue depth, the memory usage and the bandwidth
+func (c *Crawl) scaleWorkers() {
+	var (
+		m             runtime.MemStats
+		lastDataTotal = warc.DataTotal.Value()
+	)
+
+	for {
+		time.Sleep(workerPoolScalingInterval)
+
+		if c.Finished.Get() {
+			return
+		}
+
+		// Fixed-size pool, the bounds can still be changed through the API
+		c.WorkerPoolState.Lock()
+		fixedSize := c.MinWorkers == c.MaxWorkers
+		c.WorkerPoolState.Unlock()
+
+		if fixedSize {
+			lastDataTotal = warc.DataTotal.Value()
+			continue
+		}
+
+		runtime.ReadMemStats(&m)
+
+		// The data written to WARC is used as a measure of the bandwidth
+		var (
+			workers   = c.getWorkersCount()
+			active    = int(c.ActiveWorkers.Value())
+			step      = workers/10 + 1
+			dataTotal = warc.DataTotal.Value()
+			bandwidth = (dataTotal - lastDataTotal) / int64(workerPoolScalingInterval.Seconds())
+			target    = workers
+			reason    string
+		)
+
+		lastDataTotal = dataTotal
+
+		switch {
+		case c.MaxMemory > 0 && m.HeapInuse > uint64(c.MaxMemory)*MB:
+			target, reason = workers-step, "memory usage above --max-memory"
+		case c.MaxBandwidth > 0 && bandwidth > int64(c.MaxBandwidth)*MB:
+			target, reason = workers-step, "bandwidth above --max-bandwidth"
+		case c.MaxBandwidth > 0 && bandwidth > int64(c.MaxBandwidth)*MB*9/10:
+			// Close to the bandwidth limit, adding workers would only make us reach it
+			continue
+		case active >= workers-workers/10 && (c.UseHQ || c.Frontier.QueueCount.Value() > int64(workers)):
+			target, reason = workers+step, "all workers busy with URLs waiting in the queue"
+		case active < workers/2:
+			target, reason = workers-step, "idle workers"
+		}
+
+		if target == workers {
+			continue
+		}
+
+		c.setWorkersCount(target)
+
+		if c.getWorkersCount() != workers {
+			logInfo.WithFields(c.genLogFields(nil, nil, map[string]interface{}{
+				"previousWorkers": workers,
+				"workers":         c.getWorkersCount(),
+				"reason":          reason,
+			})).Info("worker pool resized")
+		}
+	}
+}
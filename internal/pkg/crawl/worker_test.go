@@ -0,0 +1,39 @@
+package crawl
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/internetarchive/Zeno/internal/pkg/frontier"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPullItemMaxConsecutivePerHost(t *testing.T) {
+	c := &Crawl{
+		Frontier:              &frontier.Frontier{PullChan: make(chan *frontier.Item, 8)},
+		MaxConsecutivePerHost: 2,
+	}
+
+	for _, rawURL := range []string{"http://a/1", "http://a/2", "http://a/3", "http://b/1", "http://a/4", "http://a/5", "http://a/6"} {
+		URL, err := url.Parse(rawURL)
+		assert.NoError(t, err)
+
+		c.Frontier.PullChan <- frontier.NewItem(URL, nil, "seed", 0, "", false)
+	}
+
+	var (
+		fairness hostFairness
+		stop     = make(chan struct{})
+		pulled   []string
+	)
+
+	for i := 0; i < 7; i++ {
+		pulled = append(pulled, c.pullItem(stop, &fairness).URL.String())
+	}
+
+	// a/3 waits for b/1, a/5 and a/6 are then captured in a row since nothing else is waiting
+	assert.Equal(t, []string{"http://a/1", "http://a/2", "http://b/1", "http://a/3", "http://a/4", "http://a/5", "http://a/6"}, pulled)
+
+	close(stop)
+	assert.Nil(t, c.pullItem(stop, &fairness))
+}
@@ -32,6 +32,8 @@ type Frontier struct {
 	Queue *goque.PrefixQueue
 	// QueueCount store the number of URLs currently queued
 	QueueCount *ratecounter.Counter
+	// QueueAge keeps track of how long items wait in the queue
+	QueueAge *QueueAge
 
 	// HostPool is an struct that contains a map and a Mutex.
 	// the map contains all the different hosts that Zeno crawled,
@@ -42,6 +44,10 @@ type Frontier struct {
 	UseSeencheck bool
 	Seencheck    *Seencheck
 	LoggingChan  chan *FrontierLogMessage
+
+	// SeenFunc is called with the items dropped by the seencheck, so that
+	// the items delivered by crawl HQ or a queue backend are marked as done
+	SeenFunc func(item *Item)
 }
 
 type FrontierLogMessage struct {
@@ -69,6 +75,7 @@ func (f *Frontier) Init(jobPath string, loggingChan chan *FrontierLogMessage, wo
 
 	f.QueueCount = new(ratecounter.Counter)
 	f.QueueCount.Incr(int64(f.Queue.Length()))
+	f.QueueAge = new(QueueAge)
 
 	logrus.Info("persistent queue initialized")
 
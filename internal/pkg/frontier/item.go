@@ -2,6 +2,7 @@ package frontier
 
 import (
 	"net/url"
+	"time"
 
 	"github.com/internetarchive/Zeno/internal/pkg/utils"
 	"github.com/zeebo/xxh3"
@@ -10,15 +11,35 @@ import (
 // Item is crawl-able object
 type Item struct {
 	ID              string
+	CaptureID       string
 	Hash            uint64
 	Hop             uint8
 	Host            string
 	Type            string
 	Redirect        int
+	CrossHostRedir  int
+	Pagination      int
 	URL             *url.URL
 	ParentItem      *Item
 	LocallyCrawled  uint64
 	BypassSeencheck string
+	WorkerID        int
+	EnqueuedAt      time.Time
+	Hints           *LinkHints
+	Scope           string
+	Collection      string
+	NotAfter        time.Time
+	Urgent          bool
+	TimeGateChecked bool
+}
+
+// LinkHints describe the link an item has been discovered from, they are given
+// with the produced URLs so that they can be ranked without being fetched
+type LinkHints struct {
+	ContentType string
+	AnchorText  string
+	Heading     string
+	Rel         string
 }
 
 // NewItem initialize an *Item
@@ -29,12 +50,21 @@ func NewItem(URL *url.URL, parentItem *Item, itemType string, hop uint8, ID stri
 	if ID != "" {
 		item.ID = ID
 	}
+	// URLToString also punycode-encode the host of the URL, so it
+	// has to be called before storing the host of the item
+	item.Hash = xxh3.HashString(utils.URLToString(URL))
 	item.Host = URL.Host
 	item.Hop = hop
 	item.ParentItem = parentItem
-	item.Hash = xxh3.HashString(utils.URLToString(URL))
 	item.Type = itemType
 
+	// The scope, the collection and the expiry of a seed are inherited by all its descendants
+	if parentItem != nil {
+		item.Scope = parentItem.Scope
+		item.Collection = parentItem.Collection
+		item.NotAfter = parentItem.NotAfter
+	}
+
 	// The reason we are using a string instead of a bool is because
 	// gob's encode/decode doesn't properly support booleans
 	if bypassSeencheck {
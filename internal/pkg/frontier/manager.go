@@ -18,13 +18,18 @@ func (f *Frontier) writeItemsToQueue() {
 		}
 
 		// If --local-seencheck is enabled, then we check if the URI is in the
-		// seencheck DB before doing anything. If it is in it, we skip the item
-		if f.UseSeencheck {
+		// seencheck DB before doing anything. If it is in it, we skip the item,
+		// unless it is sent back to the queue on purpose, e.g. a demoted item
+		if f.UseSeencheck && item.BypassSeencheck != "true" {
 			hash := strconv.FormatUint(item.Hash, 10)
 			found, value := f.Seencheck.IsSeen(hash)
 			if !found || (value == "asset" && item.Type == "seed") {
 				f.Seencheck.Seen(hash, item.Type)
 			} else {
+				if f.SeenFunc != nil {
+					f.SeenFunc(item)
+				}
+
 				continue
 			}
 		}
@@ -34,6 +39,7 @@ func (f *Frontier) writeItemsToQueue() {
 		f.IncrHost(item.Host)
 
 		// Add the item to the host's queue
+		item.EnqueuedAt = time.Now()
 		_, err := f.Queue.EnqueueObject([]byte(item.Host), item)
 		if err != nil {
 			f.LoggingChan <- &FrontierLogMessage{
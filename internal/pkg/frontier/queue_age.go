@@ -0,0 +1,57 @@
+package frontier
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// queueAgeSamples is the number of most recent samples kept to compute the percentiles
+const queueAgeSamples = 10000
+
+// QueueAge keeps track of how long the most recent items
+// stayed in the queue before being dispatched to a worker
+type QueueAge struct {
+	sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// Add record the time an item spent in the queue
+func (q *QueueAge) Add(age time.Duration) {
+	q.Lock()
+	defer q.Unlock()
+
+	if len(q.samples) < queueAgeSamples {
+		q.samples = append(q.samples, age)
+		return
+	}
+
+	q.samples[q.next] = age
+	q.next = (q.next + 1) % queueAgeSamples
+}
+
+// Percentiles return the queue age at the given percentiles (between 0 and 1),
+// computed over the most recent samples
+func (q *QueueAge) Percentiles(percentiles ...float64) []time.Duration {
+	q.Lock()
+	sorted := make([]time.Duration, len(q.samples))
+	copy(sorted, q.samples)
+	q.Unlock()
+
+	results := make([]time.Duration, len(percentiles))
+	if len(sorted) == 0 {
+		return results
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	for i, percentile := range percentiles {
+		index := int(percentile * float64(len(sorted)-1))
+		results[i] = sorted[index]
+	}
+
+	return results
+}
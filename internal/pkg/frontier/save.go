@@ -8,8 +8,9 @@ import (
 )
 
 // Load take the path to the frontier's hosts pool and status dump
-// it decodes that file and load it in the job's frontier
-func (f *Frontier) Load() {
+// it decodes that file and load it in the job's frontier. It returns an
+// error if the file exists but can't be decoded, the job can't be resumed then.
+func (f *Frontier) Load() error {
 	// Open a RO file
 	decodeFile, err := os.OpenFile(path.Join(f.JobPath, "frontier.gob"), os.O_RDONLY, 0644)
 	if err != nil {
@@ -21,18 +22,12 @@ func (f *Frontier) Load() {
 			Level:   logrus.WarnLevel,
 		}
 
-		return
+		return nil
 	}
 	defer decodeFile.Close()
 
 	if err := SyncMapDecode(f.HostPool, decodeFile); err != nil {
-		f.LoggingChan <- &FrontierLogMessage{
-			Fields: logrus.Fields{
-				"err": err.Error(),
-			},
-			Message: "unable to decode Frontier stats and host pool",
-			Level:   logrus.WarnLevel,
-		}
+		return err
 	}
 
 	f.LoggingChan <- &FrontierLogMessage{
@@ -42,6 +37,8 @@ func (f *Frontier) Load() {
 		Message: "successfully loaded previous frontier's hosts pool",
 		Level:   logrus.InfoLevel,
 	}
+
+	return nil
 }
 
 // Save write the in-memory hosts pool to resume properly the next time the job is loaded
@@ -0,0 +1,93 @@
+package frontier
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// Scope is the scope a seed gives to its descendants, it overrides the global scope
+// of the crawl. It is written after the URL of the seed in the seed list, either as
+// a regular expression matched against the URLs (regex:^https?://example\.com/blog/)
+// or as a list of SURT prefixes separated by spaces (surt:(com,example,)/blog/ (com,example,cdn,)).
+type Scope struct {
+	regex        *regexp.Regexp
+	SURTPrefixes []string
+}
+
+// ParseScope parse a scope expression
+func ParseScope(expression string) (*Scope, error) {
+	kind, value, found := strings.Cut(strings.TrimSpace(expression), ":")
+	if !found {
+		return nil, errors.New("scope expression must start with regex: or surt:")
+	}
+
+	switch kind {
+	case "regex":
+		regex, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Scope{regex: regex}, nil
+	case "surt":
+		prefixes := strings.Fields(strings.ToLower(value))
+		if len(prefixes) == 0 {
+			return nil, errors.New("empty SURT prefix list")
+		}
+
+		return &Scope{SURTPrefixes: prefixes}, nil
+	default:
+		return nil, fmt.Errorf("unknown scope expression type: %s", kind)
+	}
+}
+
+// Match return true if the URL is in the scope
+func (scope *Scope) Match(URL *url.URL) bool {
+	if scope.regex != nil {
+		return scope.regex.MatchString(utils.URLToString(URL))
+	}
+
+	SURT := utils.URLToSURT(URL)
+
+	for _, prefix := range scope.SURTPrefixes {
+		if strings.HasPrefix(SURT, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitSeedLine split a line of the seed list into the seed and its scope expression, if any
+func splitSeedLine(line string) (rawSeed string, scopeExpression string) {
+	trimmed := strings.TrimSpace(line)
+
+	index := strings.IndexAny(trimmed, " \t")
+	if index == -1 {
+		return line, ""
+	}
+
+	return trimmed[:index], strings.TrimSpace(trimmed[index:])
+}
+
+// splitSeedCollection split the collection of a seed, given as collection:<name>
+// before its scope expression, from the rest of the seed line
+func splitSeedCollection(annotations string) (collection string, scopeExpression string) {
+	if !strings.HasPrefix(annotations, "collection:") {
+		return "", annotations
+	}
+
+	collection = strings.TrimPrefix(annotations, "collection:")
+
+	index := strings.IndexAny(collection, " \t")
+	if index == -1 {
+		return collection, ""
+	}
+
+	return collection[:index], strings.TrimSpace(collection[index:])
+}
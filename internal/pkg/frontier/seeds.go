@@ -0,0 +1,275 @@
+package frontier
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/internetarchive/Zeno/internal/pkg/utils"
+)
+
+// Shorteners are the hosts of common URL shorteners, which only redirect to the actual URL
+var Shorteners = []string{"bit.ly", "t.co", "tinyurl.com", "goo.gl", "ow.ly", "buff.ly", "is.gd", "rebrand.ly", "cutt.ly", "shorturl.at", "tiny.cc", "lnkd.in", "dlvr.it", "fb.me", "youtu.be"}
+
+// regexOpaqueScheme matches the seeds like mailto:x, which have a scheme but no authority.
+// A host followed by a port (example.com:8080) isn't matched since the port is a number.
+var regexOpaqueScheme = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*):([^0-9]|$)`)
+
+// SeedChange is a seed that has been rewritten or rejected during the validation
+type SeedChange struct {
+	Input  string
+	Output string
+	Reason string
+}
+
+// SeedsValidation is the report of the validation and normalization of the seeds
+type SeedsValidation struct {
+	sync.Mutex
+	Total     int
+	Valid     int
+	Rewritten []SeedChange
+	Rejected  []SeedChange
+	Merged    []SeedChange
+}
+
+func (report *SeedsValidation) rewrite(input, output, reason string) {
+	report.Lock()
+	defer report.Unlock()
+
+	report.Rewritten = append(report.Rewritten, SeedChange{Input: input, Output: output, Reason: reason})
+}
+
+func (report *SeedsValidation) merge(input, output, reason string) {
+	report.Lock()
+	defer report.Unlock()
+
+	report.Merged = append(report.Merged, SeedChange{Input: input, Output: output, Reason: reason})
+}
+
+func (report *SeedsValidation) reject(input, reason string) {
+	report.Lock()
+	defer report.Unlock()
+
+	report.Rejected = append(report.Rejected, SeedChange{Input: input, Reason: reason})
+}
+
+// NormalizeSeed validate a raw seed and normalize it: the scheme is added if it is
+// missing, the scheme and the host are lowercased and the fragment is removed.
+// The returned reason explains the rewriting, it is empty if the seed is unchanged.
+func NormalizeSeed(rawSeed string) (URL *url.URL, reason string, err error) {
+	var reasons []string
+
+	seed := strings.TrimSpace(rawSeed)
+	if seed == "" {
+		return nil, "", errors.New("empty seed")
+	}
+
+	if seed != rawSeed {
+		reasons = append(reasons, "whitespace trimmed")
+	}
+
+	if !strings.Contains(seed, "://") {
+		// The default scheme is only added to the seeds without any scheme
+		if match := regexOpaqueScheme.FindStringSubmatch(seed); match != nil {
+			return nil, "", fmt.Errorf("unsupported scheme: %s", strings.ToLower(match[1]))
+		}
+
+		seed = "http://" + seed
+		reasons = append(reasons, "scheme added")
+	}
+
+	URL, err = url.Parse(seed)
+	if err != nil {
+		return nil, "", err
+	}
+
+	URL.Scheme = strings.ToLower(URL.Scheme)
+	if URL.Scheme != "http" && URL.Scheme != "https" && URL.Scheme != "gemini" && URL.Scheme != "gopher" {
+		return nil, "", fmt.Errorf("unsupported scheme: %s", URL.Scheme)
+	}
+
+	if URL.Host == "" {
+		return nil, "", errors.New("no host")
+	}
+
+	if strings.ToLower(URL.Host) != URL.Host {
+		URL.Host = strings.ToLower(URL.Host)
+		reasons = append(reasons, "host lowercased")
+	}
+
+	if URL.Fragment != "" {
+		URL.Fragment = ""
+		URL.RawFragment = ""
+		reasons = append(reasons, "fragment removed")
+	}
+
+	return URL, strings.Join(reasons, ", "), nil
+}
+
+// canonicalSeedKey return the key two seeds capturing the same resource share: the
+// default port is removed and an empty path is the root path
+func canonicalSeedKey(URL *url.URL) string {
+	canonical := *URL
+
+	if (canonical.Scheme == "http" && canonical.Port() == "80") || (canonical.Scheme == "https" && canonical.Port() == "443") {
+		canonical.Host = canonical.Hostname()
+	}
+
+	if canonical.Path == "" && canonical.Opaque == "" {
+		canonical.Path = "/"
+	}
+
+	return utils.URLToString(&canonical)
+}
+
+// DeduplicateSeeds remove the seeds that are the same resource as a previous seed once
+// normalized, the first occurrence (with its scope and collection) is kept and
+// the merged duplicates are added to the report
+func DeduplicateSeeds(seeds []Item, report *SeedsValidation) []Item {
+	var (
+		seen         = make(map[string]string)
+		deduplicated = seeds[:0]
+	)
+
+	for _, seed := range seeds {
+		key := canonicalSeedKey(seed.URL)
+
+		if kept, exists := seen[key]; exists {
+			reason := "duplicate"
+			if seed.Scope != "" || seed.Collection != "" {
+				reason = "duplicate, the scope and collection of the first occurrence are kept"
+			}
+
+			report.merge(utils.URLToString(seed.URL), kept, reason)
+			continue
+		}
+
+		seen[key] = utils.URLToString(seed.URL)
+		deduplicated = append(deduplicated, seed)
+	}
+
+	return deduplicated
+}
+
+// shortenerClient is the client the URL shorteners are resolved with,
+// it doesn't follow the redirections so that every hop is seen
+var shortenerClient = &http.Client{
+	Timeout: 10 * time.Second,
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// IsShortener return true if the URL is on the host of a known URL shortener
+func IsShortener(URL *url.URL) bool {
+	return utils.StringInSlice(strings.TrimPrefix(URL.Hostname(), "www."), Shorteners)
+}
+
+// ResolveShortener follow the redirections of a URL shortener as long as they point to
+// a shortener, and return the chain of the URLs it redirected to, the last one being
+// its destination. The chain is empty if the shortener couldn't be resolved.
+func ResolveShortener(URL *url.URL, userAgent string) (chain []*url.URL) {
+	for hops := 0; hops < 5 && IsShortener(URL); hops++ {
+		req, err := http.NewRequest("HEAD", utils.URLToString(URL), nil)
+		if err != nil {
+			break
+		}
+
+		req.Header.Set("User-Agent", userAgent)
+
+		resp, err := shortenerClient.Do(req)
+		if err != nil {
+			break
+		}
+		resp.Body.Close()
+
+		location, err := resp.Location()
+		if err != nil {
+			break
+		}
+
+		URL = location
+		chain = append(chain, URL)
+	}
+
+	return chain
+}
+
+// ResolveShorteners replace the seeds that are URL shorteners by the URL they
+// redirect to, the redirections are followed as long as they point to a shortener
+func ResolveShorteners(seeds []Item, report *SeedsValidation, userAgent string) {
+	for i := range seeds {
+		if !IsShortener(seeds[i].URL) {
+			continue
+		}
+
+		input := utils.URLToString(seeds[i].URL)
+
+		chain := ResolveShortener(seeds[i].URL, userAgent)
+		if len(chain) > 0 {
+			URL := chain[len(chain)-1]
+			scope, collection := seeds[i].Scope, seeds[i].Collection
+			seeds[i] = *NewItem(URL, nil, "seed", 0, "", false)
+			seeds[i].Scope = scope
+			seeds[i].Collection = collection
+			report.rewrite(input, utils.URLToString(URL), "shortener resolved")
+		}
+	}
+}
+
+// Write dump the rewritten, merged and rejected seeds as a CSV file in the job's directory
+func (report *SeedsValidation) Write(jobPath string) error {
+	report.Lock()
+	defer report.Unlock()
+
+	err := os.MkdirAll(jobPath, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path.Join(jobPath, "seeds-validation.csv"))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+
+	err = writer.Write([]string{"input", "status", "output", "reason"})
+	if err != nil {
+		return err
+	}
+
+	for _, change := range report.Rewritten {
+		err = writer.Write([]string{change.Input, "rewritten", change.Output, change.Reason})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, change := range report.Merged {
+		err = writer.Write([]string{change.Input, "merged", change.Output, change.Reason})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, change := range report.Rejected {
+		err = writer.Write([]string{change.Input, "rejected", "", change.Reason})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
@@ -5,8 +5,8 @@ import (
 	"encoding/gob"
 	"errors"
 	"fmt"
-	"net/url"
 	"os"
+	"strings"
 	"sync"
 
 	"github.com/gosuri/uilive"
@@ -14,22 +14,25 @@ import (
 )
 
 // IsSeedList validates if the path is a seed list, and return an array of
-// frontier.Item made of the seeds if it can
-func IsSeedList(path string) (seeds []Item, err error) {
-	var totalCount, validCount int
+// frontier.Item made of the seeds if it can, the seeds are normalized and
+// the report of the rewritten or rejected seeds is returned. A seed can be
+// followed by the collection its captures are written to, as collection:<name>,
+// and by the scope expression of its descendants, see ParseScope.
+func IsSeedList(path string) (seeds []Item, report *SeedsValidation, err error) {
+	report = new(SeedsValidation)
 	writer := uilive.New()
 	writer.Start()
 
 	// Verify that the file exist
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		// File doesn't exist
-		return seeds, err
+		return seeds, report, err
 	}
 
 	// Open the file
 	file, err := os.Open(path)
 	if err != nil {
-		return seeds, err
+		return seeds, report, err
 	}
 	defer file.Close()
 
@@ -41,33 +44,56 @@ func IsSeedList(path string) (seeds []Item, err error) {
 	}).Info("Start reading input list")
 
 	for scanner.Scan() {
-		totalCount++
-		URL, err := url.Parse(scanner.Text())
+		// Skip empty lines
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		report.Total++
+
+		rawSeed, annotations := splitSeedLine(scanner.Text())
+		collection, scopeExpression := splitSeedCollection(annotations)
+
+		if scopeExpression != "" {
+			if _, err := ParseScope(scopeExpression); err != nil {
+				report.reject(scanner.Text(), "invalid scope: "+err.Error())
+				continue
+			}
+		}
+
+		URL, reason, err := NormalizeSeed(rawSeed)
 		if err != nil {
 			logrus.WithFields(logrus.Fields{
-				"url": scanner.Text(),
+				"url": rawSeed,
 				"err": err.Error(),
 			}).Debug("this is not a valid URL")
+			report.reject(rawSeed, err.Error())
 			continue
 		}
 
+		if reason != "" {
+			report.rewrite(rawSeed, URL.String(), reason)
+		}
+
 		item := NewItem(URL, nil, "seed", 0, "", false)
+		item.Scope = scopeExpression
+		item.Collection = collection
 		seeds = append(seeds, *item)
-		validCount++
-		fmt.Fprintf(writer, "\t   Reading input list.. Found %d valid URLs out of %d URLs read.\n", validCount, totalCount)
+		report.Valid++
+		fmt.Fprintf(writer, "\t   Reading input list.. Found %d valid URLs out of %d URLs read.\n", report.Valid, report.Total)
 		writer.Flush()
 	}
 	writer.Stop()
 
 	if err := scanner.Err(); err != nil {
-		return seeds, err
+		return seeds, report, err
 	}
 
 	if len(seeds) == 0 {
-		return seeds, errors.New("seed list's content invalid")
+		return seeds, report, errors.New("seed list's content invalid")
 	}
 
-	return seeds, nil
+	return seeds, report, nil
 }
 
 type Pair struct {
@@ -0,0 +1,201 @@
+// Package testsite generates fake websites served by an httptest.Server, to run
+// integration tests and benchmarks against a site of a known shape: a tree of
+// pages of a configurable depth, with their assets, redirection chains in front
+// of the pages and an optional crawler trap.
+package testsite
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+)
+
+// Config describes the generated website
+type Config struct {
+	// Depth is the number of levels of pages below the home page
+	Depth int
+
+	// LinksPerPage is the number of child pages linked by every page above the last level
+	LinksPerPage int
+
+	// AssetsPerPage is the number of images referenced by every page, they are unique to the page
+	AssetsPerPage int
+
+	// AssetSize is the size in bytes of every asset
+	AssetSize int
+
+	// Redirects is the length of the redirection chain in front of every page but the home page
+	Redirects int
+
+	// Trap adds an infinite chain of calendar pages (/calendar/0, /calendar/1...) linked from the home page
+	Trap bool
+}
+
+// DefaultConfig return the configuration of a small website of 111 pages
+func DefaultConfig() Config {
+	return Config{
+		Depth:         2,
+		LinksPerPage:  10,
+		AssetsPerPage: 5,
+		AssetSize:     1024,
+	}
+}
+
+// Pages return the number of pages of the website, without the trap
+func (config Config) Pages() (pages int) {
+	level := 1
+
+	for depth := 0; depth <= config.Depth; depth++ {
+		pages += level
+		level *= config.LinksPerPage
+	}
+
+	return pages
+}
+
+// URIs return the number of URIs to capture to get the whole website, without the
+// trap: the pages, their assets and the redirections in front of the pages
+func (config Config) URIs() int {
+	pages := config.Pages()
+
+	return pages + pages*config.AssetsPerPage + (pages-1)*config.Redirects
+}
+
+// New start a server serving the website, it must be closed by the caller
+func New(config Config) *httptest.Server {
+	return httptest.NewServer(Handler(config))
+}
+
+// Handler return the handler serving the website, to use it with a custom server
+func Handler(config Config) http.Handler {
+	site := &site{config: config, asset: []byte(strings.Repeat("0", config.AssetSize))}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", site.serveHome)
+	mux.HandleFunc("/p/", site.servePage)
+	mux.HandleFunc("/r/", site.serveRedirect)
+	mux.HandleFunc("/a/", site.serveAsset)
+
+	if config.Trap {
+		mux.HandleFunc("/calendar/", site.serveCalendar)
+	}
+
+	return mux
+}
+
+type site struct {
+	config Config
+	asset  []byte
+}
+
+func (s *site) serveHome(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var extra string
+	if s.config.Trap {
+		extra = `<a href="/calendar/0">Calendar</a>`
+	}
+
+	s.writePage(w, "/p", nil, extra)
+}
+
+// servePage serve the pages of the tree, their path is the index of the page at
+// every level: /p/3/7 is the 8th child of the 4th child of the home page
+func (s *site) servePage(w http.ResponseWriter, r *http.Request) {
+	var indexes []int
+
+	for _, component := range strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/p"), "/"), "/") {
+		index, err := strconv.Atoi(component)
+		if err != nil || index < 0 || index >= s.config.LinksPerPage {
+			http.NotFound(w, r)
+			return
+		}
+
+		indexes = append(indexes, index)
+	}
+
+	if len(indexes) == 0 || len(indexes) > s.config.Depth {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.writePage(w, r.URL.Path, indexes, "")
+}
+
+func (s *site) writePage(w http.ResponseWriter, pagePath string, indexes []int, extra string) {
+	var body strings.Builder
+
+	fmt.Fprintf(&body, "<!DOCTYPE html><html><head><title>Page %s</title></head><body>", pagePath)
+
+	for i := 0; i < s.config.AssetsPerPage; i++ {
+		fmt.Fprintf(&body, `<img src="/a%s/%d.png">`, pagePath, i)
+	}
+
+	if len(indexes) < s.config.Depth {
+		for i := 0; i < s.config.LinksPerPage; i++ {
+			fmt.Fprintf(&body, `<a href="%s">Page %d</a>`, s.linkTo(fmt.Sprintf("%s/%d", pagePath, i)), i)
+		}
+	}
+
+	body.WriteString(extra)
+	body.WriteString("</body></html>")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(body.String()))
+}
+
+// linkTo return the link to a page, through the redirection chain if there is one
+func (s *site) linkTo(pagePath string) string {
+	if s.config.Redirects == 0 {
+		return pagePath
+	}
+
+	return fmt.Sprintf("/r/%d%s", s.config.Redirects, pagePath)
+}
+
+// serveRedirect serve the redirection chains: /r/N/p/... redirects to /r/N-1/p/...,
+// and /r/1/p/... to the page itself
+func (s *site) serveRedirect(w http.ResponseWriter, r *http.Request) {
+	rawCount, pagePath, found := strings.Cut(strings.TrimPrefix(r.URL.Path, "/r/"), "/")
+
+	count, err := strconv.Atoi(rawCount)
+	if !found || err != nil || count < 1 || count > s.config.Redirects {
+		http.NotFound(w, r)
+		return
+	}
+
+	target := "/" + pagePath
+	if count > 1 {
+		target = fmt.Sprintf("/r/%d/%s", count-1, pagePath)
+	}
+
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+func (s *site) serveAsset(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasSuffix(r.URL.Path, ".png") {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Content-Length", strconv.Itoa(len(s.asset)))
+	w.Write(s.asset)
+}
+
+// serveCalendar serve the trap: every calendar page links to the next one, forever
+func (s *site) serveCalendar(w http.ResponseWriter, r *http.Request) {
+	page, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/calendar/"))
+	if err != nil || page < 0 {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!DOCTYPE html><html><body><a href="/calendar/%d">Next month</a></body></html>`, page+1)
+}
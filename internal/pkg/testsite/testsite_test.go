@@ -0,0 +1,100 @@
+package testsite
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, URL string) (*http.Response, string) {
+	resp, err := http.Get(URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return resp, string(body)
+}
+
+func TestPages(t *testing.T) {
+	config := DefaultConfig()
+
+	assert.Equal(t, 111, config.Pages())
+	assert.Equal(t, 111+111*5, config.URIs())
+
+	config.Redirects = 2
+	assert.Equal(t, 111+111*5+110*2, config.URIs())
+}
+
+func TestTree(t *testing.T) {
+	server := New(DefaultConfig())
+	defer server.Close()
+
+	resp, body := get(t, server.URL)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 10, strings.Count(body, "<a href="))
+	assert.Equal(t, 5, strings.Count(body, "<img src="))
+
+	// The pages of the last level don't have children
+	resp, body = get(t, server.URL+"/p/9/9")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 0, strings.Count(body, "<a href="))
+
+	resp, _ = get(t, server.URL+"/p/9/9/9")
+	assert.Equal(t, 404, resp.StatusCode)
+
+	resp, body = get(t, server.URL+"/a/p/9/9/4.png")
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, 1024, len(body))
+}
+
+func TestRedirects(t *testing.T) {
+	config := DefaultConfig()
+	config.Redirects = 3
+
+	server := New(config)
+	defer server.Close()
+
+	_, body := get(t, server.URL)
+	assert.Contains(t, body, `<a href="/r/3/p/0">`)
+
+	var redirects int
+
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			redirects++
+			return nil
+		},
+	}
+
+	resp, err := client.Get(server.URL + "/r/3/p/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	assert.Equal(t, 3, redirects)
+	assert.Equal(t, "/p/0", resp.Request.URL.Path)
+}
+
+func TestTrap(t *testing.T) {
+	config := DefaultConfig()
+	config.Trap = true
+
+	server := New(config)
+	defer server.Close()
+
+	_, body := get(t, server.URL)
+	assert.Contains(t, body, `<a href="/calendar/0">`)
+
+	_, body = get(t, server.URL+"/calendar/41")
+	assert.Contains(t, body, `<a href="/calendar/42">`)
+}
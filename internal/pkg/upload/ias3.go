@@ -0,0 +1,111 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IAS3 upload the files to an archive.org item using the S3-like API,
+// the item is created with its metadata on the first upload
+type IAS3 struct {
+	Endpoint   string
+	AccessKey  string
+	SecretKey  string
+	Item       string
+	Collection string
+	MediaType  string
+	Subjects   []string
+
+	client *http.Client
+}
+
+// NewIAS3 return an archive.org S3 uploader for the given item
+func NewIAS3(endpoint, accessKey, secretKey, item, collection, mediaType string, subjects []string) *IAS3 {
+	return &IAS3{
+		Endpoint:   strings.TrimSuffix(endpoint, "/"),
+		AccessKey:  accessKey,
+		SecretKey:  secretKey,
+		Item:       item,
+		Collection: collection,
+		MediaType:  mediaType,
+		Subjects:   subjects,
+		client:     &http.Client{},
+	}
+}
+
+// Upload PUT the file in the item, the requests are retried
+// when the API asks to slow down or fails
+func (s *IAS3) Upload(filePath string) (err error) {
+	for retry := 0; retry < 5; retry++ {
+		if retry > 0 {
+			time.Sleep(time.Duration(retry*retry) * 10 * time.Second)
+		}
+
+		var retryable bool
+
+		retryable, err = s.put(filePath)
+		if err == nil || !retryable {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (s *IAS3) put(filePath string) (retryable bool, err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequest("PUT", s.Endpoint+"/"+url.PathEscape(s.Item)+"/"+url.PathEscape(path.Base(filePath)), file)
+	if err != nil {
+		return false, err
+	}
+
+	req.ContentLength = stat.Size()
+	req.Header.Set("Authorization", fmt.Sprintf("LOW %s:%s", s.AccessKey, s.SecretKey))
+	req.Header.Set("x-amz-auto-make-bucket", "1")
+	req.Header.Set("x-archive-size-hint", strconv.FormatInt(stat.Size(), 10))
+
+	if s.Collection != "" {
+		req.Header.Set("x-archive-meta-collection", s.Collection)
+	}
+
+	if s.MediaType != "" {
+		req.Header.Set("x-archive-meta-mediatype", s.MediaType)
+	}
+
+	for i, subject := range s.Subjects {
+		req.Header.Set(fmt.Sprintf("x-archive-meta%02d-subject", i+1), subject)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	// 503 is returned when the item is overloaded (SlowDown)
+	return resp.StatusCode >= 500, fmt.Errorf("upload of %s failed with status %d: %s", path.Base(filePath), resp.StatusCode, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,9 @@
+// Package upload holds the storage backends where the
+// finished WARC files and the reports of a crawl are uploaded
+package upload
+
+// Uploader is a storage backend, Upload is called once per file
+// and must be safe to call again if a previous upload failed
+type Uploader interface {
+	Upload(filePath string) error
+}
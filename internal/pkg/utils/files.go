@@ -6,8 +6,71 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
 )
 
+// MaxFileNameLength is the maximum length in bytes of the names sanitized by
+// SanitizeFileName, it leaves room for the suffixes added to them (timestamps,
+// serials, extensions) under the 255 bytes limit of most filesystems
+const MaxFileNameLength = 100
+
+// WindowsMaxPathLength is the maximum length of a path on Windows when long paths aren't enabled
+const WindowsMaxPathLength = 259
+
+// windowsReservedNames are the device names that can't be used as file names on Windows
+var windowsReservedNames = []string{"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9"}
+
+// SanitizeFileName turn a name into one that can be used as a file or directory
+// name on Linux, macOS and Windows, and in containers mounting Windows volumes:
+// the reserved characters are replaced by underscores, the trailing dots and spaces
+// are removed, the device names are prefixed and the name is truncated
+func SanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	base := strings.ToUpper(strings.SplitN(name, ".", 2)[0])
+	if StringInSlice(base, windowsReservedNames) {
+		name = "_" + name
+	}
+
+	for len(name) > MaxFileNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	name = strings.TrimRight(name, ". ")
+	if name == "" {
+		return "_"
+	}
+
+	return name
+}
+
+// CheckPathLength return false if a file of the given name length created in the
+// directory would exceed the maximum path length of Windows, on Windows only
+func CheckPathLength(directory string, nameLength int) bool {
+	if runtime.GOOS != "windows" {
+		return true
+	}
+
+	absolute, err := filepath.Abs(directory)
+	if err != nil {
+		return true
+	}
+
+	return len(absolute)+1+nameLength <= WindowsMaxPathLength
+}
+
 // FileExists checks if a file exists and is not a directory before we
 // try using it to prevent further errors
 func FileExists(filename string) bool {
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/spf13/afero"
@@ -15,3 +16,22 @@ func TestFileExists(t *testing.T) {
 	assert.True(t, true, FileExists("src/a"))
 	assert.False(t, false, FileExists("src/b"))
 }
+
+func TestSanitizeFileName(t *testing.T) {
+	names := map[string]string{
+		"my-job":                "my-job",
+		"news/2024: elections?": "news_2024_ elections_",
+		"trailing. ":            "trailing",
+		"CON":                   "_CON",
+		"aux.warc":              "_aux.warc",
+		"console":               "console",
+		"":                      "_",
+		"...":                   "_",
+		"tab\tname":             "tab_name",
+		strings.Repeat("é", 60): strings.Repeat("é", 50),
+	}
+
+	for name, expected := range names {
+		assert.Equal(t, expected, SanitizeFileName(name), name)
+	}
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+const journaldSocket = "/run/systemd/journal/socket"
+
+// JournaldHook is a logrus hook sending the log entries to systemd-journald
+// using its native protocol, the log fields are sent as journal fields
+type JournaldHook struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+// NewJournaldHook create a hook writing to the journald socket
+func NewJournaldHook() (*JournaldHook, error) {
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: "", Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournaldHook{
+		conn: conn,
+		addr: &net.UnixAddr{Name: journaldSocket, Net: "unixgram"},
+	}, nil
+}
+
+// Levels return the levels handled by the hook
+func (hook *JournaldHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire send the entry to journald
+func (hook *JournaldHook) Fire(entry *logrus.Entry) error {
+	var payload bytes.Buffer
+
+	writeJournaldField(&payload, "MESSAGE", entry.Message)
+	writeJournaldField(&payload, "PRIORITY", fmt.Sprint(syslogSeverity(entry.Level)))
+	writeJournaldField(&payload, "SYSLOG_IDENTIFIER", "zeno")
+
+	for key, value := range entry.Data {
+		writeJournaldField(&payload, journaldFieldName(key), fmt.Sprint(value))
+	}
+
+	_, err := hook.conn.WriteToUnix(payload.Bytes(), hook.addr)
+
+	return err
+}
+
+// writeJournaldField write a field using the journal native protocol, values
+// containing a newline are written using the binary-safe serialization
+func writeJournaldField(payload *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		payload.WriteString(name + "=" + value + "\n")
+		return
+	}
+
+	payload.WriteString(name + "\n")
+	binary.Write(payload, binary.LittleEndian, uint64(len(value)))
+	payload.WriteString(value + "\n")
+}
+
+// journaldFieldName turns a log field name like statusCode into a valid
+// journal field name like STATUS_CODE
+func journaldFieldName(name string) string {
+	var builder strings.Builder
+
+	for i, r := range name {
+		switch {
+		case unicode.IsUpper(r) && i > 0:
+			builder.WriteRune('_')
+			builder.WriteRune(r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(unicode.ToUpper(r))
+		default:
+			builder.WriteRune('_')
+		}
+	}
+
+	// Fields starting with an underscore are reserved to journald
+	return strings.TrimLeft(builder.String(), "_")
+}
@@ -0,0 +1,14 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournaldFieldName(t *testing.T) {
+	assert.Equal(t, "STATUS_CODE", journaldFieldName("statusCode"))
+	assert.Equal(t, "URL", journaldFieldName("url"))
+	assert.Equal(t, "HQ_PROJECT", journaldFieldName("hqProject"))
+	assert.Equal(t, "ERR_FUNC", journaldFieldName("_errFunc"))
+}
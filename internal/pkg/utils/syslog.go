@@ -0,0 +1,172 @@
+package utils
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// syslogStructuredDataID is the SD-ID used for the structured data of the
+// RFC5424 messages, 32473 is the private enterprise number reserved for documentation
+const syslogStructuredDataID = "zeno@32473"
+
+// SyslogHook is a logrus hook sending the log entries to a syslog server
+// as RFC5424 messages, with the log fields as structured data
+type SyslogHook struct {
+	sync.Mutex
+	network  string
+	address  string
+	hostname string
+	conn     net.Conn
+}
+
+// NewSyslogHook create a hook sending the log entries to the given address,
+// the address is an URL like udp://127.0.0.1:514, tcp://127.0.0.1:514 or unix:///dev/log
+func NewSyslogHook(address string) (*SyslogHook, error) {
+	URL, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := &SyslogHook{
+		network:  URL.Scheme,
+		address:  URL.Host,
+		hostname: GetHostname(),
+	}
+
+	switch hook.network {
+	case "udp", "tcp":
+	case "unix":
+		hook.network = "unixgram"
+		hook.address = URL.Path
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", URL.Scheme)
+	}
+
+	err = hook.connect()
+	if err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+func (hook *SyslogHook) connect() (err error) {
+	if hook.conn != nil {
+		hook.conn.Close()
+	}
+
+	hook.conn, err = net.Dial(hook.network, hook.address)
+
+	return err
+}
+
+// Levels return the levels handled by the hook
+func (hook *SyslogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire format the entry as a RFC5424 message and send it
+func (hook *SyslogHook) Fire(entry *logrus.Entry) error {
+	message := hook.format(entry)
+
+	// Over TCP, messages are framed using octet counting (RFC6587)
+	if hook.network == "tcp" {
+		message = fmt.Sprintf("%d %s", len(message), message)
+	}
+
+	hook.Lock()
+	defer hook.Unlock()
+
+	_, err := hook.conn.Write([]byte(message))
+	if err != nil {
+		// The syslog server may have been restarted, reconnect once and retry
+		if err = hook.connect(); err != nil {
+			return err
+		}
+
+		_, err = hook.conn.Write([]byte(message))
+	}
+
+	return err
+}
+
+func (hook *SyslogHook) format(entry *logrus.Entry) string {
+	var (
+		// Facility 3 is "system daemons"
+		priority       = 3*8 + syslogSeverity(entry.Level)
+		structuredData = "-"
+	)
+
+	if len(entry.Data) > 0 {
+		var keys []string
+
+		for key := range entry.Data {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		params := []string{syslogStructuredDataID}
+		for _, key := range keys {
+			params = append(params, fmt.Sprintf("%s=\"%s\"", syslogParamName(key), syslogParamValue(fmt.Sprint(entry.Data[key]))))
+		}
+
+		structuredData = "[" + strings.Join(params, " ") + "]"
+	}
+
+	return fmt.Sprintf("<%d>1 %s %s zeno %d - %s %s\n",
+		priority,
+		entry.Time.UTC().Format(time.RFC3339Nano),
+		hook.hostname,
+		os.Getpid(),
+		structuredData,
+		entry.Message,
+	)
+}
+
+func syslogSeverity(level logrus.Level) int {
+	switch level {
+	case logrus.PanicLevel:
+		return 1
+	case logrus.FatalLevel:
+		return 2
+	case logrus.ErrorLevel:
+		return 3
+	case logrus.WarnLevel:
+		return 4
+	case logrus.InfoLevel:
+		return 6
+	default:
+		return 7
+	}
+}
+
+// syslogParamName strips the characters that aren't allowed in a SD-PARAM name
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r <= 32 || r >= 127 || r == '=' || r == ']' || r == '"' {
+			return -1
+		}
+
+		return r
+	}, name)
+
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	return name
+}
+
+// syslogParamValue escapes the characters that need to be escaped in a SD-PARAM value
+func syslogParamValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(value)
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// SdNotify send a state to systemd using the sd_notify protocol, like READY=1 or
+// WATCHDOG=1. It returns false without error when not running as a notify service.
+func SdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// Sockets in the abstract namespace are given with a leading @
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// SdWatchdogInterval return the interval in which systemd expects the WATCHDOG=1
+// notifications (WatchdogSec= of the unit), or 0 if the watchdog isn't enabled for us
+func SdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	sent, err := SdNotify("READY=1")
+	assert.NoError(t, err)
+	assert.False(t, sent)
+
+	socket := path.Join(t.TempDir(), "notify.sock")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	sent, err = SdNotify("READY=1")
+	assert.NoError(t, err)
+	assert.True(t, sent)
+
+	buffer := make([]byte, 64)
+	n, err := conn.Read(buffer)
+	assert.NoError(t, err)
+	assert.Equal(t, "READY=1", string(buffer[:n]))
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "")
+	assert.Equal(t, time.Duration(0), SdWatchdogInterval())
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	assert.Equal(t, 30*time.Second, SdWatchdogInterval())
+
+	// The watchdog is meant for another process
+	t.Setenv("WATCHDOG_PID", "1")
+	assert.Equal(t, time.Duration(0), SdWatchdogInterval())
+}
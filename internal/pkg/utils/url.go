@@ -3,6 +3,7 @@ package utils
 import (
 	"errors"
 	"net/url"
+	"strings"
 
 	"github.com/asaskevich/govalidator"
 )
@@ -59,3 +60,30 @@ func ValidateURL(u *url.URL) error {
 
 	return nil
 }
+
+// URLToSURT return the SURT (Sort-friendly URI Reordering Transform) form of the URL
+// without its scheme, the labels of the host are reversed: (org,archive,www,)/details
+func URLToSURT(URL *url.URL) string {
+	labels := strings.Split(strings.ToLower(URL.Hostname()), ".")
+	for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+		labels[i], labels[j] = labels[j], labels[i]
+	}
+
+	SURT := "(" + strings.Join(labels, ",") + ","
+
+	if URL.Port() != "" {
+		SURT += ":" + URL.Port()
+	}
+
+	SURT += ")" + URL.EscapedPath()
+
+	if URL.EscapedPath() == "" {
+		SURT += "/"
+	}
+
+	if URL.RawQuery != "" {
+		SURT += "?" + URL.RawQuery
+	}
+
+	return strings.ToLower(SURT)
+}
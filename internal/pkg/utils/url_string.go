@@ -3,6 +3,7 @@ package utils
 import (
 	"net/url"
 	"strings"
+	"unicode/utf8"
 
 	"golang.org/x/net/idna"
 )
@@ -10,29 +11,111 @@ import (
 func URLToString(u *url.URL) string {
 	var err error
 
-	q := u.Query()
-	u.RawQuery = q.Encode()
-	u.Host, err = idna.ToASCII(u.Host)
+	// The URL is normalized on a copy, only the fields that changed are written
+	// back: the URL of a parent item is read by its assets captured concurrently
+	URL := *u
+
+	q := URL.Query()
+	URL.RawQuery = q.Encode()
+	URL.Host, err = hostToASCII(URL.Host)
 	if err != nil {
 		LogWarning.Warningf("could not IDNA encode URL: %s", err)
 	}
 
-	tempHost, err := idna.ToASCII(u.Hostname())
+	tempHost, err := hostToASCII(URL.Hostname())
 	if err != nil {
 		LogWarning.Warningf("could not IDNA encode URL: %s", err)
-		tempHost = u.Hostname()
+		tempHost = URL.Hostname()
 	}
 
 	if strings.Contains(tempHost, ":") && !(strings.HasPrefix(tempHost, "[") && strings.HasSuffix(tempHost, "]")) {
 		tempHost = "[" + tempHost + "]"
 	}
 
-	port := u.Port()
+	port := URL.Port()
 	if len(port) > 0 {
-		u.Host = tempHost + ":" + port
+		URL.Host = tempHost + ":" + port
 	} else {
-		u.Host = tempHost
+		URL.Host = tempHost
+	}
+
+	if u.RawQuery != URL.RawQuery {
+		u.RawQuery = URL.RawQuery
+	}
+
+	if u.Host != URL.Host {
+		u.Host = URL.Host
+	}
+
+	return URL.String()
+}
+
+// URLToUnicodeString return the URL as a string with its host decoded
+// from punycode, it is meant for logging only, the requests and the WARC
+// records always use the ASCII form given by URLToString
+func URLToUnicodeString(u *url.URL) string {
+	URL := *u
+
+	// The host is replaced in the ASCII form of the URL, whether it was parsed
+	// in punycode or in unicode, url.URL.String escaping the non-ASCII characters
+	ASCIIHost, err := hostToASCII(URL.Hostname())
+	if err != nil || strings.Contains(ASCIIHost, ":") {
+		return u.String()
+	}
+
+	host, err := idna.ToUnicode(ASCIIHost)
+	if err != nil {
+		return u.String()
+	}
+
+	if port := URL.Port(); len(port) > 0 {
+		ASCIIHost = ASCIIHost + ":" + port
+		host = host + ":" + port
+	}
+
+	URL.Host = ASCIIHost
+
+	// The host follows the user info when there is one
+	prefix := "//"
+	if URL.User != nil {
+		prefix = "@"
+	}
+
+	return strings.Replace(URL.String(), prefix+ASCIIHost, prefix+host, 1)
+}
+
+// IsIDN return true if the host of the URL is an internationalized domain name,
+// either in its unicode form or already encoded in punycode
+func IsIDN(u *url.URL) bool {
+	host := u.Hostname()
+
+	if strings.HasPrefix(host, "xn--") || strings.Contains(host, ".xn--") {
+		return true
+	}
+
+	for _, r := range host {
+		if r >= utf8.RuneSelf {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hostToASCII encode the host in punycode, hosts with non-ASCII characters
+// are mapped first (e.g. lowercased) so that the same domain written
+// differently always gives the same ASCII form
+func hostToASCII(host string) (string, error) {
+	for _, r := range host {
+		if r >= utf8.RuneSelf {
+			ASCIIHost, err := idna.Lookup.ToASCII(host)
+			if err == nil {
+				return ASCIIHost, nil
+			}
+
+			break
+		}
 	}
 
-	return u.String()
+	return idna.ToASCII(host)
 }
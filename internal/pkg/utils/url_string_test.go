@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLToStringIDN(t *testing.T) {
+	lowercase, _ := url.Parse("https://bücher.example/path")
+	uppercase, _ := url.Parse("https://BÜCHER.example/path")
+
+	assert.Equal(t, "https://xn--bcher-kva.example/path", URLToString(lowercase))
+	assert.Equal(t, URLToString(lowercase), URLToString(uppercase))
+}
+
+func TestURLToUnicodeString(t *testing.T) {
+	URL, _ := url.Parse("https://xn--bcher-kva.example:8080/path")
+
+	assert.True(t, IsIDN(URL))
+	assert.Equal(t, "https://bücher.example:8080/path", URLToUnicodeString(URL))
+
+	// The hosts already in unicode are given as is, not escaped
+	URL, _ = url.Parse("https://bücher.example/a")
+	assert.Equal(t, "https://bücher.example/a", URLToUnicodeString(URL))
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestURLToSURT(t *testing.T) {
+	URL, _ := url.Parse("https://www.Archive.org/details/Item?q=1")
+	assert.Equal(t, "(org,archive,www,)/details/item?q=1", URLToSURT(URL))
+
+	URL, _ = url.Parse("http://example.com:8080")
+	assert.Equal(t, "(com,example,:8080)/", URLToSURT(URL))
+}
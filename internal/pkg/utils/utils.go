@@ -16,7 +16,7 @@ import (
 var LogInfo, LogWarning, LogError *logrus.Logger
 
 // SetupLogging setup the logger for the crawl
-func SetupLogging(jobPath string, liveStats bool, esURL string) (logInfo, logWarning, logError *logrus.Logger) {
+func SetupLogging(jobPath string, liveStats bool, esURL string, syslogAddress string, journald bool) (logInfo, logWarning, logError *logrus.Logger) {
 	var logsDirectory = path.Join(jobPath, "logs")
 
 	hostname, err := os.Hostname()
@@ -64,6 +64,32 @@ func SetupLogging(jobPath string, liveStats bool, esURL string) (logInfo, logWar
 		}()
 	}
 
+	if syslogAddress != "" {
+		hook, err := NewSyslogHook(syslogAddress)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err.Error(),
+			}).Fatalln("failed to initialize syslog output")
+		}
+
+		logInfo.Hooks.Add(hook)
+		logWarning.Hooks.Add(hook)
+		logError.Hooks.Add(hook)
+	}
+
+	if journald {
+		hook, err := NewJournaldHook()
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"err": err.Error(),
+			}).Fatalln("failed to initialize journald output")
+		}
+
+		logInfo.Hooks.Add(hook)
+		logWarning.Hooks.Add(hook)
+		logError.Hooks.Add(hook)
+	}
+
 	// Create logs directory for the job
 	os.MkdirAll(logsDirectory, os.ModePerm)
 